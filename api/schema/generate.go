@@ -0,0 +1,159 @@
+/*
+ * Copyright 2026 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	apiv1 "github.com/ffromani/ctrreschk/api/v1"
+)
+
+const (
+	MetaSchema = "https://json-schema.org/draft/2020-12/schema"
+	BaseID     = "https://github.com/ffromani/ctrreschk/api/schema/"
+)
+
+// enums lists the values of the string types which are enumerations
+var enums = map[reflect.Type][]string{
+	reflect.TypeOf(apiv0.DeviceAffinityLevel("")): {
+		string(apiv0.DeviceAffinitySwitch),
+		string(apiv0.DeviceAffinityPCIERoot),
+		string(apiv0.DeviceAffinityNUMA),
+		string(apiv0.DeviceAffinityNone),
+	},
+	reflect.TypeOf(apiv0.SchedLevel("")): {
+		string(apiv0.SchedLevelCPUSet),
+		string(apiv0.SchedLevelSMT),
+		string(apiv0.SchedLevelLLC),
+		string(apiv0.SchedLevelNUMA),
+	},
+	reflect.TypeOf(apiv1.Status("")): {
+		string(apiv1.StatusAligned),
+		string(apiv1.StatusUnaligned),
+		string(apiv1.StatusSkipped),
+		string(apiv1.StatusError),
+	},
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Generate builds the JSON Schema of the document from the Go types, following the encoding/json rules.
+// The named structs are emitted as definitions. The objects allow additional properties, so consumers
+// keep working when new fields are added to the same API version.
+func Generate(doc Document) ([]byte, error) {
+	gen := generator{
+		defs: make(map[string]any),
+	}
+	root := gen.structSchema(doc.Type)
+	root["$schema"] = MetaSchema
+	root["$id"] = BaseID + doc.Path()
+	root["title"] = doc.Name()
+	if len(gen.defs) > 0 {
+		root["$defs"] = gen.defs
+	}
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+type generator struct {
+	defs map[string]any
+}
+
+func (gen *generator) typeSchema(typ reflect.Type) map[string]any {
+	if typ == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	if values, ok := enums[typ]; ok {
+		return map[string]any{"type": "string", "enum": values}
+	}
+	switch typ.Kind() {
+	case reflect.Pointer:
+		return map[string]any{"anyOf": []any{gen.typeSchema(typ.Elem()), map[string]any{"type": "null"}}}
+	case reflect.Struct:
+		if _, ok := gen.defs[typ.Name()]; !ok {
+			// placeholder to stop the recursion on self referencing types
+			gen.defs[typ.Name()] = nil
+			gen.defs[typ.Name()] = gen.structSchema(typ)
+		}
+		return map[string]any{"$ref": "#/$defs/" + typ.Name()}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": []string{"array", "null"}, "items": gen.typeSchema(typ.Elem())}
+	case reflect.Map:
+		ret := map[string]any{"type": []string{"object", "null"}, "additionalProperties": gen.typeSchema(typ.Elem())}
+		if isInteger(typ.Key().Kind()) {
+			ret["propertyNames"] = map[string]any{"pattern": "^-?[0-9]+$"}
+		}
+		return ret
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	}
+	if isInteger(typ.Kind()) {
+		return map[string]any{"type": "integer"}
+	}
+	// interfaces and everything else encoding/json can't describe further
+	return map[string]any{}
+}
+
+func (gen *generator) structSchema(typ reflect.Type) map[string]any {
+	props := make(map[string]any)
+	var required []string
+	for idx := 0; idx < typ.NumField(); idx++ {
+		field := typ.Field(idx)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		props[name] = gen.typeSchema(field.Type)
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			required = append(required, name)
+		}
+	}
+	ret := map[string]any{
+		"type":       "object",
+		"properties": props,
+	}
+	if len(required) > 0 {
+		ret["required"] = required
+	}
+	return ret
+}
+
+func isInteger(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
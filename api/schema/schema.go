@@ -0,0 +1,93 @@
+/*
+ * Copyright 2026 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	apiv1 "github.com/ffromani/ctrreschk/api/v1"
+)
+
+//go:generate go run ../../hack/genschema -o .
+
+// Document is a published JSON Schema document, describing the output of a command.
+type Document struct {
+	// Version is the API version, e.g. v0
+	Version string
+	Type    reflect.Type
+}
+
+// Name is the fully qualified type name, e.g. v0.Allocation
+func (doc Document) Name() string {
+	return doc.Version + "." + doc.Type.Name()
+}
+
+// Path is the path of the schema file, relative to this package
+func (doc Document) Path() string {
+	return path.Join(doc.Version, doc.Type.Name()+".json")
+}
+
+// Documents lists the published schemas, ordered by version
+var Documents = []Document{
+	{Version: "v0", Type: reflect.TypeOf(apiv0.Allocation{})},
+	{Version: "v0", Type: reflect.TypeOf(apiv0.CgroupStatsInfo{})},
+	{Version: "v0", Type: reflect.TypeOf(apiv0.CPUPowerInfo{})},
+	{Version: "v0", Type: reflect.TypeOf(apiv0.IOMMUInfo{})},
+	{Version: "v0", Type: reflect.TypeOf(apiv0.IRQAuditInfo{})},
+	{Version: "v0", Type: reflect.TypeOf(apiv0.NUMAMapsInfo{})},
+	{Version: "v0", Type: reflect.TypeOf(apiv0.PCIEInfo{})},
+	{Version: "v0", Type: reflect.TypeOf(apiv0.SchedInfo{})},
+	{Version: "v1", Type: reflect.TypeOf(apiv1.Allocation{})},
+	{Version: "v1", Type: reflect.TypeOf(apiv1.AllocationDiff{})},
+	{Version: "v1", Type: reflect.TypeOf(apiv1.AllocationSummary{})},
+}
+
+//go:embed v0/*.json v1/*.json
+var files embed.FS
+
+// Lookup finds the document by name. Names without the version, e.g. Allocation, refer to the latest version.
+func Lookup(name string) (Document, error) {
+	version, typeName, ok := strings.Cut(name, ".")
+	if !ok {
+		typeName = name
+		version = ""
+	}
+	for _, doc := range slices.Backward(Documents) {
+		if doc.Type.Name() != typeName {
+			continue
+		}
+		if version == "" || doc.Version == version {
+			return doc, nil
+		}
+	}
+	return Document{}, fmt.Errorf("unknown schema %q", name)
+}
+
+// Get returns the embedded schema of the document with the given name, see Lookup.
+func Get(name string) ([]byte, error) {
+	doc, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return files.ReadFile(doc.Path())
+}
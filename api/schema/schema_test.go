@@ -0,0 +1,76 @@
+/*
+ * Copyright 2026 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEmbeddedUpToDate(t *testing.T) {
+	for _, doc := range Documents {
+		t.Run(doc.Name(), func(t *testing.T) {
+			expected, err := Generate(doc)
+			if err != nil {
+				t.Fatalf("cannot generate: %v", err)
+			}
+			got, err := Get(doc.Name())
+			if err != nil {
+				t.Fatalf("cannot get the embedded schema: %v", err)
+			}
+			if string(got) != string(expected) {
+				t.Fatalf("embedded schema %s is stale, run go generate ./api/schema/...", doc.Path())
+			}
+			var decoded map[string]any
+			if err := json.Unmarshal(got, &decoded); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	testCases := []struct {
+		name        string
+		expected    string
+		expectedErr bool
+	}{
+		{name: "v0.Allocation", expected: "v0.Allocation"},
+		{name: "v1.Allocation", expected: "v1.Allocation"},
+		{name: "Allocation", expected: "v1.Allocation"},
+		{name: "NUMAMapsInfo", expected: "v0.NUMAMapsInfo"},
+		{name: "v1.NUMAMapsInfo", expectedErr: true},
+		{name: "Foobar", expectedErr: true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Lookup(tt.name)
+			if tt.expectedErr {
+				if err == nil {
+					t.Fatalf("expected error, got %s", doc.Name())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if doc.Name() != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, doc.Name())
+			}
+		})
+	}
+}
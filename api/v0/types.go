@@ -41,6 +41,10 @@ type AlignedInfo struct {
 	NUMA map[int]ContainerResourcesDetails `json:"numa,omitempty"`
 	// numacellid -> resources (memory NUMA nodes matching CPU NUMA nodes)
 	Memory map[int]ContainerResourcesDetails `json:"memory,omitempty"`
+	// pcieroot -> resources (devices whose PCIe root complex is local to all the container CPUs)
+	PCIERoot map[string]ContainerResourcesDetails `json:"pcieRoot,omitempty"`
+	// core class -> resources (e.g. performance, efficiency)
+	CoreType map[string]ContainerResourcesDetails `json:"coreType,omitempty"`
 }
 
 type UnalignedInfo struct {
@@ -49,20 +53,163 @@ type UnalignedInfo struct {
 	NUMA    ContainerResourcesDetails `json:"numa,omitempty"`
 	Memory  ContainerResourcesDetails `json:"memory,omitempty"`
 	Devices ContainerResourcesDetails `json:"devices,omitempty"`
+	// CPUs are the container CPUs not local to the PCIe root complex of the devices
+	PCIERoot ContainerResourcesDetails `json:"pcieRoot,omitempty"`
+	// Devices are the storage controllers backing the container mounts on NUMA nodes not including any container CPU
+	Storage ContainerResourcesDetails `json:"storage,omitempty"`
+	// CPUs are the container CPUs not in the core class holding most of the container CPUs
+	CoreType ContainerResourcesDetails `json:"coreType,omitempty"`
 }
 
 type Alignment struct {
-	SMT     bool  `json:"smt"`
-	LLC     bool  `json:"llc"`
-	NUMA    bool  `json:"numa"`
-	Memory  bool  `json:"memory"`
-	Devices *bool `json:"devices,omitempty"`
+	SMT      bool  `json:"smt"`
+	LLC      bool  `json:"llc"`
+	NUMA     bool  `json:"numa"`
+	Memory   bool  `json:"memory"`
+	Devices  *bool `json:"devices,omitempty"`
+	PCIERoot *bool `json:"pcieRoot,omitempty"`
+	// Storage is true if the storage controllers backing the container mounts are NUMA aligned with the container CPUs
+	Storage *bool `json:"storage,omitempty"`
+	// DeviceAffinity is true if all the devices share the same PCIe root complex or switch
+	DeviceAffinity *bool `json:"deviceAffinity,omitempty"`
+	// CoreType is true if all the container CPUs have the same core class (e.g. only performance cores on hybrid CPUs)
+	CoreType *bool `json:"coreType,omitempty"`
 }
 
+// DeviceAffinityLevel is the closest hardware component shared by a set of devices,
+// from the closest (switch) to the farthest (none).
+type DeviceAffinityLevel string
+
+const (
+	DeviceAffinitySwitch   DeviceAffinityLevel = "switch"
+	DeviceAffinityPCIERoot DeviceAffinityLevel = "pcieRoot"
+	DeviceAffinityNUMA     DeviceAffinityLevel = "numa"
+	DeviceAffinityNone     DeviceAffinityLevel = "none"
+)
+
+type DeviceAffinityInfo struct {
+	Level DeviceAffinityLevel `json:"level"`
+	// CommonAncestor is the closest bridge, or PCIe root complex, shared by all the devices
+	CommonAncestor string `json:"commonAncestor,omitempty"`
+	// pcieroot -> devices
+	PCIERoots map[string][]string `json:"pcieRoots,omitempty"`
+	// switch upstream port (the bridge below the root port) -> devices, omitting the devices attached directly to a root port
+	Switches map[string][]string `json:"switches,omitempty"`
+	// numacellid -> devices
+	NUMANodes map[int][]string `json:"numaNodes,omitempty"`
+}
+
+type PhysFnInfo struct {
+	// Address is the PCI address of the SR-IOV physical function
+	Address string `json:"address"`
+	NetDev  string `json:"netDev,omitempty"`
+	// NUMANode is -1 if unknown
+	NUMANode int    `json:"numaNode"`
+	PCIERoot string `json:"pcieRoot,omitempty"`
+	// VFIndex is the index of the virtual function in the physical function, -1 if unknown
+	VFIndex int `json:"vfIndex"`
+}
+
+type DeviceDetails struct {
+	PCIAddress string `json:"pciAddress"`
+	// Driver is the kernel driver the device is bound to, e.g. vfio-pci
+	Driver string `json:"driver,omitempty"`
+	// NUMANode is -1 if unknown
+	NUMANode int    `json:"numaNode"`
+	PCIERoot string `json:"pcieRoot,omitempty"`
+	NetDev   string `json:"netDev,omitempty"`
+	// BlockDevs are the block devices backed by the device
+	BlockDevs []string `json:"blockDevs,omitempty"`
+	// MountPoints are the container mount points backed by BlockDevs
+	MountPoints []string `json:"mountPoints,omitempty"`
+	// PhysFn is set only if the device is a SR-IOV virtual function
+	PhysFn *PhysFnInfo `json:"physFn,omitempty"`
+}
+
+// IsolationInfo reports how the kernel isolates the container CPUs from the system activities
+type IsolationInfo struct {
+	// Isolated are the container CPUs removed from the scheduler domains (isolcpus)
+	Isolated []int `json:"isolated,omitempty"`
+	// NoHZFull are the container CPUs which stop the scheduler tick (nohz_full)
+	NoHZFull []int `json:"nohzFull,omitempty"`
+	// RCUNoCBs are the container CPUs whose RCU callbacks are offloaded (rcu_nocbs)
+	RCUNoCBs []int `json:"rcuNoCBs,omitempty"`
+	// Housekeeping are the container CPUs which are neither isolated nor tickless
+	Housekeeping []int `json:"housekeeping,omitempty"`
+	// Mixed is true if the container has both isolated or tickless CPUs and housekeeping CPUs
+	Mixed bool `json:"mixed"`
+}
+
+type ResctrlDomainInfo struct {
+	// Domain is the resctrl domain ID, which is the L3 cache ID
+	Domain int `json:"domain"`
+	// CPUs are the container CPUs in the domain
+	CPUs []int `json:"cpus,omitempty"`
+	// CBM is the L3 cache capacity bitmask, in hex
+	CBM string `json:"cbm,omitempty"`
+	// CacheWays is the number of L3 cache ways allocated to the group
+	CacheWays int `json:"cacheWays,omitempty"`
+	// TotalCacheWays is the number of L3 cache ways which can be allocated
+	TotalCacheWays int `json:"totalCacheWays,omitempty"`
+	// MemoryBandwidth is the memory bandwidth allocated to the group, in percentage (MBps with the MBA software controller)
+	MemoryBandwidth int `json:"memoryBandwidth,omitempty"`
+}
+
+type ResctrlGroupInfo struct {
+	// Group is the resctrl group name, "/" for the default group
+	Group string `json:"group"`
+	Mode  string `json:"mode,omitempty"`
+	// Tasks are the container threads using the group
+	Tasks []int `json:"tasks,omitempty"`
+	// ViaCPUs is true if the tasks are in the default group and use this group because it owns,
+	// through its cpus_list, the container CPUs reported in Domains
+	ViaCPUs bool                `json:"viaCPUs,omitempty"`
+	Domains []ResctrlDomainInfo `json:"domains,omitempty"`
+}
+
+type ResctrlInfo struct {
+	Groups []ResctrlGroupInfo `json:"groups,omitempty"`
+	// Confined is true if all the container threads use the same, non-default, resctrl group on all the container CPUs
+	Confined bool `json:"confined"`
+}
+
+// LimitsInfo reports the cgroup CPU and memory limits, which in-place pod resize can change under a running container
+type LimitsInfo struct {
+	// CPUs is the size of the container cpuset
+	CPUs int `json:"cpus"`
+	// CPUQuotaMillis is the CPU quota in millicores, -1 if unlimited
+	CPUQuotaMillis int64 `json:"cpuQuotaMillis"`
+	CPUPeriodUS    int64 `json:"cpuPeriodUS,omitempty"`
+	CPUWeight      int64 `json:"cpuWeight,omitempty"`
+	// MemoryMaxBytes is -1 if unlimited, omitted if unknown
+	MemoryMaxBytes int64 `json:"memoryMaxBytes,omitempty"`
+	// Exclusive is true if the CPU quota is integral and equal to the cpuset size, like Guaranteed QoS pods with exclusive CPUs
+	Exclusive bool `json:"exclusive"`
+	// Throttled is true if the CPU quota is smaller than the cpuset, so the workload is throttled even if pinned
+	Throttled bool `json:"throttled"`
+}
+
+// The checks which can fail to gather the data they need, used as keys of Allocation.Errors
+const (
+	CheckDevices   = "devices"
+	CheckPCIERoot  = "pcieRoot"
+	CheckIsolation = "isolation"
+	CheckCoreType  = "coreType"
+	CheckLimits    = "limits"
+)
+
 type Allocation struct {
-	Alignment Alignment      `json:"alignment"`
-	Aligned   *AlignedInfo   `json:"aligned,omitempty"`
-	Unaligned *UnalignedInfo `json:"unaligned,omitempty"`
+	Alignment      Alignment           `json:"alignment"`
+	Aligned        *AlignedInfo        `json:"aligned,omitempty"`
+	Unaligned      *UnalignedInfo      `json:"unaligned,omitempty"`
+	DeviceAffinity *DeviceAffinityInfo `json:"deviceAffinity,omitempty"`
+	Devices        []DeviceDetails     `json:"devices,omitempty"`
+	Isolation      *IsolationInfo      `json:"isolation,omitempty"`
+	Resctrl        *ResctrlInfo        `json:"resctrl,omitempty"`
+	Limits         *LimitsInfo         `json:"limits,omitempty"`
+	// Errors maps the checks which could not gather the data they need to the reason.
+	// The results of these checks are partial, if any.
+	Errors map[string]string `json:"errors,omitempty"`
 }
 
 type NUMAMapsNodeInfo struct {
@@ -76,3 +223,245 @@ type NUMAMapsInfo struct {
 	RemotePages int64                    `json:"remotePages"`
 	Local       bool                     `json:"local"`
 }
+
+type PCIEDeviceInfo struct {
+	// Address is the PCI address in the extended BDF notation (Domain:Bus:Device.Function)
+	Address    string `json:"address"`
+	ClassID    string `json:"classID"`
+	SubclassID string `json:"subclassID"`
+	ProgIF     string `json:"progIF,omitempty"`
+	VendorID   string `json:"vendorID,omitempty"`
+	DeviceID   string `json:"deviceID,omitempty"`
+	ClassName  string `json:"className,omitempty"`
+	// Description is the most specific known name of the device class, e.g. "Non-Volatile memory controller (NVM Express)"
+	Description string `json:"description,omitempty"`
+	Driver      string `json:"driver,omitempty"`
+	// NUMANode is -1 if unknown
+	NUMANode  int              `json:"numaNode"`
+	LocalCPUs []int            `json:"localCPUs,omitempty"`
+	Children  []PCIEDeviceInfo `json:"children,omitempty"`
+}
+
+type PCIERootInfo struct {
+	// Root is the PCIe root complex, in the same format of the resource.kubernetes.io/pcieRoot attribute
+	Root    string           `json:"root"`
+	Devices []PCIEDeviceInfo `json:"devices,omitempty"`
+}
+
+type PCIEDomainInfo struct {
+	Root      string `json:"root"`
+	LocalCPUs []int  `json:"localCPUs,omitempty"`
+	// NUMANode is -1 if unknown
+	NUMANode int `json:"numaNode"`
+}
+
+type PCIEContainerInfo struct {
+	CPUs []int `json:"cpus,omitempty"`
+	// Roots lists the PCIe roots local to at least one of the container CPUs
+	Roots []string `json:"roots,omitempty"`
+	// OrphanedCPUs lists the container CPUs which are not local to any PCIe root
+	OrphanedCPUs []int `json:"orphanedCPUs,omitempty"`
+	// Local is true if all the container CPUs are local to at least one PCIe root
+	Local bool `json:"local"`
+}
+
+type PCIEInfo struct {
+	Roots   []PCIERootInfo   `json:"roots,omitempty"`
+	Domains []PCIEDomainInfo `json:"domains,omitempty"`
+	// OrphanedCPUs lists the online CPUs which are not local to any PCIe root
+	OrphanedCPUs []int              `json:"orphanedCPUs,omitempty"`
+	Container    *PCIEContainerInfo `json:"container,omitempty"`
+}
+
+type IOMMUDeviceInfo struct {
+	Address    string `json:"address"`
+	ClassID    string `json:"classID"`
+	SubclassID string `json:"subclassID"`
+	Driver     string `json:"driver,omitempty"`
+	// VFIO is true if the device is bound to vfio-pci or to one of its vendor variants
+	VFIO bool `json:"vfio"`
+	// NUMANode is -1 if unknown
+	NUMANode int    `json:"numaNode"`
+	PCIERoot string `json:"pcieRoot,omitempty"`
+	// Allocated is true if the device is allocated to the container
+	Allocated bool `json:"allocated"`
+}
+
+type IOMMUGroupInfo struct {
+	Group string `json:"group"`
+	// Viable is true if the group is usable through vfio, which requires all the devices in the group to be vfio compatible
+	Viable  bool              `json:"viable"`
+	Devices []IOMMUDeviceInfo `json:"devices,omitempty"`
+}
+
+type IOMMUInfo struct {
+	Groups []IOMMUGroupInfo `json:"groups,omitempty"`
+	// Unresolved are the allocated devices or vfio groups not found in the IOMMU groups of the system
+	Unresolved []string `json:"unresolved,omitempty"`
+}
+
+type IRQInfo struct {
+	IRQ  int    `json:"irq"`
+	Name string `json:"name,omitempty"`
+	// Affinity is the set of CPUs the IRQ is allowed to land on
+	Affinity []int `json:"affinity,omitempty"`
+	// EffectiveAffinity is the set of CPUs the IRQ is actually routed to
+	EffectiveAffinity []int `json:"effectiveAffinity,omitempty"`
+	// Count is the number of interrupts served by the container CPUs since boot
+	Count uint64 `json:"count"`
+}
+
+type IRQDeviceInfo struct {
+	PCIAddress string `json:"pciAddress"`
+	// LocalCPUs are the CPUs local to the device
+	LocalCPUs []int `json:"localCPUs,omitempty"`
+	IRQs      []int `json:"irqs,omitempty"`
+	// NonLocal are the device IRQs routed to CPUs not local to the device
+	NonLocal []IRQInfo `json:"nonLocal,omitempty"`
+}
+
+type IRQAuditInfo struct {
+	CPUs []int `json:"cpus"`
+	// IRQs are the IRQs which can land on the container CPUs
+	IRQs    []IRQInfo       `json:"irqs,omitempty"`
+	Devices []IRQDeviceInfo `json:"devices,omitempty"`
+	// Clean is true if no IRQ can land on the container CPUs and all the container devices IRQs are local
+	Clean bool `json:"clean"`
+}
+
+type CPUIdleStateInfo struct {
+	Name string `json:"name"`
+	// LatencyUS is the exit latency in microseconds
+	LatencyUS int64 `json:"latencyUS"`
+	Disabled  bool  `json:"disabled"`
+}
+
+type CPUPowerStateInfo struct {
+	CPU                         int                `json:"cpu"`
+	Driver                      string             `json:"driver,omitempty"`
+	Governor                    string             `json:"governor,omitempty"`
+	EnergyPerformancePreference string             `json:"energyPerformancePreference,omitempty"`
+	MinFreqKHz                  int64              `json:"minFreqKHz,omitempty"`
+	MaxFreqKHz                  int64              `json:"maxFreqKHz,omitempty"`
+	HWMinFreqKHz                int64              `json:"hwMinFreqKHz,omitempty"`
+	HWMaxFreqKHz                int64              `json:"hwMaxFreqKHz,omitempty"`
+	IdleStates                  []CPUIdleStateInfo `json:"idleStates,omitempty"`
+}
+
+type CPUPowerDeviationInfo struct {
+	CPU       int    `json:"cpu"`
+	Attribute string `json:"attribute"`
+	Expected  string `json:"expected"`
+	Actual    string `json:"actual"`
+}
+
+type CPUPowerInfo struct {
+	CPUs   []int               `json:"cpus"`
+	States []CPUPowerStateInfo `json:"states,omitempty"`
+	// Heterogeneous maps the attributes which differ across the container CPUs to the CPUs having each value
+	Heterogeneous map[string]map[string][]int `json:"heterogeneous,omitempty"`
+	// Deviations are the differences from the desired profile
+	Deviations []CPUPowerDeviationInfo `json:"deviations,omitempty"`
+	// Consistent is true if all the container CPUs have the same configuration and match the desired profile
+	Consistent bool `json:"consistent"`
+}
+
+type CPUStatInfo struct {
+	UsageUS  uint64 `json:"usageUS"`
+	UserUS   uint64 `json:"userUS"`
+	SystemUS uint64 `json:"systemUS"`
+	// Periods is the number of CPU quota enforcement periods elapsed, 0 without CPU quota
+	Periods uint64 `json:"periods"`
+	// ThrottledPeriods is the number of periods the container ran out of CPU quota
+	ThrottledPeriods uint64 `json:"throttledPeriods"`
+	ThrottledUS      uint64 `json:"throttledUS"`
+}
+
+type PressureStallInfo struct {
+	// Avg10, Avg60 and Avg300 are the percentage of time stalled over the last 10, 60 and 300 seconds
+	Avg10  float64 `json:"avg10"`
+	Avg60  float64 `json:"avg60"`
+	Avg300 float64 `json:"avg300"`
+	// TotalUS is the cumulative stall time in microseconds
+	TotalUS uint64 `json:"totalUS"`
+}
+
+type PressureInfo struct {
+	// Some is the time at least one task stalled waiting for the resource
+	Some PressureStallInfo `json:"some"`
+	// Full is the time all the non-idle tasks stalled at once, if reported by the kernel
+	Full *PressureStallInfo `json:"full,omitempty"`
+}
+
+type StallRateInfo struct {
+	SomePercent float64 `json:"somePercent"`
+	FullPercent float64 `json:"fullPercent,omitempty"`
+}
+
+type CgroupRatesInfo struct {
+	IntervalMS int64 `json:"intervalMS"`
+	// UsageCPUs is the average number of CPUs used during the interval
+	UsageCPUs float64 `json:"usageCPUs"`
+	// ThrottledPeriodsPercent is the percentage of the CPU quota periods in which the container was throttled
+	ThrottledPeriodsPercent float64 `json:"throttledPeriodsPercent"`
+	ThrottledPeriods        uint64  `json:"throttledPeriods"`
+	ThrottledUS             uint64  `json:"throttledUS"`
+	// the stall rates are omitted if the pressure is unknown
+	CPUStall    *StallRateInfo `json:"cpuStall,omitempty"`
+	MemoryStall *StallRateInfo `json:"memoryStall,omitempty"`
+	IOStall     *StallRateInfo `json:"ioStall,omitempty"`
+}
+
+type CgroupStatsInfo struct {
+	CPU CPUStatInfo `json:"cpu"`
+	// the pressures are omitted if the kernel has PSI disabled
+	CPUPressure    *PressureInfo `json:"cpuPressure,omitempty"`
+	MemoryPressure *PressureInfo `json:"memoryPressure,omitempty"`
+	IOPressure     *PressureInfo `json:"ioPressure,omitempty"`
+	// Rates are set only if the statistics are sampled twice over an interval
+	Rates *CgroupRatesInfo `json:"rates,omitempty"`
+	// Throttled is true if the container ran out of CPU quota, during the interval if sampled, since its creation otherwise
+	Throttled bool `json:"throttled"`
+}
+
+// SchedLevel is a level of the CPU topology a thread can run outside of
+type SchedLevel string
+
+const (
+	SchedLevelCPUSet SchedLevel = "cpuset"
+	SchedLevelSMT    SchedLevel = "smt"
+	SchedLevelLLC    SchedLevel = "llc"
+	SchedLevelNUMA   SchedLevel = "numa"
+)
+
+type ThreadSchedInfo struct {
+	PID  int    `json:"pid"`
+	TID  int    `json:"tid"`
+	Comm string `json:"comm,omitempty"`
+	// Affinity are the CPUs the thread is allowed to run on
+	Affinity []int `json:"affinity,omitempty"`
+	// CPUs are the CPUs the thread was observed running on during the window
+	CPUs []int `json:"cpus"`
+	// Cores, LLCs and NUMANodes are the physical cores, last level caches and NUMA nodes of CPUs
+	Cores     []int `json:"cores,omitempty"`
+	LLCs      []int `json:"llcs,omitempty"`
+	NUMANodes []int `json:"numaNodes,omitempty"`
+	// Migrations is the number of migrations during the window, -1 if unknown
+	Migrations          int64  `json:"migrations"`
+	VoluntarySwitches   uint64 `json:"voluntarySwitches"`
+	InvoluntarySwitches uint64 `json:"involuntarySwitches"`
+	RunTimeNS           uint64 `json:"runTimeNS,omitempty"`
+	WaitTimeNS          uint64 `json:"waitTimeNS,omitempty"`
+	// Outside lists the levels at which the thread ran outside the groups allocated to the container
+	Outside []SchedLevel `json:"outside,omitempty"`
+}
+
+type SchedInfo struct {
+	CPUs     []int `json:"cpus"`
+	WindowMS int64 `json:"windowMS"`
+	Samples  int   `json:"samples"`
+	// Threads are ordered by thread ID
+	Threads []ThreadSchedInfo `json:"threads,omitempty"`
+	// Contained is true if no thread ran outside the groups allocated to the container at any level
+	Contained bool `json:"contained"`
+}
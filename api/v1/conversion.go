@@ -0,0 +1,201 @@
+/*
+ * Copyright 2026 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1
+
+import (
+	"strconv"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+)
+
+// FromV0 converts a v0 allocation. v0 omits the checks which could not run, so a missing
+// result is reported as skipped, unless v0 recorded the check failed to gather its data.
+func FromV0(alloc apiv0.Allocation, meta Metadata) Allocation {
+	aligned := alloc.Aligned
+	if aligned == nil {
+		aligned = &apiv0.AlignedInfo{}
+	}
+	unaligned := alloc.Unaligned
+	if unaligned == nil {
+		unaligned = &apiv0.UnalignedInfo{}
+	}
+
+	checks := []CheckResult{
+		boolCheck(CheckSMT, alloc.Alignment.SMT, intKeys(aligned.SMT), unaligned.SMT),
+		boolCheck(CheckLLC, alloc.Alignment.LLC, intKeys(aligned.LLC), unaligned.LLC),
+		boolCheck(CheckNUMA, alloc.Alignment.NUMA, intKeys(aligned.NUMA), unaligned.NUMA),
+	}
+
+	// v0 reports memory as unaligned also when the check didn't run, but a failed check always lists the memory nodes
+	if !alloc.Alignment.Memory && len(unaligned.Memory.NUMANodes) == 0 {
+		checks = append(checks, skippedCheck(CheckMemory, "no memory nodes assigned"))
+	} else {
+		checks = append(checks, boolCheck(CheckMemory, alloc.Alignment.Memory, intKeys(aligned.Memory), unaligned.Memory))
+	}
+
+	checks = append(checks,
+		optionalCheck(CheckDevices, alloc.Alignment.Devices, "no devices", deviceKeys(aligned.NUMA), unaligned.Devices),
+		optionalCheck(CheckPCIERoot, alloc.Alignment.PCIERoot, "no devices with known PCIe root", aligned.PCIERoot, unaligned.PCIERoot),
+		optionalCheck(CheckStorage, alloc.Alignment.Storage, "no storage controllers with known NUMA node", nil, unaligned.Storage),
+		optionalCheck(CheckDeviceAffinity, alloc.Alignment.DeviceAffinity, "less than two devices with known PCIe hierarchy", nil, apiv0.ContainerResourcesDetails{}),
+		optionalCheck(CheckCoreType, alloc.Alignment.CoreType, "homogeneous or unknown core types", aligned.CoreType, unaligned.CoreType),
+		isolationCheck(alloc.Isolation),
+		resctrlCheck(alloc.Resctrl),
+		cpuQuotaCheck(alloc.Limits),
+	)
+	for name, reason := range alloc.Errors {
+		setCheckError(checks, v0ErrorChecks[name], reason)
+	}
+
+	return Allocation{
+		APIVersion:     APIVersion,
+		Kind:           KindAllocation,
+		Metadata:       meta,
+		Status:         OverallStatus(checks),
+		Checks:         checks,
+		DeviceAffinity: alloc.DeviceAffinity,
+		Devices:        alloc.Devices,
+		Isolation:      alloc.Isolation,
+		Resctrl:        alloc.Resctrl,
+		Limits:         alloc.Limits,
+	}
+}
+
+// v0ErrorChecks maps the keys of the v0 errors to the checks
+var v0ErrorChecks = map[string]string{
+	apiv0.CheckDevices:   CheckDevices,
+	apiv0.CheckPCIERoot:  CheckPCIERoot,
+	apiv0.CheckIsolation: CheckIsolation,
+	apiv0.CheckCoreType:  CheckCoreType,
+	apiv0.CheckLimits:    CheckCPUQuota,
+}
+
+// setCheckError marks the check as failed, keeping its partial details. A mismatch found
+// on the data which could be gathered is more relevant than the failure, so unaligned is kept.
+func setCheckError(checks []CheckResult, name, reason string) {
+	for idx := range checks {
+		if checks[idx].Name != name || checks[idx].Status == StatusUnaligned {
+			continue
+		}
+		checks[idx].Status = StatusError
+		checks[idx].Reason = reason
+	}
+}
+
+func boolCheck(name string, aligned bool, alignedDets map[string]ResourcesDetails, unalignedDets apiv0.ContainerResourcesDetails) CheckResult {
+	res := CheckResult{
+		Name:    name,
+		Status:  StatusAligned,
+		Aligned: alignedDets,
+	}
+	if !aligned {
+		res.Status = StatusUnaligned
+		res.Unaligned = &unalignedDets
+	}
+	return res
+}
+
+func optionalCheck(name string, aligned *bool, skipReason string, alignedDets map[string]ResourcesDetails, unalignedDets apiv0.ContainerResourcesDetails) CheckResult {
+	if aligned == nil {
+		return skippedCheck(name, skipReason)
+	}
+	return boolCheck(name, *aligned, alignedDets, unalignedDets)
+}
+
+func skippedCheck(name, reason string) CheckResult {
+	return CheckResult{
+		Name:   name,
+		Status: StatusSkipped,
+		Reason: reason,
+	}
+}
+
+func isolationCheck(isol *apiv0.IsolationInfo) CheckResult {
+	if isol == nil {
+		return skippedCheck(CheckIsolation, "CPU isolation unknown")
+	}
+	res := CheckResult{
+		Name:   CheckIsolation,
+		Status: StatusAligned,
+	}
+	if isol.Mixed {
+		res.Status = StatusUnaligned
+		res.Reason = "isolated or tickless CPUs mixed with housekeeping CPUs"
+		res.Unaligned = &ResourcesDetails{CPUs: isol.Housekeeping}
+	}
+	return res
+}
+
+// resctrlCheck reports unaligned the containers without a dedicated class of service. Most containers run in the default
+// resctrl group by design, so the ones which don't need it should leave resctrl out of the policy.
+func resctrlCheck(info *apiv0.ResctrlInfo) CheckResult {
+	if info == nil {
+		return skippedCheck(CheckResctrl, "resctrl not inspected")
+	}
+	if !info.Confined {
+		return CheckResult{
+			Name:   CheckResctrl,
+			Status: StatusUnaligned,
+			Reason: "container threads not confined to a single non-default resctrl group, see the resctrl details",
+		}
+	}
+	return CheckResult{
+		Name:   CheckResctrl,
+		Status: StatusAligned,
+	}
+}
+
+func cpuQuotaCheck(limits *apiv0.LimitsInfo) CheckResult {
+	if limits == nil {
+		return skippedCheck(CheckCPUQuota, "CPU limits unknown: CPU controller not enabled")
+	}
+	res := CheckResult{
+		Name:   CheckCPUQuota,
+		Status: StatusAligned,
+	}
+	if limits.Throttled {
+		res.Status = StatusUnaligned
+		res.Reason = "CPU quota smaller than the cpuset, the workload is throttled"
+	}
+	return res
+}
+
+func intKeys(dets map[int]apiv0.ContainerResourcesDetails) map[string]ResourcesDetails {
+	if len(dets) == 0 {
+		return nil
+	}
+	ret := make(map[string]ResourcesDetails, len(dets))
+	for id, det := range dets {
+		ret[strconv.Itoa(id)] = det
+	}
+	return ret
+}
+
+// deviceKeys extracts the devices from the NUMA details, where v0 reports the aligned devices
+func deviceKeys(numaDets map[int]apiv0.ContainerResourcesDetails) map[string]ResourcesDetails {
+	ret := make(map[string]ResourcesDetails)
+	for id, det := range numaDets {
+		if len(det.Devices) == 0 {
+			continue
+		}
+		ret[strconv.Itoa(id)] = ResourcesDetails{Devices: det.Devices}
+	}
+	if len(ret) == 0 {
+		return nil
+	}
+	return ret
+}
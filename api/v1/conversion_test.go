@@ -0,0 +1,287 @@
+/*
+ * Copyright 2026 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+)
+
+func TestFromV0(t *testing.T) {
+	meta := Metadata{
+		Hostname:      "worker-0",
+		KernelVersion: "6.12.0",
+		Timestamp:     time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		ToolVersion:   "v0.0.20260102",
+	}
+	trueVal, falseVal := true, false
+
+	testCases := []struct {
+		name           string
+		alloc          apiv0.Allocation
+		expectedStatus Status
+		expectedChecks map[string]CheckResult
+	}{
+		{
+			name: "aligned, optional checks skipped",
+			alloc: apiv0.Allocation{
+				Alignment: apiv0.Alignment{SMT: true, LLC: true, NUMA: true},
+				Aligned: &apiv0.AlignedInfo{
+					NUMA: map[int]apiv0.ContainerResourcesDetails{0: {CPUs: []int{0, 16}}},
+				},
+			},
+			expectedStatus: StatusAligned,
+			expectedChecks: map[string]CheckResult{
+				CheckSMT:            {Name: CheckSMT, Status: StatusAligned},
+				CheckLLC:            {Name: CheckLLC, Status: StatusAligned},
+				CheckNUMA:           {Name: CheckNUMA, Status: StatusAligned, Aligned: map[string]ResourcesDetails{"0": {CPUs: []int{0, 16}}}},
+				CheckMemory:         {Name: CheckMemory, Status: StatusSkipped, Reason: "no memory nodes assigned"},
+				CheckDevices:        {Name: CheckDevices, Status: StatusSkipped, Reason: "no devices"},
+				CheckPCIERoot:       {Name: CheckPCIERoot, Status: StatusSkipped, Reason: "no devices with known PCIe root"},
+				CheckStorage:        {Name: CheckStorage, Status: StatusSkipped, Reason: "no storage controllers with known NUMA node"},
+				CheckDeviceAffinity: {Name: CheckDeviceAffinity, Status: StatusSkipped, Reason: "less than two devices with known PCIe hierarchy"},
+				CheckCoreType:       {Name: CheckCoreType, Status: StatusSkipped, Reason: "homogeneous or unknown core types"},
+				CheckIsolation:      {Name: CheckIsolation, Status: StatusSkipped, Reason: "CPU isolation unknown"},
+				CheckResctrl:        {Name: CheckResctrl, Status: StatusSkipped, Reason: "resctrl not inspected"},
+				CheckCPUQuota:       {Name: CheckCPUQuota, Status: StatusSkipped, Reason: "CPU limits unknown: CPU controller not enabled"},
+			},
+		},
+		{
+			name: "unaligned memory and devices",
+			alloc: apiv0.Allocation{
+				Alignment: apiv0.Alignment{SMT: true, LLC: true, NUMA: true, Memory: false, Devices: &falseVal, CoreType: &trueVal},
+				Aligned: &apiv0.AlignedInfo{
+					NUMA:     map[int]apiv0.ContainerResourcesDetails{0: {CPUs: []int{0, 16}, Devices: []string{"0000:05:10.2"}}},
+					CoreType: map[string]apiv0.ContainerResourcesDetails{"performance": {CPUs: []int{0, 16}}},
+				},
+				Unaligned: &apiv0.UnalignedInfo{
+					Memory:  apiv0.ContainerResourcesDetails{NUMANodes: []int{0, 1}, MemoryMiB: 1024},
+					Devices: apiv0.ContainerResourcesDetails{Devices: []string{"0000:85:00.0"}, NUMANodes: []int{1}},
+				},
+				Isolation: &apiv0.IsolationInfo{Isolated: []int{16}, Housekeeping: []int{0}, Mixed: true},
+				Limits:    &apiv0.LimitsInfo{CPUs: 2, CPUQuotaMillis: 1500, CPUPeriodUS: 100000, Throttled: true},
+				Resctrl:   &apiv0.ResctrlInfo{Confined: true},
+			},
+			expectedStatus: StatusUnaligned,
+			expectedChecks: map[string]CheckResult{
+				CheckMemory: {Name: CheckMemory, Status: StatusUnaligned, Unaligned: &ResourcesDetails{NUMANodes: []int{0, 1}, MemoryMiB: 1024}},
+				CheckDevices: {
+					Name:      CheckDevices,
+					Status:    StatusUnaligned,
+					Aligned:   map[string]ResourcesDetails{"0": {Devices: []string{"0000:05:10.2"}}},
+					Unaligned: &ResourcesDetails{Devices: []string{"0000:85:00.0"}, NUMANodes: []int{1}},
+				},
+				CheckCoreType: {Name: CheckCoreType, Status: StatusAligned, Aligned: map[string]ResourcesDetails{"performance": {CPUs: []int{0, 16}}}},
+				CheckIsolation: {
+					Name:      CheckIsolation,
+					Status:    StatusUnaligned,
+					Reason:    "isolated or tickless CPUs mixed with housekeeping CPUs",
+					Unaligned: &ResourcesDetails{CPUs: []int{0}},
+				},
+				CheckResctrl: {Name: CheckResctrl, Status: StatusAligned},
+				CheckCPUQuota: {
+					Name:   CheckCPUQuota,
+					Status: StatusUnaligned,
+					Reason: "CPU quota smaller than the cpuset, the workload is throttled",
+				},
+			},
+		},
+		{
+			name: "discovery failures",
+			alloc: apiv0.Allocation{
+				Alignment: apiv0.Alignment{SMT: true, LLC: true, NUMA: true, Devices: &trueVal, PCIERoot: &falseVal},
+				Unaligned: &apiv0.UnalignedInfo{
+					PCIERoot: apiv0.ContainerResourcesDetails{CPUs: []int{16}, Devices: []string{"0000:1b:00.0"}},
+				},
+				Errors: map[string]string{
+					apiv0.CheckDevices:   "devices not found: 0000:99:00.0",
+					apiv0.CheckPCIERoot:  "devices not found: 0000:99:00.0",
+					apiv0.CheckIsolation: "cannot detect CPU isolation: missing /proc/cmdline",
+					apiv0.CheckLimits:    "cannot read CPU limits: missing cpu.max",
+				},
+			},
+			expectedStatus: StatusUnaligned,
+			expectedChecks: map[string]CheckResult{
+				CheckDevices: {Name: CheckDevices, Status: StatusError, Reason: "devices not found: 0000:99:00.0"},
+				// the mismatch found on the other devices takes precedence
+				CheckPCIERoot:  {Name: CheckPCIERoot, Status: StatusUnaligned, Unaligned: &ResourcesDetails{CPUs: []int{16}, Devices: []string{"0000:1b:00.0"}}},
+				CheckIsolation: {Name: CheckIsolation, Status: StatusError, Reason: "cannot detect CPU isolation: missing /proc/cmdline"},
+				CheckCoreType:  {Name: CheckCoreType, Status: StatusSkipped, Reason: "homogeneous or unknown core types"},
+				CheckCPUQuota:  {Name: CheckCPUQuota, Status: StatusError, Reason: "cannot read CPU limits: missing cpu.max"},
+			},
+		},
+		{
+			name: "discovery failure without mismatches",
+			alloc: apiv0.Allocation{
+				Alignment: apiv0.Alignment{SMT: true, LLC: true, NUMA: true},
+				Errors: map[string]string{
+					apiv0.CheckCoreType: "cannot detect core types: invalid cpu_core cpus",
+				},
+			},
+			expectedStatus: StatusError,
+			expectedChecks: map[string]CheckResult{
+				CheckCoreType: {Name: CheckCoreType, Status: StatusError, Reason: "cannot detect core types: invalid cpu_core cpus"},
+			},
+		},
+		{
+			name: "default resctrl group is unaligned",
+			alloc: apiv0.Allocation{
+				Alignment: apiv0.Alignment{SMT: true, LLC: true, NUMA: true},
+				Resctrl: &apiv0.ResctrlInfo{
+					Groups: []apiv0.ResctrlGroupInfo{{Group: "/", Tasks: []int{1, 2}}},
+				},
+			},
+			expectedStatus: StatusUnaligned,
+			expectedChecks: map[string]CheckResult{
+				CheckResctrl: {Name: CheckResctrl, Status: StatusUnaligned, Reason: "container threads not confined to a single non-default resctrl group, see the resctrl details"},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FromV0(tt.alloc, meta)
+			if got.APIVersion != APIVersion || got.Kind != KindAllocation {
+				t.Errorf("unexpected type: %s %s", got.APIVersion, got.Kind)
+			}
+			if diff := cmp.Diff(meta, got.Metadata); diff != "" {
+				t.Errorf("unexpected metadata (-want +got):\n%s", diff)
+			}
+			if got.Status != tt.expectedStatus {
+				t.Errorf("expected status %q, got %q", tt.expectedStatus, got.Status)
+			}
+			if len(got.Checks) != 12 {
+				t.Errorf("expected all the 12 checks, got %d", len(got.Checks))
+			}
+			for name, exp := range tt.expectedChecks {
+				res, ok := got.Check(name)
+				if !ok {
+					t.Errorf("missing check %q", name)
+					continue
+				}
+				if diff := cmp.Diff(exp, res); diff != "" {
+					t.Errorf("unexpected check %q (-want +got):\n%s", name, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestSetError(t *testing.T) {
+	alloc := FromV0(apiv0.Allocation{Alignment: apiv0.Alignment{SMT: true, LLC: true, NUMA: true}}, Metadata{})
+	if alloc.Status != StatusAligned {
+		t.Fatalf("expected status %q, got %q", StatusAligned, alloc.Status)
+	}
+	alloc.SetError(CheckResctrl, "resctrl not mounted")
+	if alloc.Status != StatusError {
+		t.Errorf("expected status %q, got %q", StatusError, alloc.Status)
+	}
+	res, ok := alloc.Check(CheckResctrl)
+	if !ok || res.Status != StatusError || res.Reason != "resctrl not mounted" {
+		t.Errorf("unexpected check result: %+v", res)
+	}
+	if len(alloc.Checks) != 12 {
+		t.Errorf("expected the check to be replaced, got %d checks", len(alloc.Checks))
+	}
+
+	alloc.SetError("custom", "failed")
+	if len(alloc.Checks) != 13 {
+		t.Errorf("expected the check to be added, got %d checks", len(alloc.Checks))
+	}
+}
+
+func TestOverallStatus(t *testing.T) {
+	testCases := []struct {
+		name     string
+		statuses []Status
+		expected Status
+	}{
+		{name: "no checks", expected: StatusAligned},
+		{name: "skipped are ignored", statuses: []Status{StatusAligned, StatusSkipped}, expected: StatusAligned},
+		{name: "error", statuses: []Status{StatusAligned, StatusError, StatusSkipped}, expected: StatusError},
+		{name: "unaligned wins over error", statuses: []Status{StatusError, StatusUnaligned}, expected: StatusUnaligned},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var checks []CheckResult
+			for _, st := range tt.statuses {
+				checks = append(checks, CheckResult{Status: st})
+			}
+			if got := OverallStatus(checks); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSummary(t *testing.T) {
+	falseVal := false
+	alloc := FromV0(apiv0.Allocation{Alignment: apiv0.Alignment{SMT: true, LLC: true, NUMA: true, Devices: &falseVal}}, Metadata{})
+	got := alloc.Summary()
+	if got.APIVersion != APIVersion || got.Kind != KindAllocationSummary {
+		t.Errorf("unexpected type: %s %s", got.APIVersion, got.Kind)
+	}
+	if got.Status != StatusUnaligned {
+		t.Errorf("expected status %q, got %q", StatusUnaligned, got.Status)
+	}
+	if len(got.Checks) != len(alloc.Checks) {
+		t.Errorf("expected %d checks, got %d", len(alloc.Checks), len(got.Checks))
+	}
+	if got.Checks[CheckDevices] != StatusUnaligned || got.Checks[CheckSMT] != StatusAligned || got.Checks[CheckMemory] != StatusSkipped {
+		t.Errorf("unexpected checks: %v", got.Checks)
+	}
+}
+
+func TestViolations(t *testing.T) {
+	falseVal := false
+	alloc := FromV0(apiv0.Allocation{Alignment: apiv0.Alignment{SMT: false, LLC: true, NUMA: true, Devices: &falseVal}}, Metadata{})
+	alloc.SetError(CheckResctrl, "resctrl not mounted")
+
+	testCases := []struct {
+		name     string
+		policy   []string
+		expected []string
+	}{
+		{name: "empty policy", policy: nil},
+		{name: "aligned checks", policy: []string{CheckLLC, CheckNUMA}},
+		{name: "skipped checks", policy: []string{CheckMemory, CheckCoreType}},
+		{name: "unaligned check", policy: []string{CheckNUMA, CheckSMT}, expected: []string{CheckSMT}},
+		{name: "all", policy: []string{PolicyAll}, expected: []string{CheckSMT, CheckDevices, CheckResctrl}},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidatePolicy(tt.policy); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, res := range alloc.Violations(tt.policy) {
+				got = append(got, res.Name)
+			}
+			if diff := cmp.Diff(tt.expected, got); diff != "" {
+				t.Errorf("unexpected violations (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if err := ValidatePolicy([]string{CheckSMT, "foobar"}); err == nil {
+		t.Errorf("expected error for unknown check, got nil")
+	}
+}
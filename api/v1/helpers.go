@@ -0,0 +1,108 @@
+/*
+ * Copyright 2026 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1
+
+import (
+	"fmt"
+	"slices"
+)
+
+// PolicyAll requires all the checks to pass
+const PolicyAll = "all"
+
+// Check returns the result of the check with the given name.
+func (alloc Allocation) Check(name string) (CheckResult, bool) {
+	for _, res := range alloc.Checks {
+		if res.Name == name {
+			return res, true
+		}
+	}
+	return CheckResult{}, false
+}
+
+// SetError marks the check with the given name as failed, adding it if missing, and updates the overall status.
+func (alloc *Allocation) SetError(name, reason string) {
+	res := CheckResult{
+		Name:   name,
+		Status: StatusError,
+		Reason: reason,
+	}
+	found := false
+	for idx := range alloc.Checks {
+		if alloc.Checks[idx].Name == name {
+			alloc.Checks[idx] = res
+			found = true
+		}
+	}
+	if !found {
+		alloc.Checks = append(alloc.Checks, res)
+	}
+	alloc.Status = OverallStatus(alloc.Checks)
+}
+
+// OverallStatus summarizes the status of the checks. Skipped checks don't affect the result.
+func OverallStatus(checks []CheckResult) Status {
+	status := StatusAligned
+	for _, res := range checks {
+		switch res.Status {
+		case StatusUnaligned:
+			return StatusUnaligned
+		case StatusError:
+			status = StatusError
+		}
+	}
+	return status
+}
+
+// Summary returns the compact form of the allocation, which retains only the status.
+func (alloc Allocation) Summary() AllocationSummary {
+	sum := AllocationSummary{
+		APIVersion: APIVersion,
+		Kind:       KindAllocationSummary,
+		Status:     alloc.Status,
+		Checks:     make(map[string]Status, len(alloc.Checks)),
+	}
+	for _, res := range alloc.Checks {
+		sum.Checks[res.Name] = res.Status
+	}
+	return sum
+}
+
+// ValidatePolicy checks the policy only includes known checks or PolicyAll.
+func ValidatePolicy(policy []string) error {
+	for _, name := range policy {
+		if name != PolicyAll && !slices.Contains(Checks, name) {
+			return fmt.Errorf("unknown check %q in policy", name)
+		}
+	}
+	return nil
+}
+
+// Violations returns the checks required by the policy which are unaligned or failed.
+// Skipped checks don't violate the policy, because there was nothing to check.
+func (alloc Allocation) Violations(policy []string) []CheckResult {
+	var ret []CheckResult
+	for _, res := range alloc.Checks {
+		if !slices.Contains(policy, PolicyAll) && !slices.Contains(policy, res.Name) {
+			continue
+		}
+		if res.Status == StatusUnaligned || res.Status == StatusError {
+			ret = append(ret, res)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright 2026 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1
+
+import (
+	"time"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+)
+
+const (
+	APIVersion            = "ctrreschk/v1"
+	KindAllocation        = "Allocation"
+	KindAllocationSummary = "AllocationSummary"
+	KindAllocationDiff    = "AllocationDiff"
+)
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	StatusAligned   Status = "aligned"
+	StatusUnaligned Status = "unaligned"
+	// StatusSkipped means the check could not run, e.g. because there are no devices to check
+	StatusSkipped Status = "skipped"
+	// StatusError means the check should have run, but it failed to gather the data it needs
+	StatusError Status = "error"
+)
+
+const (
+	CheckSMT            = "smt"
+	CheckLLC            = "llc"
+	CheckNUMA           = "numa"
+	CheckMemory         = "memory"
+	CheckDevices        = "devices"
+	CheckPCIERoot       = "pcieRoot"
+	CheckStorage        = "storage"
+	CheckDeviceAffinity = "deviceAffinity"
+	CheckCoreType       = "coreType"
+	CheckIsolation      = "isolation"
+	CheckResctrl        = "resctrl"
+	CheckCPUQuota       = "cpuQuota"
+)
+
+// Checks lists all the known checks, in the order they are reported
+var Checks = []string{
+	CheckSMT,
+	CheckLLC,
+	CheckNUMA,
+	CheckMemory,
+	CheckDevices,
+	CheckPCIERoot,
+	CheckStorage,
+	CheckDeviceAffinity,
+	CheckCoreType,
+	CheckIsolation,
+	CheckResctrl,
+	CheckCPUQuota,
+}
+
+// the detail types are unchanged from v0
+type (
+	ResourcesDetails   = apiv0.ContainerResourcesDetails
+	DeviceDetails      = apiv0.DeviceDetails
+	DeviceAffinityInfo = apiv0.DeviceAffinityInfo
+	IsolationInfo      = apiv0.IsolationInfo
+	ResctrlInfo        = apiv0.ResctrlInfo
+	LimitsInfo         = apiv0.LimitsInfo
+)
+
+type CheckResult struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	// Reason explains why the check was skipped, failed or, if there are no details, why it is unaligned
+	Reason string `json:"reason,omitempty"`
+	// Aligned maps the resource IDs (e.g. NUMA node, LLC, PCIe root) to the container resources aligned on them
+	Aligned map[string]ResourcesDetails `json:"aligned,omitempty"`
+	// Unaligned are the container resources breaking the alignment. Set only if the status is unaligned.
+	Unaligned *ResourcesDetails `json:"unaligned,omitempty"`
+}
+
+type Metadata struct {
+	Hostname      string    `json:"hostname,omitempty"`
+	KernelVersion string    `json:"kernelVersion,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+	ToolVersion   string    `json:"toolVersion,omitempty"`
+}
+
+type Allocation struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Metadata   Metadata `json:"metadata"`
+	// Status is unaligned if any check is unaligned, otherwise error if any check failed, otherwise aligned
+	Status Status `json:"status"`
+	// Checks lists all the known checks, including the skipped ones
+	Checks         []CheckResult       `json:"checks"`
+	DeviceAffinity *DeviceAffinityInfo `json:"deviceAffinity,omitempty"`
+	Devices        []DeviceDetails     `json:"devices,omitempty"`
+	Isolation      *IsolationInfo      `json:"isolation,omitempty"`
+	Resctrl        *ResctrlInfo        `json:"resctrl,omitempty"`
+	Limits         *LimitsInfo         `json:"limits,omitempty"`
+}
+
+// AllocationSummary is the compact form of Allocation, small enough to fit in the kubernetes termination message
+type AllocationSummary struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Status     Status `json:"status"`
+	// Checks maps the check names to their status
+	Checks map[string]Status `json:"checks,omitempty"`
+}
+
+// Change is a single difference between two reports. Path is a JSON pointer into the report;
+// Old is missing for added values and New is missing for removed values.
+type Change struct {
+	Path string `json:"path"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// AllocationDiff describes how the allocation changed since the previous report, in watch mode
+type AllocationDiff struct {
+	APIVersion string    `json:"apiVersion"`
+	Kind       string    `json:"kind"`
+	Timestamp  time.Time `json:"timestamp"`
+	// Status is the status of the new allocation
+	Status  Status   `json:"status"`
+	Changes []Change `json:"changes"`
+}
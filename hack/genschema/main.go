@@ -0,0 +1,34 @@
+// genschema writes the JSON Schema documents of the published API types, see api/schema.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ffromani/ctrreschk/api/schema"
+)
+
+func main() {
+	outDir := flag.String("o", filepath.Join("api", "schema"), "output directory")
+	flag.Parse()
+
+	for _, doc := range schema.Documents {
+		data, err := schema.Generate(doc)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cannot generate %s: %v\n", doc.Name(), err)
+			os.Exit(1)
+		}
+		path := filepath.Join(*outDir, doc.Path())
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			fmt.Fprintf(os.Stderr, "cannot create %s: %v\n", filepath.Dir(path), err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "cannot write %s: %v\n", path, err)
+			os.Exit(1)
+		}
+	}
+}
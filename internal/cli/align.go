@@ -17,19 +17,34 @@
 package cli
 
 import (
-	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	apiv1 "github.com/ffromani/ctrreschk/api/v1"
 	"github.com/ffromani/ctrreschk/pkg/align"
 	"github.com/ffromani/ctrreschk/pkg/environ"
 	"github.com/ffromani/ctrreschk/pkg/machine"
 	"github.com/ffromani/ctrreschk/pkg/resources"
+	"github.com/ffromani/ctrreschk/pkg/version"
 )
 
 type AlignOptions struct {
 	DeviceEnvPrefixes []string
+	CDISpecDir        string
+	DiscoverNetDevs   bool
+	NetworkStatusFile string
+	DiscoverMounts    bool
+	DiscoverResctrl   bool
+	APIVersion        string
+	Output            OutputOptions
+	Watch             bool
+	WatchInterval     time.Duration
 }
 
 func NewAlignCommand(env *environ.Environ, opts *Options) *cobra.Command {
@@ -39,32 +54,116 @@ func NewAlignCommand(env *environ.Environ, opts *Options) *cobra.Command {
 		Use:   "align",
 		Short: "show resource alignment properties",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			container, err := resources.Discover(env)
-			if err != nil {
-				return err
+			if alignOpts.APIVersion != "v0" && alignOpts.APIVersion != "v1" {
+				return fmt.Errorf("unsupported API version %q", alignOpts.APIVersion)
 			}
-			if len(alignOpts.DeviceEnvPrefixes) > 0 {
-				container.Devices = resources.DiscoverDevicesFromEnv(env, os.Environ(), alignOpts.DeviceEnvPrefixes)
+			if alignOpts.Watch && alignOpts.WatchInterval <= 0 {
+				return fmt.Errorf("invalid watch interval %v", alignOpts.WatchInterval)
 			}
-			machine, err := machine.Discover(env)
+			result, resultV1, err := runAlign(env, alignOpts)
 			if err != nil {
 				return err
 			}
-			result, err := align.Check(env, container, machine)
+			err = writeAlignResult(env, alignOpts, result, resultV1)
 			if err != nil {
 				return err
 			}
-			err = json.NewEncoder(os.Stdout).Encode(result)
-			if err != nil {
-				return err
+			if alignOpts.Watch {
+				return watchAlign(env, alignOpts, result, resultV1)
 			}
 			return MainLoop(opts)
 		},
 		Args: cobra.NoArgs,
 	}
 
-	alignCmd.PersistentFlags().StringVarP(&env.DataPath, "machinedata", "M", "", "read fake machine data from path, don't read real data from the system")
-	alignCmd.PersistentFlags().StringSliceVar(&alignOpts.DeviceEnvPrefixes, "device-env-prefix", nil, "env var prefixes for device PCI addresses (e.g. SRIOVNETWORK_VF_,PCIDEVICE_)")
+	addAlignFlags(alignCmd, env, &alignOpts)
+	alignCmd.PersistentFlags().BoolVar(&alignOpts.Watch, "watch", false, "keep running and re-check the alignment, emitting a new report and its diff from the previous one when the allocation changes")
+	alignCmd.PersistentFlags().DurationVar(&alignOpts.WatchInterval, "watch-interval", DefaultWatchInterval, "interval between the periodic re-checks in watch mode; writes to the cgroup cpuset and CPU quota also trigger a re-check, on a best-effort basis")
 
 	return alignCmd
 }
+
+func addAlignFlags(cmd *cobra.Command, env *environ.Environ, alignOpts *AlignOptions) {
+	cmd.PersistentFlags().StringVarP(&env.DataPath, "machinedata", "M", "", "read fake machine data from path, don't read real data from the system")
+	cmd.PersistentFlags().StringSliceVar(&alignOpts.DeviceEnvPrefixes, "device-env-prefix", nil, "env var prefixes for device PCI addresses (e.g. SRIOVNETWORK_VF_,PCIDEVICE_)")
+	cmd.PersistentFlags().StringVar(&alignOpts.CDISpecDir, "cdi-spec-dir", "", "discover the DRA allocated devices from the CDI specs in this directory (e.g. "+resources.DefaultCDISpecDir+")")
+	cmd.PersistentFlags().BoolVar(&alignOpts.DiscoverNetDevs, "discover-netdevs", false, "discover the PCI devices backing the container network interfaces")
+	cmd.PersistentFlags().StringVar(&alignOpts.NetworkStatusFile, "network-status-file", "", "discover the network devices from the "+resources.NetworkStatusAnnotation+" annotation exposed in this file through the downward API")
+
+	cmd.PersistentFlags().BoolVar(&alignOpts.DiscoverMounts, "discover-mounts", false, "discover the storage controllers backing the block devices mounted in the container")
+	cmd.PersistentFlags().BoolVar(&alignOpts.DiscoverResctrl, "discover-resctrl", false, "discover the resctrl groups of the container threads and their cache and memory bandwidth allocation")
+
+	addOutputFlags(cmd, &alignOpts.Output)
+	cmd.PersistentFlags().StringVar(&alignOpts.APIVersion, "api-version", "v1", "version of the output API: v1 or v0")
+}
+
+// runAlign discovers the container resources and checks their alignment. The result is returned in both API versions.
+func runAlign(env *environ.Environ, alignOpts AlignOptions) (apiv0.Allocation, apiv1.Allocation, error) {
+	container, err := resources.Discover(env)
+	if err != nil {
+		return apiv0.Allocation{}, apiv1.Allocation{}, err
+	}
+	if len(alignOpts.DeviceEnvPrefixes) > 0 {
+		container.Devices = resources.DiscoverDevicesFromEnv(env, os.Environ(), alignOpts.DeviceEnvPrefixes)
+	}
+	if alignOpts.CDISpecDir != "" {
+		container.Devices = resources.AppendDevices(container.Devices, resources.DiscoverDevicesFromCDI(env, alignOpts.CDISpecDir, "/", os.Environ())...)
+	}
+	if alignOpts.DiscoverNetDevs {
+		container.Devices = resources.AppendDevices(container.Devices, resources.DiscoverDevicesFromNetDevs(env)...)
+	}
+	if alignOpts.NetworkStatusFile != "" {
+		container.Devices = resources.AppendDevices(container.Devices, resources.DiscoverDevicesFromNetworkStatus(env, alignOpts.NetworkStatusFile)...)
+	}
+	if alignOpts.DiscoverMounts {
+		container.Storage = resources.DiscoverDevicesFromMounts(env)
+	}
+	if alignOpts.DiscoverResctrl {
+		container.Resctrl = resources.DiscoverResctrl(env)
+	}
+	machine, err := machine.Discover(env)
+	if err != nil {
+		return apiv0.Allocation{}, apiv1.Allocation{}, err
+	}
+	result, err := align.Check(env, container, machine)
+	if err != nil {
+		return apiv0.Allocation{}, apiv1.Allocation{}, err
+	}
+	resultV1 := apiv1.FromV0(result, buildMetadata(env))
+	if alignOpts.DiscoverResctrl && container.Resctrl == nil {
+		resultV1.SetError(apiv1.CheckResctrl, "cannot read the resctrl groups of the container threads")
+	}
+	return result, resultV1, nil
+}
+
+func writeAlignResult(env *environ.Environ, alignOpts AlignOptions, result apiv0.Allocation, resultV1 apiv1.Allocation) error {
+	if alignOpts.APIVersion == "v0" {
+		return writeResult(env, alignOpts.Output, result, func() any {
+			// the alignment flags and the errors are bounded, the details are not
+			return apiv0.Allocation{
+				Alignment: result.Alignment,
+				Errors:    result.Errors,
+			}
+		})
+	}
+	return writeResult(env, alignOpts.Output, resultV1, func() any { return resultV1.Summary() })
+}
+
+// buildMetadata collects the run metadata. Failures are not fatal, the fields are just left empty.
+func buildMetadata(env *environ.Environ) apiv1.Metadata {
+	meta := apiv1.Metadata{
+		Timestamp:   time.Now().UTC(),
+		ToolVersion: version.Get(),
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		env.Log.V(1).Info("cannot read the hostname", "error", err)
+	}
+	meta.Hostname = hostname
+	data, err := os.ReadFile(filepath.Join(env.Root.Proc, "sys", "kernel", "osrelease"))
+	if err != nil {
+		env.Log.V(1).Info("cannot read the kernel version", "error", err)
+	}
+	meta.KernelVersion = strings.TrimSpace(string(data))
+	return meta
+}
@@ -3,9 +3,6 @@
 package cli
 
 import (
-	"encoding/json"
-	"os"
-
 	"github.com/spf13/cobra"
 
 	"k8s.io/utils/cpuset"
@@ -18,6 +15,8 @@ import (
 )
 
 func NewAlignMemCommand(env *environ.Environ, opts *Options) *cobra.Command {
+	outOpts := OutputOptions{}
+
 	alignMemCmd := &cobra.Command{
 		Use:   "alignmem",
 		Short: "verify actual memory NUMA placement via numa_maps",
@@ -42,7 +41,12 @@ func NewAlignMemCommand(env *environ.Environ, opts *Options) *cobra.Command {
 
 			result := buildNUMAMapsInfo(nm, cpuNUMANodes)
 
-			err = json.NewEncoder(os.Stdout).Encode(result)
+			err = writeResult(env, outOpts, result, func() any {
+				// the per-node details are the only unbounded part
+				sum := result
+				sum.Nodes = nil
+				return sum
+			})
 			if err != nil {
 				return err
 			}
@@ -53,6 +57,8 @@ func NewAlignMemCommand(env *environ.Environ, opts *Options) *cobra.Command {
 
 	alignMemCmd.PersistentFlags().StringVarP(&env.DataPath, "machinedata", "M", "", "read fake machine data from path, don't read real data from the system")
 
+	addOutputFlags(alignMemCmd, &outOpts)
+
 	return alignMemCmd
 }
 
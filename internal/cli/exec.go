@@ -0,0 +1,104 @@
+/*
+ * Copyright 2026 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	apiv1 "github.com/ffromani/ctrreschk/api/v1"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+type ExecOptions struct {
+	Align  AlignOptions
+	Policy []string
+}
+
+func NewExecCommand(env *environ.Environ, opts *Options) *cobra.Command {
+	execOpts := ExecOptions{}
+
+	execCmd := &cobra.Command{
+		Use:   "exec [flags] -- command [args...]",
+		Short: "check the resource alignment, then replace itself with the given command",
+		Long: `check the resource alignment and report it like the align command, then execute the given command in place,
+keeping the same PID and environment, so ctrreschk can be the entry point of any container.
+If the policy lists some checks, the command is not executed if any of them is unaligned or failed.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if execOpts.Align.APIVersion != "v0" && execOpts.Align.APIVersion != "v1" {
+				return fmt.Errorf("unsupported API version %q", execOpts.Align.APIVersion)
+			}
+			err := apiv1.ValidatePolicy(execOpts.Policy)
+			if err != nil {
+				return err
+			}
+			// fail early: no point in checking if we can't run the workload anyway
+			path, err := exec.LookPath(args[0])
+			if err != nil {
+				return err
+			}
+
+			err = checkPolicy(env, execOpts)
+			if err != nil {
+				return err
+			}
+
+			env.Log.V(2).Info("executing workload", "path", path, "args", args)
+			// returns only on failure
+			return syscall.Exec(path, args, os.Environ())
+		},
+		Args: cobra.MinimumNArgs(1),
+	}
+	// everything after the command belongs to the command, even without the "--" separator
+	execCmd.Flags().SetInterspersed(false)
+
+	addAlignFlags(execCmd, env, &execOpts.Align)
+	execCmd.PersistentFlags().StringSliceVar(&execOpts.Policy, "policy", nil, "checks which must be aligned to execute the command, or \""+apiv1.PolicyAll+"\" (e.g. smt,numa). Empty means always execute the command")
+
+	return execCmd
+}
+
+// checkPolicy runs the alignment checks and reports them. Without a policy the workload runs anyway,
+// so failing to run the checks is not fatal.
+func checkPolicy(env *environ.Environ, execOpts ExecOptions) error {
+	result, resultV1, err := runAlign(env, execOpts.Align)
+	if err != nil {
+		if len(execOpts.Policy) == 0 {
+			env.Log.Info("cannot check the alignment, executing the workload anyway", "error", err)
+			return nil
+		}
+		return err
+	}
+	err = writeAlignResult(env, execOpts.Align, result, resultV1)
+	if err != nil {
+		return err
+	}
+	violations := resultV1.Violations(execOpts.Policy)
+	if len(violations) == 0 {
+		return nil
+	}
+	var names []string
+	for _, res := range violations {
+		names = append(names, res.Name+"="+string(res.Status))
+	}
+	return fmt.Errorf("alignment policy violated: %s", strings.Join(names, ","))
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright 2026 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+	"github.com/ffromani/ctrreschk/pkg/resources"
+)
+
+type IOMMUOptions struct {
+	DeviceEnvPrefixes []string
+	VFIODevDir        string
+}
+
+func NewIOMMUCommand(env *environ.Environ, opts *Options) *cobra.Command {
+	iommuOpts := IOMMUOptions{}
+
+	iommuCmd := &cobra.Command{
+		Use:   "iommu",
+		Short: "show the IOMMU groups of the devices allocated to the container",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var groupIDs []string
+			if iommuOpts.VFIODevDir != "" {
+				groupIDs = resources.DiscoverVFIOGroups(env, iommuOpts.VFIODevDir)
+			}
+			var addrs []string
+			if len(iommuOpts.DeviceEnvPrefixes) > 0 {
+				for _, dev := range resources.DiscoverDevicesFromEnv(env, os.Environ(), iommuOpts.DeviceEnvPrefixes) {
+					addrs = append(addrs, dev.PCIAddress)
+				}
+			}
+			info, err := resources.DiscoverIOMMUGroups(env, groupIDs, addrs)
+			if err != nil {
+				return err
+			}
+			err = json.NewEncoder(os.Stdout).Encode(info)
+			if err != nil {
+				return err
+			}
+			return MainLoop(opts)
+		},
+		Args: cobra.NoArgs,
+	}
+
+	iommuCmd.PersistentFlags().StringSliceVar(&iommuOpts.DeviceEnvPrefixes, "device-env-prefix", nil, "env var prefixes for device PCI addresses (e.g. SRIOVNETWORK_VF_,PCIDEVICE_)")
+	iommuCmd.PersistentFlags().StringVar(&iommuOpts.VFIODevDir, "vfio-dir", resources.DefaultVFIODevDir, "discover the IOMMU groups from the vfio group nodes in this directory, empty to disable")
+
+	return iommuCmd
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright 2026 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/utils/cpuset"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	"github.com/ffromani/ctrreschk/pkg/cgroups"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+	"github.com/ffromani/ctrreschk/pkg/irq"
+	"github.com/ffromani/ctrreschk/pkg/resources"
+)
+
+type IRQsOptions struct {
+	DeviceEnvPrefixes []string
+	DiscoverNetDevs   bool
+}
+
+func NewIRQsCommand(env *environ.Environ, opts *Options) *cobra.Command {
+	irqsOpts := IRQsOptions{}
+
+	irqsCmd := &cobra.Command{
+		Use:   "irqs",
+		Short: "audit the IRQs landing on the container CPUs and the IRQ affinity of the container devices",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cpus, err := cgroups.Cpuset(env)
+			if err != nil {
+				return err
+			}
+			var devices []resources.DeviceInfo
+			if len(irqsOpts.DeviceEnvPrefixes) > 0 {
+				devices = resources.DiscoverDevicesFromEnv(env, os.Environ(), irqsOpts.DeviceEnvPrefixes)
+			}
+			if irqsOpts.DiscoverNetDevs {
+				devices = resources.AppendDevices(devices, resources.DiscoverDevicesFromNetDevs(env)...)
+			}
+			irqs, err := irq.Read(env)
+			if err != nil {
+				return err
+			}
+			deviceIRQs := make(map[string][]int)
+			for _, dev := range devices {
+				nums, err := irq.DeviceIRQs(env, dev.PCIAddress)
+				if err != nil {
+					env.Log.V(1).Info("cannot read device IRQs, skipping", "pciAddress", dev.PCIAddress, "error", err)
+					continue
+				}
+				deviceIRQs[dev.PCIAddress] = nums
+			}
+			result := buildIRQAuditInfo(irqs, cpus, devices, deviceIRQs)
+			err = json.NewEncoder(os.Stdout).Encode(result)
+			if err != nil {
+				return err
+			}
+			return MainLoop(opts)
+		},
+		Args: cobra.NoArgs,
+	}
+
+	irqsCmd.PersistentFlags().StringSliceVar(&irqsOpts.DeviceEnvPrefixes, "device-env-prefix", nil, "env var prefixes for device PCI addresses (e.g. SRIOVNETWORK_VF_,PCIDEVICE_)")
+	irqsCmd.PersistentFlags().BoolVar(&irqsOpts.DiscoverNetDevs, "discover-netdevs", false, "discover the PCI devices backing the container network interfaces")
+
+	return irqsCmd
+}
+
+func buildIRQAuditInfo(irqs []irq.IRQ, cpus cpuset.CPUSet, devices []resources.DeviceInfo, deviceIRQs map[string][]int) apiv0.IRQAuditInfo {
+	info := apiv0.IRQAuditInfo{
+		CPUs: cpus.List(),
+	}
+	byNumber := make(map[int]irq.IRQ)
+	for _, intr := range irqs {
+		byNumber[intr.Number] = intr
+		if intr.Affinity.Intersection(cpus).IsEmpty() {
+			continue
+		}
+		info.IRQs = append(info.IRQs, buildIRQInfo(intr, cpus))
+	}
+	for _, dev := range devices {
+		nums, ok := deviceIRQs[dev.PCIAddress]
+		if !ok {
+			continue
+		}
+		devInfo := apiv0.IRQDeviceInfo{
+			PCIAddress: dev.PCIAddress,
+			LocalCPUs:  dev.LocalCPUs.List(),
+			IRQs:       nums,
+		}
+		for _, num := range nums {
+			intr, ok := byNumber[num]
+			if !ok || dev.LocalCPUs.IsEmpty() {
+				// can't tell
+				continue
+			}
+			if intr.EffectiveAffinity.IsSubsetOf(dev.LocalCPUs) {
+				continue
+			}
+			devInfo.NonLocal = append(devInfo.NonLocal, buildIRQInfo(intr, cpus))
+		}
+		info.Devices = append(info.Devices, devInfo)
+	}
+	info.Clean = len(info.IRQs) == 0
+	for _, devInfo := range info.Devices {
+		info.Clean = info.Clean && len(devInfo.NonLocal) == 0
+	}
+	return info
+}
+
+func buildIRQInfo(intr irq.IRQ, cpus cpuset.CPUSet) apiv0.IRQInfo {
+	return apiv0.IRQInfo{
+		IRQ:               intr.Number,
+		Name:              intr.Name,
+		Affinity:          intr.Affinity.List(),
+		EffectiveAffinity: intr.EffectiveAffinity.List(),
+		Count:             intr.CountOn(cpus),
+	}
+}
@@ -17,17 +17,59 @@
 package cli
 
 import (
+	"encoding/json"
 	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/ffromani/cpumgrx/pkg/machineinformer"
 
+	"github.com/ffromani/ctrreschk/pkg/device"
 	"github.com/ffromani/ctrreschk/pkg/environ"
 )
 
 type K8SOptions struct{}
 
+func NewK8SResourceSliceCommand(env *environ.Environ, opts *Options) *cobra.Command {
+	sliceOpts := device.ResourceSliceOptions{}
+
+	resourceSliceCmd := &cobra.Command{
+		Use:   "resourceslice",
+		Short: "show the DRA ResourceSlice manifests describing the local PCI devices",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if sliceOpts.NodeName == "" {
+				hostname, err := os.Hostname()
+				if err != nil {
+					return err
+				}
+				sliceOpts.NodeName = hostname
+			}
+			sysfs := os.DirFS(env.Root.Sys).(device.SysFS)
+			resSlices, err := device.ResourceSlicesFromFS(env.Log, sysfs, sliceOpts)
+			if err != nil {
+				return err
+			}
+			enc := json.NewEncoder(os.Stdout)
+			for _, resSlice := range resSlices {
+				err = enc.Encode(resSlice)
+				if err != nil {
+					return err
+				}
+			}
+			return MainLoop(opts)
+		},
+		Args: cobra.NoArgs,
+	}
+
+	resourceSliceCmd.PersistentFlags().StringVar(&sliceOpts.DriverName, "dra-driver", "ctrreschk.local", "DRA driver name to use in the ResourceSlices")
+	resourceSliceCmd.PersistentFlags().StringVar(&sliceOpts.NodeName, "node-name", "", "node name to use in the ResourceSlices (default: hostname)")
+	resourceSliceCmd.PersistentFlags().StringSliceVar(&sliceOpts.ClassIDs, "class", nil, "only include devices of these PCI classes, with optional subclass and prog-if (e.g. 02,0302,010802)")
+	resourceSliceCmd.PersistentFlags().StringSliceVar(&sliceOpts.VendorIDs, "vendor", nil, "only include devices of these PCI vendors (e.g. 8086,10de)")
+	resourceSliceCmd.PersistentFlags().StringSliceVar(&sliceOpts.Drivers, "driver", nil, "only include devices bound to these kernel drivers (e.g. vfio-pci,mlx5_core)")
+
+	return resourceSliceCmd
+}
+
 func NewK8SMachineInfoCommand(env *environ.Environ, opts *Options) *cobra.Command {
 	machineInfoCmd := &cobra.Command{
 		Use:   "machineinfo",
@@ -51,6 +93,9 @@ func NewK8SCommand(env *environ.Environ, opts *Options) *cobra.Command {
 		},
 		Args: cobra.NoArgs,
 	}
-	k8sCmd.AddCommand(NewK8SMachineInfoCommand(env, opts))
+	k8sCmd.AddCommand(
+		NewK8SMachineInfoCommand(env, opts),
+		NewK8SResourceSliceCommand(env, opts),
+	)
 	return k8sCmd
 }
@@ -0,0 +1,85 @@
+/*
+ * Copyright 2026 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+const (
+	DefaultTerminationMessagePath = "/dev/termination-log"
+	// TerminationMessageMaxSize is the max size kubelet reads from the termination message file
+	TerminationMessageMaxSize = 4096
+)
+
+type OutputOptions struct {
+	File               string
+	TerminationMessage string
+}
+
+func addOutputFlags(cmd *cobra.Command, outOpts *OutputOptions) {
+	cmd.PersistentFlags().StringVar(&outOpts.File, "output-file", "", "write the result also to this file")
+	cmd.PersistentFlags().StringVar(&outOpts.TerminationMessage, "termination-message", "", "write the result, or its summary if larger than 4KiB, also to the kubernetes termination message file")
+	cmd.PersistentFlags().Lookup("termination-message").NoOptDefVal = DefaultTerminationMessagePath
+}
+
+// writeResult emits the result on stdout and on the files requested by the options.
+// The termination message gets the summary if the result doesn't fit, because kubelet would truncate it.
+func writeResult(env *environ.Environ, outOpts OutputOptions, result any, summarize func() any) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = os.Stdout.Write(data)
+	if err != nil {
+		return err
+	}
+
+	if outOpts.File != "" {
+		env.Log.V(2).Info("writing output file", "path", outOpts.File, "size", len(data))
+		err = os.WriteFile(outOpts.File, data, 0o644)
+		if err != nil {
+			return err
+		}
+	}
+
+	if outOpts.TerminationMessage != "" {
+		msg := data
+		if len(msg) > TerminationMessageMaxSize {
+			msg, err = json.Marshal(summarize())
+			if err != nil {
+				return err
+			}
+			env.Log.V(1).Info("result too large for the termination message, writing the summary", "size", len(data), "summarySize", len(msg))
+		}
+		if len(msg) > TerminationMessageMaxSize {
+			env.Log.V(1).Info("termination message will be truncated", "size", len(msg))
+		}
+		env.Log.V(2).Info("writing termination message", "path", outOpts.TerminationMessage, "size", len(msg))
+		err = os.WriteFile(outOpts.TerminationMessage, msg, 0o644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+/*
+ * Copyright 2026 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	apiv1 "github.com/ffromani/ctrreschk/api/v1"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+func TestWriteResult(t *testing.T) {
+	type payload struct {
+		Status  string `json:"status"`
+		Details string `json:"details,omitempty"`
+	}
+	summary := payload{Status: "aligned"}
+
+	testCases := []struct {
+		name                string
+		result              payload
+		withFiles           bool
+		expectedTermination string
+	}{
+		{
+			name:   "stdout only",
+			result: payload{Status: "aligned", Details: "small"},
+		},
+		{
+			name:                "fits the termination message",
+			result:              payload{Status: "aligned", Details: "small"},
+			withFiles:           true,
+			expectedTermination: `{"status":"aligned","details":"small"}` + "\n",
+		},
+		{
+			name:                "summary in the termination message",
+			result:              payload{Status: "aligned", Details: strings.Repeat("x", TerminationMessageMaxSize)},
+			withFiles:           true,
+			expectedTermination: `{"status":"aligned"}`,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			readStdout := captureStdout(t)
+			dir := t.TempDir()
+			outOpts := OutputOptions{}
+			if tt.withFiles {
+				outOpts.File = filepath.Join(dir, "result.json")
+				outOpts.TerminationMessage = filepath.Join(dir, "termination-log")
+			}
+
+			err := writeResult(environ.New(), outOpts, tt.result, func() any { return summary })
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expected := mustMarshal(t, tt.result) + "\n"
+			if got := readStdout(); got != expected {
+				t.Errorf("unexpected stdout: got=%q expected=%q", got, expected)
+			}
+			if !tt.withFiles {
+				if entries, _ := os.ReadDir(dir); len(entries) > 0 {
+					t.Errorf("unexpected files written: %v", entries)
+				}
+				return
+			}
+			if got := mustReadFile(t, outOpts.File); got != expected {
+				t.Errorf("unexpected output file: got=%q expected=%q", got, expected)
+			}
+			if got := mustReadFile(t, outOpts.TerminationMessage); got != tt.expectedTermination {
+				t.Errorf("unexpected termination message: got=%q expected=%q", got, tt.expectedTermination)
+			}
+		})
+	}
+}
+
+func TestWriteResultError(t *testing.T) {
+	captureStdout(t)
+	outOpts := OutputOptions{
+		File: filepath.Join(t.TempDir(), "missing", "result.json"),
+	}
+	if err := writeResult(environ.New(), outOpts, "aligned", nil); err == nil {
+		t.Fatalf("expected error writing to a missing directory, got success")
+	}
+}
+
+func TestWriteAlignResultSummary(t *testing.T) {
+	aligned := true
+	result := apiv0.Allocation{
+		Alignment: apiv0.Alignment{SMT: true, LLC: true, NUMA: true, Devices: &aligned},
+		Errors:    map[string]string{apiv0.CheckLimits: "cannot read CPU limits: missing cpu.max"},
+	}
+	// enough devices to overflow the termination message
+	for idx := 0; idx < 64; idx++ {
+		result.Devices = append(result.Devices, apiv0.DeviceDetails{PCIAddress: fmt.Sprintf("0000:%02x:00.0", idx), Driver: "vfio-pci"})
+	}
+	resultV1 := apiv1.FromV0(result, apiv1.Metadata{})
+
+	testCases := []struct {
+		apiVersion string
+		expected   any
+	}{
+		{
+			apiVersion: "v0",
+			expected: apiv0.Allocation{
+				Alignment: result.Alignment,
+				Errors:    result.Errors,
+			},
+		},
+		{
+			apiVersion: "v1",
+			expected:   resultV1.Summary(),
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.apiVersion, func(t *testing.T) {
+			captureStdout(t)
+			alignOpts := AlignOptions{
+				APIVersion: tt.apiVersion,
+				Output: OutputOptions{
+					TerminationMessage: filepath.Join(t.TempDir(), "termination-log"),
+				},
+			}
+			if err := writeAlignResult(environ.New(), alignOpts, result, resultV1); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := mustReadFile(t, alignOpts.Output.TerminationMessage)
+			if diff := cmp.Diff(mustMarshal(t, tt.expected), got); diff != "" {
+				t.Errorf("unexpected termination message (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// captureStdout redirects stdout to a file until the end of the test, and returns a function to read it.
+func captureStdout(t *testing.T) func() string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stdout")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("cannot create %s: %v", path, err)
+	}
+	orig := os.Stdout
+	os.Stdout = file
+	t.Cleanup(func() {
+		os.Stdout = orig
+		file.Close()
+	})
+	return func() string {
+		return mustReadFile(t, path)
+	}
+}
+
+func mustMarshal(t *testing.T, obj any) string {
+	t.Helper()
+	data, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("cannot marshal %v: %v", obj, err)
+	}
+	return string(data)
+}
+
+func mustReadFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cannot read %s: %v", path, err)
+	}
+	return string(data)
+}
@@ -17,38 +17,75 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/spf13/cobra"
 
+	"k8s.io/utils/cpuset"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	"github.com/ffromani/ctrreschk/pkg/cgroups"
 	"github.com/ffromani/ctrreschk/pkg/device"
 	"github.com/ffromani/ctrreschk/pkg/environ"
 )
 
-type PCIEScanOptions struct{}
+const (
+	PCIEScanFormatJSON = "json"
+	PCIEScanFormatText = "text"
+)
+
+type PCIEScanOptions struct {
+	Format string
+}
 
 func NewPCIEScanCommand(env *environ.Environ, opts *Options) *cobra.Command {
-	return &cobra.Command{
+	scanOpts := PCIEScanOptions{}
+
+	scanCmd := &cobra.Command{
 		Use:   "pciescan",
 		Short: "show pcieroot data",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if scanOpts.Format != PCIEScanFormatJSON && scanOpts.Format != PCIEScanFormatText {
+				return fmt.Errorf("unsupported format %q", scanOpts.Format)
+			}
 			sysfs := os.DirFS(env.Root.Sys).(device.SysFS)
-			err := runScan(env.Log, sysfs)
+			// pciescan is useful also outside containers, so the cpuset is optional
+			cpus, err := cgroups.Cpuset(env)
+			if err != nil {
+				env.Log.V(1).Info("cannot detect container CPUs, skipping", "error", err)
+				cpus = cpuset.New()
+			}
+			info, err := runScan(env.Log, sysfs, cpus)
 			if err != nil {
 				return err
 			}
+			if scanOpts.Format == PCIEScanFormatText {
+				writePCIETree(os.Stdout, info)
+			} else {
+				err = json.NewEncoder(os.Stdout).Encode(info)
+				if err != nil {
+					return err
+				}
+			}
 			return MainLoop(opts)
 		},
 		Args: cobra.NoArgs,
 	}
+
+	scanCmd.PersistentFlags().StringVar(&scanOpts.Format, "format", PCIEScanFormatJSON, "output format: json or text")
+
+	return scanCmd
 }
 
-func runScan(lh logr.Logger, sysfs device.SysFS) error {
+func runScan(lh logr.Logger, sysfs device.SysFS, containerCPUs cpuset.CPUSet) (apiv0.PCIEInfo, error) {
 	domains, err := device.PCIEDomainsFromFS(lh, sysfs)
 	if err != nil {
-		return fmt.Errorf("failed to scan the PCIE domains: %w", err)
+		return apiv0.PCIEInfo{}, fmt.Errorf("failed to scan the PCIE domains: %w", err)
 	}
 	lh.V(4).Info("found PCIE domains", "count", len(domains))
 	for _, dom := range domains {
@@ -57,10 +94,105 @@ func runScan(lh logr.Logger, sysfs device.SysFS) error {
 
 	onlineCPUs, err := device.OnlineCPUs(lh, sysfs)
 	if err != nil {
-		return fmt.Errorf("failed to get the online CPUs: %w", err)
+		return apiv0.PCIEInfo{}, fmt.Errorf("failed to get the online CPUs: %w", err)
 	}
 
 	orphans := device.FindOrphanedCPUs(domains, onlineCPUs)
 	lh.V(2).Info("found orphaned CPUs", "count", orphans.Size())
-	return nil
+
+	trees, err := device.PCIETreesFromFS(lh, sysfs)
+	if err != nil {
+		return apiv0.PCIEInfo{}, fmt.Errorf("failed to scan the PCIE hierarchy: %w", err)
+	}
+
+	info := buildPCIEInfo(trees, domains, orphans)
+	if !containerCPUs.IsEmpty() {
+		info.Container = buildPCIEContainerInfo(domains, containerCPUs)
+		lh.V(2).Info("container PCIE locality", "cpus", containerCPUs.String(), "roots", info.Container.Roots, "local", info.Container.Local)
+	}
+	return info, nil
+}
+
+func buildPCIEInfo(trees []device.PCIETree, domains []device.PCIEDomain, orphans cpuset.CPUSet) apiv0.PCIEInfo {
+	info := apiv0.PCIEInfo{
+		OrphanedCPUs: orphans.List(),
+	}
+	for _, dom := range domains {
+		info.Domains = append(info.Domains, apiv0.PCIEDomainInfo{
+			Root:      dom.Root(),
+			LocalCPUs: dom.LocalCPUs.List(),
+			NUMANode:  dom.NUMANode,
+		})
+	}
+	for _, tree := range trees {
+		info.Roots = append(info.Roots, apiv0.PCIERootInfo{
+			Root:    tree.Root,
+			Devices: buildPCIEDeviceInfos(tree.Children),
+		})
+	}
+	return info
+}
+
+func buildPCIEContainerInfo(domains []device.PCIEDomain, cpus cpuset.CPUSet) *apiv0.PCIEContainerInfo {
+	orphans := device.FindOrphanedCPUs(domains, cpus)
+	info := apiv0.PCIEContainerInfo{
+		CPUs:         cpus.List(),
+		OrphanedCPUs: orphans.List(),
+		Local:        orphans.IsEmpty(),
+	}
+	for _, dom := range device.FindLocalDomains(domains, cpus) {
+		info.Roots = append(info.Roots, dom.Root())
+	}
+	return &info
+}
+
+func buildPCIEDeviceInfos(nodes []*device.PCIENode) []apiv0.PCIEDeviceInfo {
+	var devs []apiv0.PCIEDeviceInfo
+	for _, node := range nodes {
+		devs = append(devs, apiv0.PCIEDeviceInfo{
+			Address:     node.Address,
+			ClassID:     node.ClassID,
+			SubclassID:  node.SubclassID,
+			ProgIF:      node.ProgIF,
+			VendorID:    node.VendorID,
+			DeviceID:    node.DeviceID,
+			ClassName:   node.ClassName(),
+			Description: node.Description(),
+			Driver:      node.Driver,
+			NUMANode:    node.NUMANode,
+			LocalCPUs:   node.LocalCPUs.List(),
+			Children:    buildPCIEDeviceInfos(node.Children),
+		})
+	}
+	return devs
+}
+
+func writePCIETree(w io.Writer, info apiv0.PCIEInfo) {
+	for _, root := range info.Roots {
+		fmt.Fprintf(w, "%s\n", root.Root)
+		writePCIEDevices(w, root.Devices, 1)
+	}
+	for _, dom := range info.Domains {
+		fmt.Fprintf(w, "domain %s: numa=%d cpus=%s\n", dom.Root, dom.NUMANode, cpuset.New(dom.LocalCPUs...).String())
+	}
+	fmt.Fprintf(w, "orphaned cpus=%s\n", cpuset.New(info.OrphanedCPUs...).String())
+	if info.Container != nil {
+		fmt.Fprintf(w, "container cpus=%s roots=%s orphaned=%s local=%v\n", cpuset.New(info.Container.CPUs...).String(), strings.Join(info.Container.Roots, ","), cpuset.New(info.Container.OrphanedCPUs...).String(), info.Container.Local)
+	}
+}
+
+func writePCIEDevices(w io.Writer, devs []apiv0.PCIEDeviceInfo, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, dev := range devs {
+		driver := dev.Driver
+		if driver == "" {
+			driver = "-"
+		}
+		ids := ""
+		if dev.VendorID != "" {
+			ids = fmt.Sprintf(" [%s:%s]", dev.VendorID, dev.DeviceID)
+		}
+		fmt.Fprintf(w, "%s%s [%s%s] %s%s driver=%s numa=%d cpus=%s\n", indent, dev.Address, dev.ClassID, dev.SubclassID, dev.Description, ids, driver, dev.NUMANode, cpuset.New(dev.LocalCPUs...).String())
+		writePCIEDevices(w, dev.Children, depth+1)
+	}
 }
@@ -0,0 +1,123 @@
+/*
+ * Copyright 2026 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/utils/cpuset"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	"github.com/ffromani/ctrreschk/pkg/cgroups"
+	"github.com/ffromani/ctrreschk/pkg/cpupower"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+type PowerOptions struct {
+	Governor                    string
+	EnergyPerformancePreference string
+	UncappedMaxFreq             bool
+	MaxIdleLatencyUS            int64
+}
+
+func NewPowerCommand(env *environ.Environ, opts *Options) *cobra.Command {
+	powerOpts := PowerOptions{}
+
+	powerCmd := &cobra.Command{
+		Use:   "power",
+		Short: "check the frequency scaling and idle states of the container CPUs are consistent",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cpus, err := cgroups.Cpuset(env)
+			if err != nil {
+				return err
+			}
+			states, err := cpupower.Read(env, cpus)
+			if err != nil {
+				return err
+			}
+			prof := cpupower.Profile{
+				Governor:                    powerOpts.Governor,
+				EnergyPerformancePreference: powerOpts.EnergyPerformancePreference,
+				UncappedMaxFreq:             powerOpts.UncappedMaxFreq,
+				MaxIdleLatencyUS:            powerOpts.MaxIdleLatencyUS,
+			}
+			result := buildCPUPowerInfo(cpus, states, prof)
+			err = json.NewEncoder(os.Stdout).Encode(result)
+			if err != nil {
+				return err
+			}
+			return MainLoop(opts)
+		},
+		Args: cobra.NoArgs,
+	}
+
+	powerCmd.PersistentFlags().StringVar(&powerOpts.Governor, "governor", "", "desired scaling governor (e.g. performance). Empty means don't check")
+	powerCmd.PersistentFlags().StringVar(&powerOpts.EnergyPerformancePreference, "energy-performance-preference", "", "desired energy performance preference (e.g. performance). Empty means don't check")
+	powerCmd.PersistentFlags().BoolVar(&powerOpts.UncappedMaxFreq, "uncapped-max-freq", false, "require the max scaling frequency to be the max hardware frequency")
+	powerCmd.PersistentFlags().Int64Var(&powerOpts.MaxIdleLatencyUS, "max-idle-latency", -1, "max exit latency in microseconds of the enabled idle states. Negative means don't check")
+
+	return powerCmd
+}
+
+func buildCPUPowerInfo(cpus cpuset.CPUSet, states []cpupower.CPUState, prof cpupower.Profile) apiv0.CPUPowerInfo {
+	info := apiv0.CPUPowerInfo{
+		CPUs: cpus.List(),
+	}
+	for _, st := range states {
+		stInfo := apiv0.CPUPowerStateInfo{
+			CPU:                         st.CPU,
+			Driver:                      st.Driver,
+			Governor:                    st.Governor,
+			EnergyPerformancePreference: st.EnergyPerformancePreference,
+			MinFreqKHz:                  st.MinFreqKHz,
+			MaxFreqKHz:                  st.MaxFreqKHz,
+			HWMinFreqKHz:                st.HWMinFreqKHz,
+			HWMaxFreqKHz:                st.HWMaxFreqKHz,
+		}
+		for _, idle := range st.IdleStates {
+			stInfo.IdleStates = append(stInfo.IdleStates, apiv0.CPUIdleStateInfo{
+				Name:      idle.Name,
+				LatencyUS: idle.LatencyUS,
+				Disabled:  idle.Disabled,
+			})
+		}
+		info.States = append(info.States, stInfo)
+
+		for _, dev := range prof.Deviations(st) {
+			info.Deviations = append(info.Deviations, apiv0.CPUPowerDeviationInfo{
+				CPU:       dev.CPU,
+				Attribute: dev.Attribute,
+				Expected:  dev.Expected,
+				Actual:    dev.Actual,
+			})
+		}
+	}
+	hetero := cpupower.Heterogeneous(states)
+	if len(hetero) > 0 {
+		info.Heterogeneous = make(map[string]map[string][]int)
+		for attr, cpusByValue := range hetero {
+			info.Heterogeneous[attr] = make(map[string][]int)
+			for value, valueCPUs := range cpusByValue {
+				info.Heterogeneous[attr][value] = valueCPUs.List()
+			}
+		}
+	}
+	info.Consistent = len(info.Heterogeneous) == 0 && len(info.Deviations) == 0
+	return info
+}
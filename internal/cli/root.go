@@ -65,10 +65,17 @@ func NewRootCommand(env *environ.Environ, extraCmds ...NewCommandFunc) *cobra.Co
 	root.AddCommand(
 		NewAlignCommand(env, &opts),
 		NewAlignMemCommand(env, &opts),
+		NewExecCommand(env, &opts),
 		NewInfoCommand(env, &opts),
+		NewIOMMUCommand(env, &opts),
+		NewIRQsCommand(env, &opts),
 		NewK8SCommand(env, &opts),
 		NewPauseCommand(env, &opts),
 		NewPCIEScanCommand(env, &opts),
+		NewPowerCommand(env, &opts),
+		NewSchedCommand(env, &opts),
+		NewSchemaCommand(env, &opts),
+		NewStatsCommand(env, &opts),
 	)
 	for _, extraCmd := range extraCmds {
 		root.AddCommand(extraCmd(&opts))
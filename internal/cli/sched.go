@@ -0,0 +1,100 @@
+/*
+ * Copyright 2026 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ffromani/ctrreschk/pkg/align"
+	"github.com/ffromani/ctrreschk/pkg/cgroups"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+	"github.com/ffromani/ctrreschk/pkg/machine"
+	"github.com/ffromani/ctrreschk/pkg/sched"
+)
+
+type SchedOptions struct {
+	PIDs           []int
+	Window         time.Duration
+	SampleInterval time.Duration
+}
+
+func NewSchedCommand(env *environ.Environ, opts *Options) *cobra.Command {
+	schedOpts := SchedOptions{}
+
+	schedCmd := &cobra.Command{
+		Use:   "sched",
+		Short: "show on which CPUs the container threads run and how often they migrate over a window",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if schedOpts.SampleInterval <= 0 {
+				return fmt.Errorf("invalid sample interval %v", schedOpts.SampleInterval)
+			}
+			cpus, err := cgroups.Cpuset(env)
+			if err != nil {
+				return err
+			}
+			pids := schedOpts.PIDs
+			if len(pids) == 0 {
+				pids = containerPIDs(env)
+			}
+			env.Log.V(2).Info("sampling threads", "pids", pids, "window", schedOpts.Window, "interval", schedOpts.SampleInterval)
+			win, err := sched.Sample(env, pids, schedOpts.Window, schedOpts.SampleInterval)
+			if err != nil {
+				return err
+			}
+			machine, err := machine.Discover(env)
+			if err != nil {
+				return err
+			}
+			result := align.CheckThreads(env, cpus, win, machine)
+			err = json.NewEncoder(os.Stdout).Encode(result)
+			if err != nil {
+				return err
+			}
+			return MainLoop(opts)
+		},
+		Args: cobra.NoArgs,
+	}
+
+	schedCmd.PersistentFlags().StringVarP(&env.DataPath, "machinedata", "M", "", "read fake machine data from path, don't read real data from the system")
+	schedCmd.PersistentFlags().IntSliceVar(&schedOpts.PIDs, "pid", nil, "sample the threads of these processes. Default is all the processes in the container cgroup but ctrreschk itself")
+	schedCmd.PersistentFlags().DurationVar(&schedOpts.Window, "window", 10*time.Second, "sampling window")
+	schedCmd.PersistentFlags().DurationVar(&schedOpts.SampleInterval, "sample-interval", 100*time.Millisecond, "interval between the samples of the CPU each thread runs on")
+
+	return schedCmd
+}
+
+// containerPIDs returns the processes of the container cgroup but ctrreschk itself, which is
+// sampled only if it is alone, e.g. when it fronts the workload through exec.
+func containerPIDs(env *environ.Environ) []int {
+	self := os.Getpid()
+	pids, err := cgroups.Procs(env)
+	if err != nil {
+		env.Log.V(1).Info("cannot read the container processes, sampling only self", "error", err)
+		return []int{self}
+	}
+	pids = slices.DeleteFunc(pids, func(pid int) bool { return pid == self })
+	if len(pids) == 0 {
+		return []int{self}
+	}
+	return pids
+}
@@ -0,0 +1,40 @@
+/*
+ * Copyright 2026 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+func TestSchedCommandInvalidSampleInterval(t *testing.T) {
+	for _, interval := range []string{"0s", "-100ms"} {
+		t.Run(interval, func(t *testing.T) {
+			cmd := NewSchedCommand(environ.New(), &Options{})
+			cmd.SetArgs([]string{"--sample-interval=" + interval})
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			err := cmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), "invalid sample interval") {
+				t.Fatalf("expected invalid sample interval error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright 2026 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ffromani/ctrreschk/api/schema"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+func NewSchemaCommand(env *environ.Environ, opts *Options) *cobra.Command {
+	schemaCmd := &cobra.Command{
+		Use:   "schema [type]",
+		Short: "show the JSON Schema of the output of the commands, or list the available types",
+		Long:  "show the JSON Schema of the output of the commands. Types are like v0.Allocation; without version, the latest version is used.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				for _, doc := range schema.Documents {
+					fmt.Fprintln(os.Stdout, doc.Name())
+				}
+				return MainLoop(opts)
+			}
+			data, err := schema.Get(args[0])
+			if err != nil {
+				return err
+			}
+			env.Log.V(2).Info("schema", "type", args[0], "size", len(data))
+			_, err = os.Stdout.Write(data)
+			if err != nil {
+				return err
+			}
+			return MainLoop(opts)
+		},
+		Args: cobra.MaximumNArgs(1),
+	}
+	return schemaCmd
+}
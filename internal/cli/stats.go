@@ -0,0 +1,135 @@
+/*
+ * Copyright 2026 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	"github.com/ffromani/ctrreschk/pkg/cgroups"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+type StatsOptions struct {
+	Interval time.Duration
+}
+
+func NewStatsCommand(env *environ.Environ, opts *Options) *cobra.Command {
+	statsOpts := StatsOptions{}
+
+	statsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "show the CPU throttling and the resource pressure of the container cgroup",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			st, err := cgroups.ReadStats(env)
+			if err != nil {
+				return err
+			}
+			var rates *cgroups.Rates
+			if statsOpts.Interval > 0 {
+				env.Log.V(2).Info("sampling the cgroup statistics", "interval", statsOpts.Interval)
+				time.Sleep(statsOpts.Interval)
+				cur, err := cgroups.ReadStats(env)
+				if err != nil {
+					return err
+				}
+				rt := cur.RatesSince(st)
+				rates = &rt
+				st = cur
+			}
+			result := buildCgroupStatsInfo(st, rates)
+			err = json.NewEncoder(os.Stdout).Encode(result)
+			if err != nil {
+				return err
+			}
+			return MainLoop(opts)
+		},
+		Args: cobra.NoArgs,
+	}
+
+	statsCmd.PersistentFlags().DurationVar(&statsOpts.Interval, "interval", 0, "sample the statistics twice over this interval to report the rates. Zero means sample once")
+
+	return statsCmd
+}
+
+func buildCgroupStatsInfo(st cgroups.Stats, rates *cgroups.Rates) apiv0.CgroupStatsInfo {
+	info := apiv0.CgroupStatsInfo{
+		CPU: apiv0.CPUStatInfo{
+			UsageUS:          st.CPU.UsageUSec,
+			UserUS:           st.CPU.UserUSec,
+			SystemUS:         st.CPU.SystemUSec,
+			Periods:          st.CPU.NrPeriods,
+			ThrottledPeriods: st.CPU.NrThrottled,
+			ThrottledUS:      st.CPU.ThrottledUSec,
+		},
+		CPUPressure:    buildPressureInfo(st.CPUPressure),
+		MemoryPressure: buildPressureInfo(st.MemoryPressure),
+		IOPressure:     buildPressureInfo(st.IOPressure),
+		Throttled:      st.CPU.NrThrottled > 0,
+	}
+	if rates != nil {
+		info.Rates = &apiv0.CgroupRatesInfo{
+			IntervalMS:              rates.Interval.Milliseconds(),
+			UsageCPUs:               rates.UsageCPUs,
+			ThrottledPeriodsPercent: rates.ThrottledPeriodsPercent,
+			ThrottledPeriods:        rates.NrThrottled,
+			ThrottledUS:             rates.ThrottledUSec,
+			CPUStall:                buildStallRateInfo(rates.CPUStall),
+			MemoryStall:             buildStallRateInfo(rates.MemoryStall),
+			IOStall:                 buildStallRateInfo(rates.IOStall),
+		}
+		info.Throttled = rates.NrThrottled > 0
+	}
+	return info
+}
+
+func buildPressureInfo(pr *cgroups.Pressure) *apiv0.PressureInfo {
+	if pr == nil {
+		return nil
+	}
+	info := apiv0.PressureInfo{
+		Some: buildPressureStallInfo(pr.Some),
+	}
+	if pr.Full != nil {
+		full := buildPressureStallInfo(*pr.Full)
+		info.Full = &full
+	}
+	return &info
+}
+
+func buildPressureStallInfo(pl cgroups.PressureLine) apiv0.PressureStallInfo {
+	return apiv0.PressureStallInfo{
+		Avg10:   pl.Avg10,
+		Avg60:   pl.Avg60,
+		Avg300:  pl.Avg300,
+		TotalUS: pl.Total,
+	}
+}
+
+func buildStallRateInfo(sr *cgroups.StallRate) *apiv0.StallRateInfo {
+	if sr == nil {
+		return nil
+	}
+	return &apiv0.StallRateInfo{
+		SomePercent: sr.SomePercent,
+		FullPercent: sr.FullPercent,
+	}
+}
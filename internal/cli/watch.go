@@ -0,0 +1,137 @@
+/*
+ * Copyright 2026 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	apiv1 "github.com/ffromani/ctrreschk/api/v1"
+	"github.com/ffromani/ctrreschk/pkg/cgroups"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+	"github.com/ffromani/ctrreschk/pkg/watch"
+)
+
+const (
+	DefaultWatchInterval = time.Minute
+)
+
+// watchAlign keeps re-checking the alignment periodically until SIGINT or SIGTERM. A new report, followed by the diff
+// from the previous one, is emitted only when the allocation changes. Failed re-checks are logged and don't stop the loop.
+// The writes to the cgroup cpuset and CPU quota trigger an early re-check, but this is best-effort: the changes
+// inherited from the parent cgroup, which only show in the effective cpuset, emit no notification.
+func watchAlign(env *environ.Environ, alignOpts AlignOptions, result apiv0.Allocation, resultV1 apiv1.Allocation) error {
+	exitSignal := make(chan os.Signal, 1)
+	signal.Notify(exitSignal, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(exitSignal)
+
+	// a nil channel blocks forever, so without notifications we rely on the periodic check only
+	var events <-chan struct{}
+	notifier, err := watch.NewFileNotifier(env, cgroups.CpusetCPUsPath(env), cgroups.CpusetMemsPath(env), cgroups.CPUMaxPath(env))
+	if err != nil {
+		env.Log.V(1).Info("cannot watch the cgroup files, checking periodically only", "error", err)
+	} else {
+		defer notifier.Close()
+		events = notifier.Events()
+	}
+
+	ticker := time.NewTicker(alignOpts.WatchInterval)
+	defer ticker.Stop()
+
+	prev := watchedResult(alignOpts, result, resultV1)
+	for {
+		select {
+		case <-exitSignal:
+			return nil
+		case _, ok := <-events:
+			if !ok {
+				env.Log.V(1).Info("cgroup files notifications stopped, checking periodically only")
+				events = nil
+				continue
+			}
+			env.Log.V(2).Info("cgroup files changed, re-checking")
+		case <-ticker.C:
+			env.Log.V(2).Info("periodic re-check")
+		}
+
+		result, resultV1, err := runAlign(env, alignOpts)
+		if err != nil {
+			env.Log.V(1).Info("re-check failed", "error", err)
+			continue
+		}
+		cur := watchedResult(alignOpts, result, resultV1)
+		changes, err := watch.Diff(prev, cur)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			env.Log.V(2).Info("allocation unchanged")
+			continue
+		}
+		env.Log.V(1).Info("allocation changed", "changes", len(changes), "status", resultV1.Status)
+
+		err = writeAlignResult(env, alignOpts, result, resultV1)
+		if err != nil {
+			return err
+		}
+		diff := apiv1.AllocationDiff{
+			APIVersion: apiv1.APIVersion,
+			Kind:       apiv1.KindAllocationDiff,
+			Timestamp:  resultV1.Metadata.Timestamp,
+			Status:     resultV1.Status,
+			Changes:    changes,
+		}
+		err = json.NewEncoder(os.Stdout).Encode(diff)
+		if err != nil {
+			return err
+		}
+		prev = cur
+	}
+}
+
+// watchedResult returns the report in the requested API version, without the run metadata
+// and the resctrl group tasks, which change on every check or when the container threads come and go.
+func watchedResult(alignOpts AlignOptions, result apiv0.Allocation, resultV1 apiv1.Allocation) any {
+	if alignOpts.APIVersion == "v0" {
+		result.Resctrl = withoutResctrlTasks(result.Resctrl)
+		return result
+	}
+	resultV1.Metadata = apiv1.Metadata{}
+	resultV1.Resctrl = withoutResctrlTasks(resultV1.Resctrl)
+	return resultV1
+}
+
+// withoutResctrlTasks returns a copy of the resctrl details without the thread IDs. The group assignments
+// are still compared, so a thread moving to another group is reported.
+func withoutResctrlTasks(info *apiv0.ResctrlInfo) *apiv0.ResctrlInfo {
+	if info == nil {
+		return nil
+	}
+	ret := apiv0.ResctrlInfo{
+		Groups:   make([]apiv0.ResctrlGroupInfo, 0, len(info.Groups)),
+		Confined: info.Confined,
+	}
+	for _, group := range info.Groups {
+		group.Tasks = nil
+		ret.Groups = append(ret.Groups, group)
+	}
+	return &ret
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2026 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"testing"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	apiv1 "github.com/ffromani/ctrreschk/api/v1"
+	"github.com/ffromani/ctrreschk/pkg/watch"
+)
+
+func TestWatchedResultIgnoresResctrlTasks(t *testing.T) {
+	resctrlWith := func(group string, tasks ...int) *apiv0.ResctrlInfo {
+		return &apiv0.ResctrlInfo{
+			Groups: []apiv0.ResctrlGroupInfo{
+				{
+					Group:   group,
+					Tasks:   tasks,
+					Domains: []apiv0.ResctrlDomainInfo{{Domain: 0, CPUs: []int{2, 3}, CBM: "ff"}},
+				},
+			},
+			Confined: group != "/",
+		}
+	}
+
+	testCases := []struct {
+		name            string
+		apiVersion      string
+		prev            *apiv0.ResctrlInfo
+		cur             *apiv0.ResctrlInfo
+		expectedChanges int
+	}{
+		{
+			name:       "v0 threads churn",
+			apiVersion: "v0",
+			prev:       resctrlWith("rt", 100, 101),
+			cur:        resctrlWith("rt", 100, 102, 103),
+		},
+		{
+			name:       "v1 threads churn",
+			apiVersion: "v1",
+			prev:       resctrlWith("rt", 100, 101),
+			cur:        resctrlWith("rt", 100, 102, 103),
+		},
+		{
+			name:            "v0 threads moved to another group",
+			apiVersion:      "v0",
+			prev:            resctrlWith("rt", 100, 101),
+			cur:             resctrlWith("/", 100, 101),
+			expectedChanges: 2,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := AlignOptions{APIVersion: tt.apiVersion}
+			prevV0 := apiv0.Allocation{Resctrl: tt.prev}
+			curV0 := apiv0.Allocation{Resctrl: tt.cur}
+
+			changes, err := watch.Diff(
+				watchedResult(opts, prevV0, apiv1.FromV0(prevV0, apiv1.Metadata{})),
+				watchedResult(opts, curV0, apiv1.FromV0(curV0, apiv1.Metadata{})),
+			)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(changes) != tt.expectedChanges {
+				t.Fatalf("expected %d changes got %d: %+v", tt.expectedChanges, len(changes), changes)
+			}
+			if len(tt.cur.Groups[0].Tasks) == 0 {
+				t.Fatalf("the reported result was modified")
+			}
+		})
+	}
+}
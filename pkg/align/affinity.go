@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package align
+
+import (
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+	"github.com/ffromani/ctrreschk/pkg/resources"
+)
+
+// checkDeviceAffinity verifies the devices are co-located with each other, regardless of the container CPUs.
+// This matters for peer-to-peer transfers (e.g. GPUDirect RDMA) which are much faster if they don't
+// need to cross the PCIe root complex.
+func checkDeviceAffinity(env *environ.Environ, resp *apiv0.Allocation, devices []resources.DeviceInfo, rmap rMap) {
+	var known []resources.DeviceInfo
+	for _, dev := range devices {
+		if len(dev.PCIEPath) < 2 {
+			env.Log.V(2).Info("device PCIe hierarchy unknown, skipping", "pciAddress", dev.PCIAddress)
+			continue
+		}
+		known = append(known, dev)
+	}
+	if len(known) < 2 {
+		env.Log.V(1).Info("not enough devices with known PCIe hierarchy, skipping device affinity check", "devices", len(known))
+		return
+	}
+
+	info := apiv0.DeviceAffinityInfo{
+		PCIERoots: make(map[string][]string),
+		Switches:  make(map[string][]string),
+		NUMANodes: make(map[int][]string),
+	}
+	numaKnown := 0
+	for _, dev := range known {
+		root := dev.PCIEPath[0]
+		info.PCIERoots[root] = append(info.PCIERoots[root], dev.PCIAddress)
+		// the path is root complex, root port, then the switch upstream port if the device sits behind a switch;
+		// devices attached directly to a root port or to the root complex (e.g. integrated endpoints) have no switch
+		if len(dev.PCIEPath) > 3 {
+			upstream := dev.PCIEPath[2]
+			info.Switches[upstream] = append(info.Switches[upstream], dev.PCIAddress)
+		}
+		if numaNode := deviceNUMANode(dev, rmap); numaNode != -1 {
+			info.NUMANodes[numaNode] = append(info.NUMANodes[numaNode], dev.PCIAddress)
+			numaKnown++
+		}
+	}
+
+	// sharing only the root complex and the root port, like the VFs of the same PF, is not sharing a switch
+	ancestors := commonAncestors(known)
+	switch {
+	case len(ancestors) > 2:
+		info.Level = apiv0.DeviceAffinitySwitch
+		info.CommonAncestor = ancestors[len(ancestors)-1]
+	case len(ancestors) > 0:
+		info.Level = apiv0.DeviceAffinityPCIERoot
+		info.CommonAncestor = ancestors[len(ancestors)-1]
+	case len(info.NUMANodes) == 1 && numaKnown == len(known):
+		info.Level = apiv0.DeviceAffinityNUMA
+	default:
+		info.Level = apiv0.DeviceAffinityNone
+	}
+	env.Log.V(2).Info("check device affinity", "devices", len(known), "level", info.Level, "commonAncestor", info.CommonAncestor)
+
+	colocated := info.Level == apiv0.DeviceAffinitySwitch || info.Level == apiv0.DeviceAffinityPCIERoot
+	resp.Alignment.DeviceAffinity = &colocated
+	resp.DeviceAffinity = &info
+}
+
+// commonAncestors returns the longest chain of upstream devices, starting from the PCIe root complex,
+// shared by all the given devices. The devices themselves are never part of the chain.
+func commonAncestors(devices []resources.DeviceInfo) []string {
+	ancestors := devices[0].PCIEPath[:len(devices[0].PCIEPath)-1]
+	for _, dev := range devices[1:] {
+		devAncestors := dev.PCIEPath[:len(dev.PCIEPath)-1]
+		idx := 0
+		for idx < len(ancestors) && idx < len(devAncestors) && ancestors[idx] == devAncestors[idx] {
+			idx++
+		}
+		ancestors = ancestors[:idx]
+	}
+	return ancestors
+}
@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package align
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/utils/cpuset"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+	"github.com/ffromani/ctrreschk/pkg/resources"
+)
+
+func TestCheckDeviceAffinity(t *testing.T) {
+	info := loadMachine(t)
+	env := environ.New()
+
+	nic := resources.DeviceInfo{
+		PCIAddress: "0000:1b:00.0",
+		NUMANode:   0,
+		PCIEPath:   []string{"pci0000:16", "0000:16:02.0", "0000:17:00.0", "0000:18:08.0", "0000:1b:00.0"},
+	}
+	gpuSameSwitch := resources.DeviceInfo{
+		PCIAddress: "0000:1c:00.0",
+		NUMANode:   0,
+		PCIEPath:   []string{"pci0000:16", "0000:16:02.0", "0000:17:00.0", "0000:18:10.0", "0000:1c:00.0"},
+	}
+	gpuSameRoot := resources.DeviceInfo{
+		PCIAddress: "0000:20:00.0",
+		NUMANode:   0,
+		PCIEPath:   []string{"pci0000:16", "0000:16:04.0", "0000:20:00.0"},
+	}
+	vf0 := resources.DeviceInfo{
+		PCIAddress: "0000:21:00.1",
+		NUMANode:   0,
+		PCIEPath:   []string{"pci0000:16", "0000:16:06.0", "0000:21:00.1"},
+	}
+	vf1 := resources.DeviceInfo{
+		PCIAddress: "0000:21:00.2",
+		NUMANode:   0,
+		PCIEPath:   []string{"pci0000:16", "0000:16:06.0", "0000:21:00.2"},
+	}
+	gpuSameNUMA := resources.DeviceInfo{
+		PCIAddress: "0000:41:00.0",
+		NUMANode:   -1,
+		LocalCPUs:  mustParseCPUSet(t, "0-31"),
+		PCIEPath:   []string{"pci0000:40", "0000:40:01.0", "0000:41:00.0"},
+	}
+	gpuOtherNUMA := resources.DeviceInfo{
+		PCIAddress: "0000:c1:00.0",
+		NUMANode:   1,
+		PCIEPath:   []string{"pci0000:c0", "0000:c0:01.0", "0000:c1:00.0"},
+	}
+
+	testCases := []struct {
+		name              string
+		devices           []resources.DeviceInfo
+		expectedAlignment *bool
+		expectedInfo      *apiv0.DeviceAffinityInfo
+	}{
+		{
+			name:    "single device skipped",
+			devices: []resources.DeviceInfo{nic},
+		},
+		{
+			name:    "unknown hierarchy skipped",
+			devices: []resources.DeviceInfo{nic, {PCIAddress: "0000:99:00.0", NUMANode: 0}},
+		},
+		{
+			name:              "same switch",
+			devices:           []resources.DeviceInfo{nic, gpuSameSwitch},
+			expectedAlignment: boolPtr(true),
+			expectedInfo: &apiv0.DeviceAffinityInfo{
+				Level:          apiv0.DeviceAffinitySwitch,
+				CommonAncestor: "0000:17:00.0",
+				PCIERoots:      map[string][]string{"pci0000:16": {"0000:1b:00.0", "0000:1c:00.0"}},
+				Switches:       map[string][]string{"0000:17:00.0": {"0000:1b:00.0", "0000:1c:00.0"}},
+				NUMANodes:      map[int][]string{0: {"0000:1b:00.0", "0000:1c:00.0"}},
+			},
+		},
+		{
+			name:              "same pcie root",
+			devices:           []resources.DeviceInfo{nic, gpuSameRoot},
+			expectedAlignment: boolPtr(true),
+			expectedInfo: &apiv0.DeviceAffinityInfo{
+				Level:          apiv0.DeviceAffinityPCIERoot,
+				CommonAncestor: "pci0000:16",
+				PCIERoots:      map[string][]string{"pci0000:16": {"0000:1b:00.0", "0000:20:00.0"}},
+				Switches:       map[string][]string{"0000:17:00.0": {"0000:1b:00.0"}},
+				NUMANodes:      map[int][]string{0: {"0000:1b:00.0", "0000:20:00.0"}},
+			},
+		},
+		{
+			name:              "same root port without switch",
+			devices:           []resources.DeviceInfo{vf0, vf1},
+			expectedAlignment: boolPtr(true),
+			expectedInfo: &apiv0.DeviceAffinityInfo{
+				Level:          apiv0.DeviceAffinityPCIERoot,
+				CommonAncestor: "0000:16:06.0",
+				PCIERoots:      map[string][]string{"pci0000:16": {"0000:21:00.1", "0000:21:00.2"}},
+				Switches:       map[string][]string{},
+				NUMANodes:      map[int][]string{0: {"0000:21:00.1", "0000:21:00.2"}},
+			},
+		},
+		{
+			name:              "same numa node using local cpus",
+			devices:           []resources.DeviceInfo{nic, gpuSameNUMA},
+			expectedAlignment: boolPtr(false),
+			expectedInfo: &apiv0.DeviceAffinityInfo{
+				Level: apiv0.DeviceAffinityNUMA,
+				PCIERoots: map[string][]string{
+					"pci0000:16": {"0000:1b:00.0"},
+					"pci0000:40": {"0000:41:00.0"},
+				},
+				Switches:  map[string][]string{"0000:17:00.0": {"0000:1b:00.0"}},
+				NUMANodes: map[int][]string{0: {"0000:1b:00.0", "0000:41:00.0"}},
+			},
+		},
+		{
+			name:              "different numa nodes",
+			devices:           []resources.DeviceInfo{nic, gpuOtherNUMA},
+			expectedAlignment: boolPtr(false),
+			expectedInfo: &apiv0.DeviceAffinityInfo{
+				Level: apiv0.DeviceAffinityNone,
+				PCIERoots: map[string][]string{
+					"pci0000:16": {"0000:1b:00.0"},
+					"pci0000:c0": {"0000:c1:00.0"},
+				},
+				Switches: map[string][]string{"0000:17:00.0": {"0000:1b:00.0"}},
+				NUMANodes: map[int][]string{
+					0: {"0000:1b:00.0"},
+					1: {"0000:c1:00.0"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Check(env, resources.Resources{CPUs: cpuset.New(0, 16), Devices: tt.devices}, info)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expectedAlignment, got.Alignment.DeviceAffinity); diff != "" {
+				t.Errorf("device affinity alignment mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedInfo, got.DeviceAffinity); diff != "" {
+				t.Errorf("device affinity info mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
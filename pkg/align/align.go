@@ -26,13 +26,14 @@ import (
 	"github.com/jaypipes/ghw/pkg/topology"
 
 	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	"github.com/ffromani/ctrreschk/pkg/coretype"
 	"github.com/ffromani/ctrreschk/pkg/environ"
 	"github.com/ffromani/ctrreschk/pkg/machine"
 	"github.com/ffromani/ctrreschk/pkg/resources"
 )
 
 func Check(env *environ.Environ, container resources.Resources, machine machine.Machine) (apiv0.Allocation, error) {
-	rmap := makeRMap(env, machine.Topology)
+	rmap := makeRMap(env, machine.Topology, machine.CoreTypes)
 	env.Log.V(2).Info("reverse mapping", "rmap", rmap)
 
 	if !container.MEMs.IsEmpty() && rmap.totalMemory <= 0 {
@@ -46,8 +47,15 @@ func Check(env *environ.Environ, container resources.Resources, machine machine.
 	checkNUMA(env, &resp, container.CPUs.Clone(), rmap)
 	checkMemory(env, &resp, container.CPUs.Clone(), container.MEMs.Clone(), rmap)
 	checkDevices(env, &resp, container.CPUs.Clone(), container.Devices, rmap)
+	checkPCIERoot(env, &resp, container.CPUs.Clone(), container.Devices)
+	checkStorage(env, &resp, container.CPUs.Clone(), container.Storage, rmap)
+	checkDeviceAffinity(env, &resp, container.Devices, rmap)
+	checkIsolation(env, &resp, container.CPUs.Clone(), machine.Isolation, machine.IsolationErr)
+	checkCoreType(env, &resp, container.CPUs.Clone(), rmap, machine.CoreTypesErr)
+	checkResctrl(env, &resp, container.CPUs.Clone(), container.Resctrl)
+	checkLimits(env, &resp, container.CPUs.Clone(), container.Limits, container.LimitsErr)
 
-	env.Log.V(2).Info("alignment check complete", "smt", resp.Alignment.SMT, "llc", resp.Alignment.LLC, "numa", resp.Alignment.NUMA, "memory", resp.Alignment.Memory, "devices", resp.Alignment.Devices)
+	env.Log.V(2).Info("alignment check complete", "smt", resp.Alignment.SMT, "llc", resp.Alignment.LLC, "numa", resp.Alignment.NUMA, "memory", resp.Alignment.Memory, "devices", resp.Alignment.Devices, "pcieRoot", resp.Alignment.PCIERoot, "storage", resp.Alignment.Storage, "deviceAffinity", resp.Alignment.DeviceAffinity, "coreType", resp.Alignment.CoreType)
 
 	return resp, nil
 }
@@ -143,13 +151,7 @@ func checkMemory(env *environ.Environ, resp *apiv0.Allocation, cpus cpuset.CPUSe
 	}
 
 	// determine which NUMA nodes the container CPUs belong to
-	cpuNUMANodes := cpuset.New()
-	for numaID := range rmap.numa {
-		numaCPUs := rmap.numa.CPUSet(numaID)
-		if !cpus.Intersection(numaCPUs).IsEmpty() {
-			cpuNUMANodes = cpuNUMANodes.Union(cpuset.New(numaID))
-		}
-	}
+	cpuNUMANodes := rmap.numaNodesForCPUs(cpus)
 
 	env.Log.V(2).Info("check memory alignment", "cpuNUMANodes", cpuNUMANodes.String(), "mems", mems.String(), "totalMemory", rmap.totalMemory)
 
@@ -201,45 +203,137 @@ func checkDevices(env *environ.Environ, resp *apiv0.Allocation, cpus cpuset.CPUS
 		return
 	}
 
-	cpuNUMANodes := cpuset.New()
-	for numaID := range rmap.numa {
-		numaCPUs := rmap.numa.CPUSet(numaID)
-		if !cpus.Intersection(numaCPUs).IsEmpty() {
-			cpuNUMANodes = cpuNUMANodes.Union(cpuset.New(numaID))
-		}
-	}
+	cpuNUMANodes := rmap.numaNodesForCPUs(cpus)
 
 	aligned := true
+	var notFound []string
 	for _, dev := range devices {
-		if dev.NUMANode == -1 {
+		devNUMANode := deviceNUMANode(dev, rmap)
+		resp.Devices = append(resp.Devices, deviceDetails(dev, devNUMANode))
+		if dev.NotFound {
+			notFound = append(notFound, dev.PCIAddress)
+			continue
+		}
+		if devNUMANode == -1 {
 			env.Log.V(2).Info("device NUMA node unknown, skipping", "pciAddress", dev.PCIAddress)
 			continue
 		}
 
-		env.Log.V(2).Info("check device alignment", "pciAddress", dev.PCIAddress, "deviceNUMA", dev.NUMANode, "cpuNUMANodes", cpuNUMANodes.String())
+		env.Log.V(2).Info("check device alignment", "pciAddress", dev.PCIAddress, "deviceNUMA", devNUMANode, "cpuNUMANodes", cpuNUMANodes.String())
 
-		if cpuNUMANodes.Contains(dev.NUMANode) {
+		if cpuNUMANodes.Contains(devNUMANode) {
 			if resp.Aligned == nil {
 				resp.Aligned = apiv0.NewAlignedInfo()
 			}
-			dets := resp.Aligned.NUMA[dev.NUMANode]
+			dets := resp.Aligned.NUMA[devNUMANode]
 			dets.Devices = append(dets.Devices, dev.PCIAddress)
-			resp.Aligned.NUMA[dev.NUMANode] = dets
+			resp.Aligned.NUMA[devNUMANode] = dets
 		} else {
 			aligned = false
 			if resp.Unaligned == nil {
 				resp.Unaligned = &apiv0.UnalignedInfo{}
 			}
 			resp.Unaligned.Devices.Devices = append(resp.Unaligned.Devices.Devices, dev.PCIAddress)
-			if !slices.Contains(resp.Unaligned.Devices.NUMANodes, dev.NUMANode) {
-				resp.Unaligned.Devices.NUMANodes = append(resp.Unaligned.Devices.NUMANodes, dev.NUMANode)
+			if !slices.Contains(resp.Unaligned.Devices.NUMANodes, devNUMANode) {
+				resp.Unaligned.Devices.NUMANodes = append(resp.Unaligned.Devices.NUMANodes, devNUMANode)
 			}
 		}
 	}
 
+	if len(notFound) > 0 {
+		setError(resp, apiv0.CheckDevices, "devices not found: "+strings.Join(notFound, ","))
+	}
 	resp.Alignment.Devices = &aligned
 }
 
+// setError records the check could not gather the data it needs.
+func setError(resp *apiv0.Allocation, name, reason string) {
+	if resp.Errors == nil {
+		resp.Errors = make(map[string]string)
+	}
+	resp.Errors[name] = reason
+}
+
+func deviceDetails(dev resources.DeviceInfo, numaNode int) apiv0.DeviceDetails {
+	dets := apiv0.DeviceDetails{
+		PCIAddress:  dev.PCIAddress,
+		Driver:      dev.Driver,
+		NUMANode:    numaNode,
+		PCIERoot:    dev.PCIERoot,
+		NetDev:      dev.NetDev,
+		BlockDevs:   dev.BlockDevs,
+		MountPoints: dev.MountPoints,
+	}
+	if dev.PhysFn != "" {
+		dets.PhysFn = &apiv0.PhysFnInfo{
+			Address:  dev.PhysFn,
+			NetDev:   dev.PhysFnNetDev,
+			NUMANode: dev.PhysFnNUMANode,
+			PCIERoot: dev.PhysFnPCIERoot,
+			VFIndex:  dev.VFIndex,
+		}
+	}
+	return dets
+}
+
+// deviceNUMANode returns the NUMA node of the device, or -1 if unknown.
+// numa_node is -1 on many single-node VMs, but local_cpulist is still meaningful, so we fall back to it.
+func deviceNUMANode(dev resources.DeviceInfo, rmap rMap) int {
+	if dev.NUMANode != -1 {
+		return dev.NUMANode
+	}
+	return rmap.numaNodeForCPUs(dev.LocalCPUs)
+}
+
+// checkPCIERoot verifies the container CPUs are local to the PCIe root complex of each device.
+// This is stricter than the NUMA check on machines with multiple root complexes per NUMA node (SNC, NPS).
+func checkPCIERoot(env *environ.Environ, resp *apiv0.Allocation, cpus cpuset.CPUSet, devices []resources.DeviceInfo) {
+	checked := 0
+	aligned := true
+	unalignedCPUs := cpuset.New()
+	var notFound []string
+	for _, dev := range devices {
+		if dev.NotFound {
+			notFound = append(notFound, dev.PCIAddress)
+			continue
+		}
+		if dev.PCIERoot == "" || dev.LocalCPUs.IsEmpty() {
+			env.Log.V(2).Info("device PCIe root unknown, skipping", "pciAddress", dev.PCIAddress)
+			continue
+		}
+		checked++
+
+		env.Log.V(2).Info("check PCIe root alignment", "pciAddress", dev.PCIAddress, "pcieRoot", dev.PCIERoot, "localCPUs", dev.LocalCPUs.String(), "cpus", cpus.String())
+
+		if cpus.IsSubsetOf(dev.LocalCPUs) {
+			if resp.Aligned == nil {
+				resp.Aligned = apiv0.NewAlignedInfo()
+			}
+			dets := resp.Aligned.PCIERoot[dev.PCIERoot]
+			dets.CPUs = cpus.List()
+			dets.Devices = append(dets.Devices, dev.PCIAddress)
+			resp.Aligned.PCIERoot[dev.PCIERoot] = dets
+		} else {
+			aligned = false
+			if resp.Unaligned == nil {
+				resp.Unaligned = &apiv0.UnalignedInfo{}
+			}
+			resp.Unaligned.PCIERoot.Devices = append(resp.Unaligned.PCIERoot.Devices, dev.PCIAddress)
+			unalignedCPUs = unalignedCPUs.Union(cpus.Difference(dev.LocalCPUs))
+			resp.Unaligned.PCIERoot.CPUs = unalignedCPUs.List()
+		}
+	}
+
+	if len(notFound) > 0 {
+		setError(resp, apiv0.CheckPCIERoot, "devices not found: "+strings.Join(notFound, ","))
+	}
+	if checked == 0 {
+		env.Log.V(1).Info("no devices with known PCIe root, skipping PCIe root alignment check")
+		return
+	}
+	resp.Alignment.PCIERoot = &aligned
+}
+
 // Reverse ID MAP (PhysicalID|LLCID|NUMAID) -> LogicalIDs
 type ridMap map[int][]int
 
@@ -255,14 +349,39 @@ func (rm ridMap) String() string {
 	return sb.String()[1:]
 }
 
+// numaNodeForCPUs returns the NUMA node which includes all the given cpus, or -1 if there is none.
+func (rm rMap) numaNodeForCPUs(cpus cpuset.CPUSet) int {
+	if cpus.IsEmpty() {
+		return -1
+	}
+	for numaID := range rm.numa {
+		if cpus.IsSubsetOf(rm.numa.CPUSet(numaID)) {
+			return numaID
+		}
+	}
+	return -1
+}
+
+// numaNodesForCPUs returns the NUMA nodes which include at least one of the given cpus.
+func (rm rMap) numaNodesForCPUs(cpus cpuset.CPUSet) cpuset.CPUSet {
+	numaNodes := cpuset.New()
+	for numaID := range rm.numa {
+		if !cpus.Intersection(rm.numa.CPUSet(numaID)).IsEmpty() {
+			numaNodes = numaNodes.Union(cpuset.New(numaID))
+		}
+	}
+	return numaNodes
+}
+
 // Resource MAPping
 type rMap struct {
 	cpuLog2Phy  map[int]int
 	cpuPhy2Log  ridMap
 	llc         ridMap
 	numa        ridMap
-	numaMemory  map[int]int64 // numaID -> usable bytes
-	totalMemory int64         // sum of all NUMA nodes usable bytes
+	numaMemory  map[int]int64            // numaID -> usable bytes
+	totalMemory int64                    // sum of all NUMA nodes usable bytes
+	coreTypes   map[string]cpuset.CPUSet // core class -> logical IDs, empty if homogeneous
 }
 
 func (rm rMap) String() string {
@@ -276,11 +395,18 @@ func newRMap() rMap {
 		llc:        make(ridMap),
 		numa:       make(ridMap),
 		numaMemory: make(map[int]int64),
+		coreTypes:  make(map[string]cpuset.CPUSet),
 	}
 }
 
-func makeRMap(env *environ.Environ, topo *topology.Info) rMap {
+func makeRMap(env *environ.Environ, topo *topology.Info, coreTypes *coretype.Info) rMap {
 	res := newRMap()
+	if coreTypes != nil {
+		for class, cpus := range coreTypes.Classes {
+			res.coreTypes[class] = cpus.Clone()
+			env.Log.V(4).Info("rmap core class -> vcpus", "class", class, "vcpus", cpus.String())
+		}
+	}
 	llcID := 0
 	for _, node := range topo.Nodes {
 		for _, core := range node.Cores {
@@ -18,35 +18,29 @@ package align
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	goruntime "runtime"
 	"testing"
+	"time"
 
 	"k8s.io/utils/cpuset"
 
 	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	"github.com/ffromani/ctrreschk/pkg/cgroups"
+	"github.com/ffromani/ctrreschk/pkg/coretype"
 	"github.com/ffromani/ctrreschk/pkg/environ"
+	"github.com/ffromani/ctrreschk/pkg/isolation"
 	"github.com/ffromani/ctrreschk/pkg/machine"
+	"github.com/ffromani/ctrreschk/pkg/resctrl"
 	"github.com/ffromani/ctrreschk/pkg/resources"
+	"github.com/ffromani/ctrreschk/pkg/sched"
 )
 
 func TestCheck(t *testing.T) {
-	root, err := getRootPath()
-	if err != nil {
-		t.Fatalf("cannot find the root: %v", err)
-	}
-	machinePath := filepath.Join(root, "hack", "machine.json")
-	machineData, err := os.ReadFile(machinePath)
-	if err != nil {
-		t.Fatalf("cannot read machine info: %v", err)
-	}
-
-	info, err := machine.FromJSON(string(machineData))
-	if err != nil {
-		t.Fatalf("cannot decode machine info: %v", err)
-	}
+	info := loadMachine(t)
 	env := environ.New()
 
 	testCases := []struct {
@@ -195,6 +189,9 @@ func TestCheck(t *testing.T) {
 						},
 					},
 				},
+				Devices: []apiv0.DeviceDetails{
+					{PCIAddress: "0000:05:10.2", NUMANode: 0},
+				},
 			},
 		},
 		{
@@ -239,6 +236,66 @@ func TestCheck(t *testing.T) {
 						NUMANodes: []int{1},
 					},
 				},
+				Devices: []apiv0.DeviceDetails{
+					{PCIAddress: "0000:05:10.2", NUMANode: 1},
+				},
+			},
+		},
+		{
+			name: "SR-IOV VF on wrong numa node",
+			res: resources.Resources{
+				CPUs: cpuset.New(0, 16),
+				MEMs: cpuset.New(0),
+				Devices: []resources.DeviceInfo{
+					{EnvVar: "SRIOVNETWORK_VF_DEV", PCIAddress: "0000:05:10.2", Driver: "vfio-pci", NUMANode: 1, PhysFn: "0000:05:00.0", PhysFnNetDev: "ens5f0", PhysFnNUMANode: 1, VFIndex: 2},
+				},
+			},
+			expectedAlloc: apiv0.Allocation{
+				Alignment: apiv0.Alignment{
+					SMT:     true,
+					LLC:     true,
+					NUMA:    true,
+					Memory:  true,
+					Devices: boolPtr(false),
+				},
+				Aligned: &apiv0.AlignedInfo{
+					LLC: map[int]apiv0.ContainerResourcesDetails{
+						0: {
+							CPUs: []int{0, 16},
+						},
+					},
+					NUMA: map[int]apiv0.ContainerResourcesDetails{
+						0: {
+							CPUs: []int{0, 16},
+						},
+					},
+					Memory: map[int]apiv0.ContainerResourcesDetails{
+						0: {
+							CPUs:          []int{0, 16},
+							MemoryMiB:     63705,
+							MemoryPercent: 100.0,
+						},
+					},
+				},
+				Unaligned: &apiv0.UnalignedInfo{
+					Devices: apiv0.ContainerResourcesDetails{
+						Devices:   []string{"0000:05:10.2"},
+						NUMANodes: []int{1},
+					},
+				},
+				Devices: []apiv0.DeviceDetails{
+					{
+						PCIAddress: "0000:05:10.2",
+						Driver:     "vfio-pci",
+						NUMANode:   1,
+						PhysFn: &apiv0.PhysFnInfo{
+							Address:  "0000:05:00.0",
+							NetDev:   "ens5f0",
+							NUMANode: 1,
+							VFIndex:  2,
+						},
+					},
+				},
 			},
 		},
 		{
@@ -268,6 +325,198 @@ func TestCheck(t *testing.T) {
 						},
 					},
 				},
+				Devices: []apiv0.DeviceDetails{
+					{PCIAddress: "0000:05:10.2", NUMANode: -1},
+				},
+			},
+		},
+		{
+			name: "device with unknown numa node falls back to local CPUs",
+			res: resources.Resources{
+				CPUs: cpuset.New(0, 16),
+				Devices: []resources.DeviceInfo{
+					{EnvVar: "SRIOVNETWORK_VF_DEV", PCIAddress: "0000:05:10.2", NUMANode: -1, LocalCPUs: mustParseCPUSet(t, "0-31")},
+				},
+			},
+			expectedAlloc: apiv0.Allocation{
+				Alignment: apiv0.Alignment{
+					SMT:     true,
+					LLC:     true,
+					NUMA:    true,
+					Devices: boolPtr(true),
+				},
+				Aligned: &apiv0.AlignedInfo{
+					LLC: map[int]apiv0.ContainerResourcesDetails{
+						0: {
+							CPUs: []int{0, 16},
+						},
+					},
+					NUMA: map[int]apiv0.ContainerResourcesDetails{
+						0: {
+							CPUs:    []int{0, 16},
+							Devices: []string{"0000:05:10.2"},
+						},
+					},
+				},
+				Devices: []apiv0.DeviceDetails{
+					{PCIAddress: "0000:05:10.2", NUMANode: 0},
+				},
+			},
+		},
+		{
+			name: "device local to the pcie root",
+			res: resources.Resources{
+				CPUs: cpuset.New(0, 16),
+				Devices: []resources.DeviceInfo{
+					{EnvVar: "SRIOVNETWORK_VF_DEV", PCIAddress: "0000:05:10.2", NUMANode: 0, PCIERoot: "pci0000:00", LocalCPUs: mustParseCPUSet(t, "0-7,16-23")},
+				},
+			},
+			expectedAlloc: apiv0.Allocation{
+				Alignment: apiv0.Alignment{
+					SMT:      true,
+					LLC:      true,
+					NUMA:     true,
+					Devices:  boolPtr(true),
+					PCIERoot: boolPtr(true),
+				},
+				Aligned: &apiv0.AlignedInfo{
+					LLC: map[int]apiv0.ContainerResourcesDetails{
+						0: {
+							CPUs: []int{0, 16},
+						},
+					},
+					NUMA: map[int]apiv0.ContainerResourcesDetails{
+						0: {
+							CPUs:    []int{0, 16},
+							Devices: []string{"0000:05:10.2"},
+						},
+					},
+					PCIERoot: map[string]apiv0.ContainerResourcesDetails{
+						"pci0000:00": {
+							CPUs:    []int{0, 16},
+							Devices: []string{"0000:05:10.2"},
+						},
+					},
+				},
+				Devices: []apiv0.DeviceDetails{
+					{PCIAddress: "0000:05:10.2", NUMANode: 0, PCIERoot: "pci0000:00"},
+				},
+			},
+		},
+		{
+			name: "device on the same numa node but not local to the pcie root",
+			res: resources.Resources{
+				CPUs: cpuset.New(0, 16),
+				Devices: []resources.DeviceInfo{
+					{EnvVar: "SRIOVNETWORK_VF_DEV", PCIAddress: "0000:05:10.2", NUMANode: 0, PCIERoot: "pci0000:80", LocalCPUs: mustParseCPUSet(t, "8-15,24-31")},
+				},
+			},
+			expectedAlloc: apiv0.Allocation{
+				Alignment: apiv0.Alignment{
+					SMT:      true,
+					LLC:      true,
+					NUMA:     true,
+					Devices:  boolPtr(true),
+					PCIERoot: boolPtr(false),
+				},
+				Aligned: &apiv0.AlignedInfo{
+					LLC: map[int]apiv0.ContainerResourcesDetails{
+						0: {
+							CPUs: []int{0, 16},
+						},
+					},
+					NUMA: map[int]apiv0.ContainerResourcesDetails{
+						0: {
+							CPUs:    []int{0, 16},
+							Devices: []string{"0000:05:10.2"},
+						},
+					},
+				},
+				Unaligned: &apiv0.UnalignedInfo{
+					PCIERoot: apiv0.ContainerResourcesDetails{
+						CPUs:    []int{0, 16},
+						Devices: []string{"0000:05:10.2"},
+					},
+				},
+				Devices: []apiv0.DeviceDetails{
+					{PCIAddress: "0000:05:10.2", NUMANode: 0, PCIERoot: "pci0000:80"},
+				},
+			},
+		},
+		{
+			name: "storage aligned with cpu numa node",
+			res: resources.Resources{
+				CPUs: cpuset.New(0, 16),
+				Storage: []resources.DeviceInfo{
+					{BlockDevs: []string{"nvme0n1p1"}, MountPoints: []string{"/var/lib/data"}, PCIAddress: "0000:04:00.0", Driver: "nvme", NUMANode: 0},
+				},
+			},
+			expectedAlloc: apiv0.Allocation{
+				Alignment: apiv0.Alignment{
+					SMT:     true,
+					LLC:     true,
+					NUMA:    true,
+					Storage: boolPtr(true),
+				},
+				Aligned: &apiv0.AlignedInfo{
+					LLC: map[int]apiv0.ContainerResourcesDetails{
+						0: {
+							CPUs: []int{0, 16},
+						},
+					},
+					NUMA: map[int]apiv0.ContainerResourcesDetails{
+						0: {
+							CPUs: []int{0, 16},
+						},
+					},
+				},
+				Devices: []apiv0.DeviceDetails{
+					{PCIAddress: "0000:04:00.0", Driver: "nvme", NUMANode: 0, BlockDevs: []string{"nvme0n1p1"}, MountPoints: []string{"/var/lib/data"}},
+				},
+			},
+		},
+		{
+			name: "storage on wrong numa node",
+			res: resources.Resources{
+				CPUs: cpuset.New(0, 16),
+				Devices: []resources.DeviceInfo{
+					{EnvVar: "SRIOVNETWORK_VF_DEV", PCIAddress: "0000:05:10.2", NUMANode: 0},
+				},
+				Storage: []resources.DeviceInfo{
+					{BlockDevs: []string{"nvme1n1"}, MountPoints: []string{"/var/lib/data", "/var/log"}, PCIAddress: "0000:85:00.0", Driver: "nvme", NUMANode: 1},
+				},
+			},
+			expectedAlloc: apiv0.Allocation{
+				Alignment: apiv0.Alignment{
+					SMT:     true,
+					LLC:     true,
+					NUMA:    true,
+					Devices: boolPtr(true),
+					Storage: boolPtr(false),
+				},
+				Aligned: &apiv0.AlignedInfo{
+					LLC: map[int]apiv0.ContainerResourcesDetails{
+						0: {
+							CPUs: []int{0, 16},
+						},
+					},
+					NUMA: map[int]apiv0.ContainerResourcesDetails{
+						0: {
+							CPUs:    []int{0, 16},
+							Devices: []string{"0000:05:10.2"},
+						},
+					},
+				},
+				Unaligned: &apiv0.UnalignedInfo{
+					Storage: apiv0.ContainerResourcesDetails{
+						Devices:   []string{"0000:85:00.0"},
+						NUMANodes: []int{1},
+					},
+				},
+				Devices: []apiv0.DeviceDetails{
+					{PCIAddress: "0000:05:10.2", NUMANode: 0},
+					{PCIAddress: "0000:85:00.0", Driver: "nvme", NUMANode: 1, BlockDevs: []string{"nvme1n1"}, MountPoints: []string{"/var/lib/data", "/var/log"}},
+				},
 			},
 		},
 		{
@@ -316,8 +565,416 @@ func TestCheck(t *testing.T) {
 	}
 }
 
+func TestCheckIsolation(t *testing.T) {
+	info := loadMachine(t)
+	info.Isolation = &isolation.Info{
+		Online:   mustParseCPUSet(t, "0-31"),
+		Isolated: mustParseCPUSet(t, "2-15,18-31"),
+		NoHZFull: mustParseCPUSet(t, "4-15,20-31"),
+		RCUNoCBs: mustParseCPUSet(t, "4-15,20-31"),
+	}
+	env := environ.New()
+
+	testCases := []struct {
+		name     string
+		cpus     cpuset.CPUSet
+		expected *apiv0.IsolationInfo
+	}{
+		{
+			name: "all isolated and tickless",
+			cpus: cpuset.New(4, 20),
+			expected: &apiv0.IsolationInfo{
+				Isolated: []int{4, 20},
+				NoHZFull: []int{4, 20},
+				RCUNoCBs: []int{4, 20},
+			},
+		},
+		{
+			name: "isolated but ticking",
+			cpus: cpuset.New(2, 18),
+			expected: &apiv0.IsolationInfo{
+				Isolated: []int{2, 18},
+			},
+		},
+		{
+			name: "all housekeeping",
+			cpus: cpuset.New(0, 16),
+			expected: &apiv0.IsolationInfo{
+				Housekeeping: []int{0, 16},
+			},
+		},
+		{
+			name: "isolated mixed with housekeeping",
+			cpus: cpuset.New(0, 4, 16, 20),
+			expected: &apiv0.IsolationInfo{
+				Isolated:     []int{4, 20},
+				NoHZFull:     []int{4, 20},
+				RCUNoCBs:     []int{4, 20},
+				Housekeeping: []int{0, 16},
+				Mixed:        true,
+			},
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Check(env, resources.Resources{CPUs: tt.cpus}, info)
+			if err != nil {
+				t.Fatalf("got error %v but expected success", err)
+			}
+			gotJSON := toJSON(got.Isolation)
+			expJSON := toJSON(tt.expected)
+			if gotJSON != expJSON {
+				t.Fatalf("got=%v expected=%v", gotJSON, expJSON)
+			}
+		})
+	}
+}
+
+func TestCheckCoreType(t *testing.T) {
+	info := loadMachine(t)
+	// pretend the first 8 cores are performance cores
+	info.CoreTypes = &coretype.Info{Classes: map[string]cpuset.CPUSet{
+		coretype.Performance: mustParseCPUSet(t, "0-7,16-23"),
+		coretype.Efficiency:  mustParseCPUSet(t, "8-15,24-31"),
+	}}
+	env := environ.New()
+
+	testCases := []struct {
+		name              string
+		cpus              cpuset.CPUSet
+		expectedAligned   bool
+		expectedClasses   map[string]apiv0.ContainerResourcesDetails
+		expectedUnaligned []int
+	}{
+		{
+			name:            "performance cores only",
+			cpus:            cpuset.New(0, 1, 16, 17),
+			expectedAligned: true,
+			expectedClasses: map[string]apiv0.ContainerResourcesDetails{
+				coretype.Performance: {CPUs: []int{0, 1, 16, 17}},
+			},
+		},
+		{
+			name:            "performance and efficiency cores",
+			cpus:            cpuset.New(6, 7, 8, 22, 23, 24),
+			expectedAligned: false,
+			expectedClasses: map[string]apiv0.ContainerResourcesDetails{
+				coretype.Performance: {CPUs: []int{6, 7, 22, 23}},
+				coretype.Efficiency:  {CPUs: []int{8, 24}},
+			},
+			expectedUnaligned: []int{8, 24},
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Check(env, resources.Resources{CPUs: tt.cpus}, info)
+			if err != nil {
+				t.Fatalf("got error %v but expected success", err)
+			}
+			if got.Alignment.CoreType == nil || *got.Alignment.CoreType != tt.expectedAligned {
+				t.Fatalf("core type alignment: got=%v expected=%v", got.Alignment.CoreType, tt.expectedAligned)
+			}
+			if gotJSON, expJSON := toJSON(got.Aligned.CoreType), toJSON(tt.expectedClasses); gotJSON != expJSON {
+				t.Fatalf("aligned core types: got=%v expected=%v", gotJSON, expJSON)
+			}
+			var gotUnaligned []int
+			if got.Unaligned != nil {
+				gotUnaligned = got.Unaligned.CoreType.CPUs
+			}
+			if gotJSON, expJSON := toJSON(gotUnaligned), toJSON(tt.expectedUnaligned); gotJSON != expJSON {
+				t.Fatalf("unaligned core types: got=%v expected=%v", gotJSON, expJSON)
+			}
+		})
+	}
+}
+
+func TestCheckResctrl(t *testing.T) {
+	info := loadMachine(t)
+	env := environ.New()
+
+	groups := []resctrl.Group{
+		{
+			Name:     resctrl.DefaultGroup,
+			CPUs:     mustParseCPUSet(t, "0-15,17-31"),
+			Schemata: map[string]map[int]string{"L3": {0: "ffff", 1: "ffff"}, "MB": {0: "100", 1: "100"}},
+		},
+		{
+			Name:     "isolated",
+			CPUs:     cpuset.New(16),
+			Schemata: map[string]map[int]string{"L3": {0: "f0", 1: "ffff"}, "MB": {0: "50", 1: "100"}},
+		},
+		{
+			Name:     "latency",
+			Mode:     "exclusive",
+			CPUs:     cpuset.New(),
+			Schemata: map[string]map[int]string{"L3": {0: "f", 1: "ffff"}, "MB": {0: "50", 1: "100"}},
+		},
+	}
+	l3Domains := map[int]cpuset.CPUSet{
+		0: mustParseCPUSet(t, "0-7,16-23"),
+		1: mustParseCPUSet(t, "8-15,24-31"),
+	}
+
+	testCases := []struct {
+		name     string
+		cpus     cpuset.CPUSet
+		tasks    map[string][]int
+		expected *apiv0.ResctrlInfo
+	}{
+		{
+			name:  "confined to a class of service",
+			cpus:  cpuset.New(0, 16),
+			tasks: map[string][]int{"latency": {10, 11}},
+			expected: &apiv0.ResctrlInfo{
+				Groups: []apiv0.ResctrlGroupInfo{
+					{
+						Group: "latency",
+						Mode:  "exclusive",
+						Tasks: []int{10, 11},
+						Domains: []apiv0.ResctrlDomainInfo{
+							{Domain: 0, CPUs: []int{0, 16}, CBM: "f", CacheWays: 4, TotalCacheWays: 16, MemoryBandwidth: 50},
+						},
+					},
+				},
+				Confined: true,
+			},
+		},
+		{
+			name:  "confined through the CPUs",
+			cpus:  cpuset.New(16),
+			tasks: map[string][]int{resctrl.DefaultGroup: {1, 2}},
+			expected: &apiv0.ResctrlInfo{
+				Groups: []apiv0.ResctrlGroupInfo{
+					{
+						Group:   "isolated",
+						Tasks:   []int{1, 2},
+						ViaCPUs: true,
+						Domains: []apiv0.ResctrlDomainInfo{
+							{Domain: 0, CPUs: []int{16}, CBM: "f0", CacheWays: 4, TotalCacheWays: 16, MemoryBandwidth: 50},
+						},
+					},
+				},
+				Confined: true,
+			},
+		},
+		{
+			name:  "tasks split across groups",
+			cpus:  cpuset.New(0, 16),
+			tasks: map[string][]int{resctrl.DefaultGroup: {1}, "latency": {10}},
+			expected: &apiv0.ResctrlInfo{
+				Groups: []apiv0.ResctrlGroupInfo{
+					{
+						Group: resctrl.DefaultGroup,
+						Tasks: []int{1},
+						Domains: []apiv0.ResctrlDomainInfo{
+							{Domain: 0, CPUs: []int{0}, CBM: "ffff", CacheWays: 16, TotalCacheWays: 16, MemoryBandwidth: 100},
+						},
+					},
+					{
+						Group:   "isolated",
+						Tasks:   []int{1},
+						ViaCPUs: true,
+						Domains: []apiv0.ResctrlDomainInfo{
+							{Domain: 0, CPUs: []int{16}, CBM: "f0", CacheWays: 4, TotalCacheWays: 16, MemoryBandwidth: 50},
+						},
+					},
+					{
+						Group: "latency",
+						Mode:  "exclusive",
+						Tasks: []int{10},
+						Domains: []apiv0.ResctrlDomainInfo{
+							{Domain: 0, CPUs: []int{0, 16}, CBM: "f", CacheWays: 4, TotalCacheWays: 16, MemoryBandwidth: 50},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			res := resources.Resources{
+				CPUs: tt.cpus,
+				Resctrl: &resctrl.Info{
+					Groups:    groups,
+					L3CBMMask: 0xffff,
+					L3Domains: l3Domains,
+					Tasks:     tt.tasks,
+				},
+			}
+			got, err := Check(env, res, info)
+			if err != nil {
+				t.Fatalf("got error %v but expected success", err)
+			}
+			gotJSON := toJSON(got.Resctrl)
+			expJSON := toJSON(tt.expected)
+			if gotJSON != expJSON {
+				t.Fatalf("got=%v expected=%v", gotJSON, expJSON)
+			}
+		})
+	}
+}
+
+func TestCheckLimits(t *testing.T) {
+	info := loadMachine(t)
+	env := environ.New()
+
+	testCases := []struct {
+		name     string
+		limits   *cgroups.Limits
+		expected *apiv0.LimitsInfo
+	}{
+		{
+			name: "unknown limits",
+		},
+		{
+			name:   "exclusive",
+			limits: &cgroups.Limits{CPUQuota: 400000, CPUPeriod: 100000, CPUWeight: 157, MemoryMax: 1073741824},
+			expected: &apiv0.LimitsInfo{
+				CPUs:           4,
+				CPUQuotaMillis: 4000,
+				CPUPeriodUS:    100000,
+				CPUWeight:      157,
+				MemoryMaxBytes: 1073741824,
+				Exclusive:      true,
+			},
+		},
+		{
+			name:   "quota shrunk below the cpuset",
+			limits: &cgroups.Limits{CPUQuota: 250000, CPUPeriod: 100000, CPUWeight: 98, MemoryMax: cgroups.Unlimited},
+			expected: &apiv0.LimitsInfo{
+				CPUs:           4,
+				CPUQuotaMillis: 2500,
+				CPUPeriodUS:    100000,
+				CPUWeight:      98,
+				MemoryMaxBytes: cgroups.Unlimited,
+				Throttled:      true,
+			},
+		},
+		{
+			name:   "unlimited",
+			limits: &cgroups.Limits{CPUQuota: cgroups.Unlimited, CPUPeriod: 100000, CPUWeight: 100},
+			expected: &apiv0.LimitsInfo{
+				CPUs:           4,
+				CPUQuotaMillis: cgroups.Unlimited,
+				CPUPeriodUS:    100000,
+				CPUWeight:      100,
+			},
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			res := resources.Resources{
+				CPUs:   cpuset.New(0, 1, 16, 17),
+				Limits: tt.limits,
+			}
+			got, err := Check(env, res, info)
+			if err != nil {
+				t.Fatalf("got error %v but expected success", err)
+			}
+			gotJSON := toJSON(got.Limits)
+			expJSON := toJSON(tt.expected)
+			if gotJSON != expJSON {
+				t.Fatalf("got=%v expected=%v", gotJSON, expJSON)
+			}
+		})
+	}
+}
+
+func TestCheckErrors(t *testing.T) {
+	info := loadMachine(t)
+	info.IsolationErr = errors.New("missing /proc/cmdline")
+	info.CoreTypesErr = errors.New("invalid cpu_core cpus")
+	env := environ.New()
+
+	res := resources.Resources{
+		CPUs: cpuset.New(0, 16),
+		Devices: []resources.DeviceInfo{
+			{EnvVar: "PCIDEVICE_NVIDIA_COM_GPU", PCIAddress: "0000:1b:00.0", NUMANode: 0, PCIERoot: "pci0000:17", LocalCPUs: mustParseCPUSet(t, "0-31")},
+			{EnvVar: "PCIDEVICE_NVIDIA_COM_GPU", PCIAddress: "0000:99:00.0", NUMANode: -1, VFIndex: -1, NotFound: true},
+		},
+		LimitsErr: errors.New("missing cpu.max"),
+	}
+	got, err := Check(env, res, info)
+	if err != nil {
+		t.Fatalf("got error %v but expected success", err)
+	}
+	expected := map[string]string{
+		apiv0.CheckDevices:   "devices not found: 0000:99:00.0",
+		apiv0.CheckPCIERoot:  "devices not found: 0000:99:00.0",
+		apiv0.CheckIsolation: "cannot detect CPU isolation: missing /proc/cmdline",
+		apiv0.CheckCoreType:  "cannot detect core types: invalid cpu_core cpus",
+		apiv0.CheckLimits:    "cannot read CPU limits: missing cpu.max",
+	}
+	if gotJSON, expJSON := toJSON(got.Errors), toJSON(expected); gotJSON != expJSON {
+		t.Fatalf("got=%v expected=%v", gotJSON, expJSON)
+	}
+	// the devices which could be found are still checked
+	if got.Alignment.Devices == nil || !*got.Alignment.Devices || got.Alignment.PCIERoot == nil || !*got.Alignment.PCIERoot {
+		t.Fatalf("expected the found devices to be aligned, got devices=%v pcieRoot=%v", got.Alignment.Devices, got.Alignment.PCIERoot)
+	}
+}
+
+func TestCheckThreads(t *testing.T) {
+	info := loadMachine(t)
+	env := environ.New()
+
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	win := sched.NewWindow()
+	win.Add(start, []sched.Thread{
+		{PID: 10, TID: 10, Comm: "app", Processor: 2, Affinity: cpuset.New(2, 18), VoluntarySwitches: 4, Migrations: 1},
+		{PID: 10, TID: 11, Comm: "worker", Processor: 2, Affinity: cpuset.New(2, 18), Migrations: -1},
+		{PID: 10, TID: 12, Comm: "worker", Processor: 2, Affinity: cpuset.New(2, 18), Migrations: -1},
+	})
+	// the cpuset changed under the running container: the workers escaped
+	win.Add(start.Add(500*time.Millisecond), []sched.Thread{
+		{PID: 10, TID: 10, Comm: "app", Processor: 18, Affinity: cpuset.New(2, 18), VoluntarySwitches: 9, InvoluntarySwitches: 1, Migrations: 2},
+		{PID: 10, TID: 11, Comm: "worker", Processor: 3, Affinity: cpuset.New(2, 3), Migrations: -1},
+		{PID: 10, TID: 12, Comm: "worker", Processor: 9, Affinity: cpuset.New(2, 9), Migrations: -1},
+	})
+
+	got := CheckThreads(env, cpuset.New(2, 18), win, info)
+	expected := apiv0.SchedInfo{
+		CPUs:     []int{2, 18},
+		WindowMS: 500,
+		Samples:  2,
+		Threads: []apiv0.ThreadSchedInfo{
+			{
+				PID: 10, TID: 10, Comm: "app", Affinity: []int{2, 18}, CPUs: []int{2, 18},
+				Cores: []int{2}, LLCs: []int{0}, NUMANodes: []int{0},
+				Migrations: 1, VoluntarySwitches: 5, InvoluntarySwitches: 1,
+			},
+			{
+				PID: 10, TID: 11, Comm: "worker", Affinity: []int{2, 3}, CPUs: []int{2, 3},
+				Cores: []int{2, 3}, LLCs: []int{0}, NUMANodes: []int{0},
+				Migrations: -1,
+				Outside:    []apiv0.SchedLevel{apiv0.SchedLevelCPUSet, apiv0.SchedLevelSMT},
+			},
+			{
+				PID: 10, TID: 12, Comm: "worker", Affinity: []int{2, 9}, CPUs: []int{2, 9},
+				Cores: []int{2, 9}, LLCs: []int{0, 1}, NUMANodes: []int{0},
+				Migrations: -1,
+				Outside:    []apiv0.SchedLevel{apiv0.SchedLevelCPUSet, apiv0.SchedLevelSMT, apiv0.SchedLevelLLC},
+			},
+		},
+	}
+	gotJSON := toJSON(got)
+	expJSON := toJSON(expected)
+	if gotJSON != expJSON {
+		t.Fatalf("got=%v expected=%v", gotJSON, expJSON)
+	}
+}
+
 func boolPtr(b bool) *bool { return &b }
 
+func mustParseCPUSet(t *testing.T, s string) cpuset.CPUSet {
+	t.Helper()
+	cpus, err := cpuset.Parse(s)
+	if err != nil {
+		t.Fatalf("parsing cpuset %q: %v", s, err)
+	}
+	return cpus
+}
+
 func toJSON(v any) string {
 	data, err := json.Marshal(v)
 	if err != nil {
@@ -326,6 +983,24 @@ func toJSON(v any) string {
 	return string(data)
 }
 
+// loadMachine returns the machine info of the reference machine in hack/machine.json
+func loadMachine(t *testing.T) machine.Machine {
+	t.Helper()
+	root, err := getRootPath()
+	if err != nil {
+		t.Fatalf("cannot find the root: %v", err)
+	}
+	machineData, err := os.ReadFile(filepath.Join(root, "hack", "machine.json"))
+	if err != nil {
+		t.Fatalf("cannot read machine info: %v", err)
+	}
+	info, err := machine.FromJSON(string(machineData))
+	if err != nil {
+		t.Fatalf("cannot decode machine info: %v", err)
+	}
+	return info
+}
+
 func getRootPath() (string, error) {
 	_, file, _, ok := goruntime.Caller(0)
 	if !ok {
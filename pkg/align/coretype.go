@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package align
+
+import (
+	"slices"
+
+	"k8s.io/utils/cpuset"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+// checkCoreType verifies the container CPUs have all the same core class. On hybrid CPUs a container
+// mixing performance and efficiency cores runs at the pace of the slowest core, even if SMT, LLC and NUMA are aligned.
+func checkCoreType(env *environ.Environ, resp *apiv0.Allocation, cpus cpuset.CPUSet, rmap rMap, coreTypesErr error) {
+	if len(rmap.coreTypes) == 0 {
+		env.Log.V(1).Info("homogeneous or unknown core types, skipping core type alignment check")
+		if coreTypesErr != nil {
+			setError(resp, apiv0.CheckCoreType, "cannot detect core types: "+coreTypesErr.Error())
+		}
+		return
+	}
+
+	classes := make([]string, 0, len(rmap.coreTypes))
+	for class := range rmap.coreTypes {
+		classes = append(classes, class)
+	}
+	slices.Sort(classes)
+
+	// the class holding most of the container CPUs is considered the intended one
+	mainSubset := cpuset.New()
+	classCount := 0
+	for _, class := range classes {
+		classSubset := cpus.Intersection(rmap.coreTypes[class])
+		env.Log.V(2).Info("check core type alignment", "class", class, "classCPUs", rmap.coreTypes[class].String(), "containerSubset", classSubset.String())
+		if classSubset.IsEmpty() {
+			continue
+		}
+		classCount++
+		if resp.Aligned == nil {
+			resp.Aligned = apiv0.NewAlignedInfo()
+		}
+		dets := resp.Aligned.CoreType[class]
+		dets.CPUs = classSubset.List()
+		resp.Aligned.CoreType[class] = dets
+
+		if classSubset.Size() > mainSubset.Size() {
+			mainSubset = classSubset
+		}
+	}
+
+	aligned := classCount <= 1
+	env.Log.V(2).Info("check core type alignment result", "aligned", aligned, "classCount", classCount)
+	if !aligned {
+		if resp.Unaligned == nil {
+			resp.Unaligned = &apiv0.UnalignedInfo{}
+		}
+		resp.Unaligned.CoreType.CPUs = cpus.Difference(mainSubset).List()
+	}
+	resp.Alignment.CoreType = &aligned
+}
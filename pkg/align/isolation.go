@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package align
+
+import (
+	"k8s.io/utils/cpuset"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+	"github.com/ffromani/ctrreschk/pkg/isolation"
+)
+
+// checkIsolation reports which container CPUs are isolated from the system activities.
+// Exclusive CPUs are usually expected to be all isolated; a container mixing isolated and
+// housekeeping CPUs commonly means the CPU manager reserved CPUs don't match the kernel isolation settings.
+func checkIsolation(env *environ.Environ, resp *apiv0.Allocation, cpus cpuset.CPUSet, isol *isolation.Info, isolErr error) {
+	if isol == nil {
+		env.Log.V(1).Info("no CPU isolation info available, skipping isolation check")
+		if isolErr != nil {
+			setError(resp, apiv0.CheckIsolation, "cannot detect CPU isolation: "+isolErr.Error())
+		}
+		return
+	}
+	isolated := cpus.Intersection(isol.Isolated)
+	nohzFull := cpus.Intersection(isol.NoHZFull)
+	housekeeping := cpus.Intersection(isol.Housekeeping())
+
+	env.Log.V(2).Info("check isolation", "cpus", cpus.String(), "isolated", isolated.String(), "nohzFull", nohzFull.String(), "housekeeping", housekeeping.String())
+
+	resp.Isolation = &apiv0.IsolationInfo{
+		Isolated:     isolated.List(),
+		NoHZFull:     nohzFull.List(),
+		RCUNoCBs:     cpus.Intersection(isol.RCUNoCBs).List(),
+		Housekeeping: housekeeping.List(),
+		Mixed:        !housekeeping.IsEmpty() && !isolated.Union(nohzFull).IsEmpty(),
+	}
+}
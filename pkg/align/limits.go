@@ -0,0 +1,34 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package align
+
+import (
+	"k8s.io/utils/cpuset"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	"github.com/ffromani/ctrreschk/pkg/cgroups"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+// checkLimits compares the CPU quota with the cpuset. In-place pod resize can change either of them
+// under a running container; a quota smaller than the cpuset silently throttles a pinned workload.
+func checkLimits(env *environ.Environ, resp *apiv0.Allocation, cpus cpuset.CPUSet, limits *cgroups.Limits, limitsErr error) {
+	if limits == nil {
+		env.Log.V(1).Info("no CPU limits available, skipping limits check")
+		if limitsErr != nil {
+			setError(resp, apiv0.CheckLimits, "cannot read CPU limits: "+limitsErr.Error())
+		}
+		return
+	}
+	res := apiv0.LimitsInfo{
+		CPUs:           cpus.Size(),
+		CPUQuotaMillis: limits.CPUQuotaMillis(),
+		CPUPeriodUS:    limits.CPUPeriod,
+		CPUWeight:      limits.CPUWeight,
+		MemoryMaxBytes: limits.MemoryMax,
+		Exclusive:      limits.Exclusive(cpus.Size()),
+		Throttled:      limits.Throttled(cpus.Size()),
+	}
+	env.Log.V(2).Info("check limits", "cpus", cpus.String(), "cpuQuotaMillis", res.CPUQuotaMillis, "exclusive", res.Exclusive, "throttled", res.Throttled)
+	resp.Limits = &res
+}
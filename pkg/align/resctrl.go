@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package align
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/utils/cpuset"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+	"github.com/ffromani/ctrreschk/pkg/resctrl"
+)
+
+// resctrlAssignment is a set of container tasks using a resctrl group on a set of container CPUs.
+type resctrlAssignment struct {
+	group   string
+	tasks   []int
+	cpus    cpuset.CPUSet
+	viaCPUs bool
+}
+
+// checkResctrl reports the resctrl groups of the container threads and what each group gets
+// on the LLC domains of the container CPUs. LLC alignment says which caches the container shares,
+// this says how much of them the container can actually use.
+func checkResctrl(env *environ.Environ, resp *apiv0.Allocation, cpus cpuset.CPUSet, info *resctrl.Info) {
+	if info == nil {
+		env.Log.V(1).Info("no resctrl info available, skipping resctrl check")
+		return
+	}
+
+	assignments := resctrlAssignments(info, cpus)
+	res := apiv0.ResctrlInfo{
+		Confined: len(assignments) > 0,
+	}
+	for _, asg := range assignments {
+		if asg.group == resctrl.DefaultGroup || asg.group != assignments[0].group {
+			res.Confined = false
+		}
+		gr, ok := info.Group(asg.group)
+		if !ok {
+			continue
+		}
+		grInfo := apiv0.ResctrlGroupInfo{
+			Group:   gr.Name,
+			Mode:    gr.Mode,
+			Tasks:   asg.tasks,
+			ViaCPUs: asg.viaCPUs,
+		}
+		for _, domain := range resctrlDomains(gr) {
+			domainCPUs := asg.cpus.Intersection(info.L3Domains[domain])
+			if len(info.L3Domains) > 0 && domainCPUs.IsEmpty() {
+				// the tasks don't run on this domain
+				continue
+			}
+			domInfo := apiv0.ResctrlDomainInfo{
+				Domain: domain,
+				CPUs:   domainCPUs.List(),
+			}
+			if cbm, ok := gr.L3CBM(domain); ok {
+				domInfo.CBM = strconv.FormatUint(cbm, 16)
+				domInfo.CacheWays = resctrl.CacheWays(cbm)
+				domInfo.TotalCacheWays = resctrl.CacheWays(info.L3CBMMask)
+			}
+			if mb, ok := gr.MBPercent(domain); ok {
+				domInfo.MemoryBandwidth = mb
+			}
+			grInfo.Domains = append(grInfo.Domains, domInfo)
+		}
+		env.Log.V(2).Info("check resctrl", "group", gr.Name, "mode", gr.Mode, "tasks", len(grInfo.Tasks), "viaCPUs", grInfo.ViaCPUs, "domains", len(grInfo.Domains))
+		res.Groups = append(res.Groups, grInfo)
+	}
+	resp.Resctrl = &res
+}
+
+// resctrlAssignments returns the groups the container tasks use, sorted by group name.
+// The tasks explicitly assigned to a group use it on all the container CPUs; the tasks of the default group
+// use the group each container CPU is assigned to through cpus_list, which is the default group if none.
+func resctrlAssignments(info *resctrl.Info, cpus cpuset.CPUSet) []resctrlAssignment {
+	var assignments []resctrlAssignment
+	for name, tasks := range info.Tasks {
+		if name != resctrl.DefaultGroup {
+			assignments = append(assignments, resctrlAssignment{group: name, tasks: tasks, cpus: cpus})
+			continue
+		}
+		cpusByGroup := make(map[string][]int)
+		for _, cpu := range cpus.List() {
+			cpuGroup := info.CPUGroup(cpu)
+			cpusByGroup[cpuGroup] = append(cpusByGroup[cpuGroup], cpu)
+		}
+		for cpuGroup, cpuIDs := range cpusByGroup {
+			assignments = append(assignments, resctrlAssignment{
+				group:   cpuGroup,
+				tasks:   tasks,
+				cpus:    cpuset.New(cpuIDs...),
+				viaCPUs: cpuGroup != resctrl.DefaultGroup,
+			})
+		}
+	}
+	slices.SortFunc(assignments, func(a, b resctrlAssignment) int {
+		if a.group != b.group {
+			return strings.Compare(a.group, b.group)
+		}
+		// explicit assignments first
+		if a.viaCPUs == b.viaCPUs {
+			return 0
+		}
+		if a.viaCPUs {
+			return 1
+		}
+		return -1
+	})
+	return assignments
+}
+
+// resctrlDomains returns the sorted IDs of the domains the group has L3 or MB allocations on.
+func resctrlDomains(gr resctrl.Group) []int {
+	var domains []int
+	for _, resource := range []string{resctrl.ResourceL3, resctrl.ResourceL3Data, resctrl.ResourceMB} {
+		for domain := range gr.Schemata[resource] {
+			if !slices.Contains(domains, domain) {
+				domains = append(domains, domain)
+			}
+		}
+	}
+	slices.Sort(domains)
+	return domains
+}
@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package align
+
+import (
+	"slices"
+
+	"k8s.io/utils/cpuset"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+	"github.com/ffromani/ctrreschk/pkg/resources"
+)
+
+// checkStorage verifies the storage controllers backing the container mounts are on the NUMA nodes of the container CPUs.
+// This is the same check of checkDevices, done separately because the storage locality is usually configured
+// separately (e.g. local volumes) from the other devices. The controllers are reported along with the devices.
+func checkStorage(env *environ.Environ, resp *apiv0.Allocation, cpus cpuset.CPUSet, storage []resources.DeviceInfo, rmap rMap) {
+	cpuNUMANodes := rmap.numaNodesForCPUs(cpus)
+	checked := 0
+	aligned := true
+	for _, dev := range storage {
+		devNUMANode := deviceNUMANode(dev, rmap)
+		if !slices.ContainsFunc(resp.Devices, func(dets apiv0.DeviceDetails) bool { return dets.PCIAddress == dev.PCIAddress }) {
+			resp.Devices = append(resp.Devices, deviceDetails(dev, devNUMANode))
+		}
+		if devNUMANode == -1 {
+			env.Log.V(2).Info("storage controller NUMA node unknown, skipping", "pciAddress", dev.PCIAddress, "blockdevs", dev.BlockDevs)
+			continue
+		}
+		checked++
+
+		env.Log.V(2).Info("check storage alignment", "pciAddress", dev.PCIAddress, "blockdevs", dev.BlockDevs, "deviceNUMA", devNUMANode, "cpuNUMANodes", cpuNUMANodes.String())
+
+		if cpuNUMANodes.Contains(devNUMANode) {
+			continue
+		}
+		aligned = false
+		if resp.Unaligned == nil {
+			resp.Unaligned = &apiv0.UnalignedInfo{}
+		}
+		resp.Unaligned.Storage.Devices = append(resp.Unaligned.Storage.Devices, dev.PCIAddress)
+		if !slices.Contains(resp.Unaligned.Storage.NUMANodes, devNUMANode) {
+			resp.Unaligned.Storage.NUMANodes = append(resp.Unaligned.Storage.NUMANodes, devNUMANode)
+		}
+	}
+	if checked == 0 {
+		env.Log.V(1).Info("no storage controllers to check, skipping storage alignment check")
+		return
+	}
+	resp.Alignment.Storage = &aligned
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package align
+
+import (
+	"slices"
+
+	"k8s.io/utils/cpuset"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+	"github.com/ffromani/ctrreschk/pkg/machine"
+	"github.com/ffromani/ctrreschk/pkg/sched"
+)
+
+// CheckThreads reports where the sampled threads ran. The container cpuset promises its CPUs
+// and, by extension, the physical cores, LLCs and NUMA nodes including them. Threads observed
+// outside of them, e.g. after the cpuset changed under a running container, are reported at
+// each level they escaped.
+func CheckThreads(env *environ.Environ, cpus cpuset.CPUSet, win *sched.Window, machine machine.Machine) apiv0.SchedInfo {
+	rmap := makeRMap(env, machine.Topology, machine.CoreTypes)
+
+	levels := []apiv0.SchedLevel{apiv0.SchedLevelCPUSet, apiv0.SchedLevelSMT, apiv0.SchedLevelLLC, apiv0.SchedLevelNUMA}
+	promised := map[apiv0.SchedLevel]cpuset.CPUSet{
+		apiv0.SchedLevelCPUSet: cpus,
+		apiv0.SchedLevelSMT:    groupsCPUs(rmap.cpuPhy2Log, cpus),
+		apiv0.SchedLevelLLC:    groupsCPUs(rmap.llc, cpus),
+		apiv0.SchedLevelNUMA:   groupsCPUs(rmap.numa, cpus),
+	}
+	env.Log.V(2).Info("check threads", "cpus", cpus.String(), "smt", promised[apiv0.SchedLevelSMT].String(), "llc", promised[apiv0.SchedLevelLLC].String(), "numa", promised[apiv0.SchedLevelNUMA].String())
+
+	info := apiv0.SchedInfo{
+		CPUs:      cpus.List(),
+		WindowMS:  win.End.Sub(win.Start).Milliseconds(),
+		Samples:   win.Samples,
+		Contained: true,
+	}
+	for _, tid := range win.TIDs() {
+		tw := win.Threads[tid]
+		th := tw.Delta()
+		thInfo := apiv0.ThreadSchedInfo{
+			PID:                 th.PID,
+			TID:                 th.TID,
+			Comm:                th.Comm,
+			Affinity:            th.Affinity.List(),
+			CPUs:                tw.CPUs.List(),
+			Cores:               groupIDs(rmap.cpuPhy2Log, tw.CPUs),
+			LLCs:                groupIDs(rmap.llc, tw.CPUs),
+			NUMANodes:           groupIDs(rmap.numa, tw.CPUs),
+			Migrations:          th.Migrations,
+			VoluntarySwitches:   th.VoluntarySwitches,
+			InvoluntarySwitches: th.InvoluntarySwitches,
+			RunTimeNS:           th.RunTimeNS,
+			WaitTimeNS:          th.WaitTimeNS,
+		}
+		for _, level := range levels {
+			if !tw.CPUs.IsSubsetOf(promised[level]) {
+				thInfo.Outside = append(thInfo.Outside, level)
+			}
+		}
+		if len(thInfo.Outside) > 0 {
+			env.Log.V(1).Info("thread ran outside the container allocation", "pid", th.PID, "tid", th.TID, "cpus", tw.CPUs.String(), "levels", thInfo.Outside)
+			info.Contained = false
+		}
+		info.Threads = append(info.Threads, thInfo)
+	}
+	return info
+}
+
+// groupIDs returns the sorted IDs of the groups including at least one of the cpus.
+func groupIDs(rm ridMap, cpus cpuset.CPUSet) []int {
+	var ids []int
+	for id := range rm {
+		if !cpus.Intersection(rm.CPUSet(id)).IsEmpty() {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// groupsCPUs returns all the CPUs of the groups including at least one of the cpus.
+func groupsCPUs(rm ridMap, cpus cpuset.CPUSet) cpuset.CPUSet {
+	res := cpuset.New()
+	for _, id := range groupIDs(rm, cpus) {
+		res = res.Union(rm.CPUSet(id))
+	}
+	return res
+}
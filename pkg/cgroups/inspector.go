@@ -19,6 +19,7 @@ package cgroups
 import (
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"k8s.io/utils/cpuset"
@@ -30,6 +31,13 @@ const (
 	CgroupPath = "fs/cgroup"
 	CpusetFile = "cpuset.cpus.effective"
 	MemsetFile = "cpuset.mems.effective"
+	// CpusetCPUsFile and CpusetMemsFile are the cpuset the container runtime writes, while the effective files
+	// are updated by the kernel, which emits no inotify events for them
+	CpusetCPUsFile = "cpuset.cpus"
+	CpusetMemsFile = "cpuset.mems"
+	ProcsFile      = "cgroup.procs"
+	// ThreadsFile lists all the threads in the cgroup, while ProcsFile lists only the thread group leaders
+	ThreadsFile = "cgroup.threads"
 )
 
 func CpusetPath(env *environ.Environ) string {
@@ -40,6 +48,22 @@ func MemsetPath(env *environ.Environ) string {
 	return filepath.Join(env.Root.Sys, CgroupPath, MemsetFile)
 }
 
+func CpusetCPUsPath(env *environ.Environ) string {
+	return filepath.Join(env.Root.Sys, CgroupPath, CpusetCPUsFile)
+}
+
+func CpusetMemsPath(env *environ.Environ) string {
+	return filepath.Join(env.Root.Sys, CgroupPath, CpusetMemsFile)
+}
+
+func ProcsPath(env *environ.Environ) string {
+	return filepath.Join(env.Root.Sys, CgroupPath, ProcsFile)
+}
+
+func ThreadsPath(env *environ.Environ) string {
+	return filepath.Join(env.Root.Sys, CgroupPath, ThreadsFile)
+}
+
 func Cpuset(env *environ.Environ) (cpuset.CPUSet, error) {
 	cpusetPath := CpusetPath(env)
 	env.Log.V(2).Info("reading cpuset", "path", cpusetPath)
@@ -75,3 +99,33 @@ func Memset(env *environ.Environ) (cpuset.CPUSet, error) {
 	env.Log.V(2).Info("parsed memset", "path", memsetPath, "mems", mems.String())
 	return mems, nil
 }
+
+// Procs reads the PIDs of the processes in the container cgroup.
+func Procs(env *environ.Environ) ([]int, error) {
+	return readIDs(env, ProcsPath(env), "procs")
+}
+
+// Threads reads the TIDs of all the threads in the container cgroup.
+func Threads(env *environ.Environ) ([]int, error) {
+	return readIDs(env, ThreadsPath(env), "threads")
+}
+
+func readIDs(env *environ.Environ, path, kind string) ([]int, error) {
+	env.Log.V(2).Info("reading "+kind, "path", path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		env.Log.V(1).Info("failed to read "+kind, "path", path, "error", err)
+		return nil, err
+	}
+	var ids []int
+	for _, line := range strings.Fields(string(data)) {
+		id, err := strconv.Atoi(line)
+		if err != nil {
+			env.Log.V(1).Info("failed to parse "+kind, "path", path, "error", err)
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	env.Log.V(2).Info("parsed "+kind, "path", path, "count", len(ids))
+	return ids, nil
+}
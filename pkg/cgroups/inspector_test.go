@@ -19,6 +19,7 @@ package cgroups
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"k8s.io/utils/cpuset"
@@ -158,3 +159,94 @@ func TestMemset(t *testing.T) {
 		})
 	}
 }
+
+func TestProcs(t *testing.T) {
+	testCases := []struct {
+		name         string
+		content      string
+		expectedPIDs []int
+		expectedErr  bool
+	}{
+		{
+			name:         "multiple processes",
+			content:      "1\n42\n43\n",
+			expectedPIDs: []int{1, 42, 43},
+		},
+		{
+			name:    "empty cgroup",
+			content: "",
+		},
+		{
+			name:        "malformed",
+			content:     "1\nfoo\n",
+			expectedErr: true,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			env := environ.Environ{
+				Root: environ.FS{
+					Sys: t.TempDir(),
+				},
+				Log: environ.DefaultLog(),
+			}
+			tmpPath := ProcsPath(&env)
+			err := os.MkdirAll(filepath.Dir(tmpPath), os.ModePerm)
+			if err != nil {
+				t.Fatalf("cannot prepare the fake data path at %v: %v", tmpPath, err)
+			}
+			err = os.WriteFile(tmpPath, []byte(tt.content), 0o644)
+			if err != nil {
+				t.Fatalf("cannot prepare the fake data file at %v: %v", tmpPath, err)
+			}
+
+			got, err := Procs(&env)
+			if tt.expectedErr && err == nil {
+				t.Fatalf("expected error, got success")
+			}
+			if !tt.expectedErr && err != nil {
+				t.Fatalf("expected success, got err=%v", err)
+			}
+			if !slices.Equal(got, tt.expectedPIDs) {
+				t.Fatalf("expected PIDs %v got %v", tt.expectedPIDs, got)
+			}
+		})
+	}
+
+	env := environ.Environ{
+		Root: environ.FS{
+			Sys: "/this/path/does/not/exist",
+		},
+		Log: environ.DefaultLog(),
+	}
+	if _, err := Procs(&env); err == nil {
+		t.Fatalf("expected error, got success")
+	}
+}
+
+func TestThreads(t *testing.T) {
+	env := environ.Environ{
+		Root: environ.FS{
+			Sys: t.TempDir(),
+		},
+		Log: environ.DefaultLog(),
+	}
+	tmpPath := ThreadsPath(&env)
+	err := os.MkdirAll(filepath.Dir(tmpPath), os.ModePerm)
+	if err != nil {
+		t.Fatalf("cannot prepare the fake data path at %v: %v", tmpPath, err)
+	}
+	// the threads of process 42 are listed, unlike in cgroup.procs
+	err = os.WriteFile(tmpPath, []byte("1\n42\n44\n45\n43\n"), 0o644)
+	if err != nil {
+		t.Fatalf("cannot prepare the fake data file at %v: %v", tmpPath, err)
+	}
+
+	got, err := Threads(&env)
+	if err != nil {
+		t.Fatalf("expected success, got err=%v", err)
+	}
+	if expected := []int{1, 42, 44, 45, 43}; !slices.Equal(got, expected) {
+		t.Fatalf("expected TIDs %v got %v", expected, got)
+	}
+}
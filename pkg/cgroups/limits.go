@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cgroups
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+const (
+	CPUMaxFile    = "cpu.max"
+	CPUWeightFile = "cpu.weight"
+	MemoryMaxFile = "memory.max"
+
+	// Unlimited is the value of the limits set to "max"
+	Unlimited = -1
+)
+
+// Limits are the CPU and memory limits of the container cgroup. Unlike the cpuset, which
+// pins the container, they cap how much CPU time and memory it can use. Both can change
+// under a running container, e.g. on in-place pod resize.
+type Limits struct {
+	// CPUQuota is the CPU time in microseconds the container can use every CPUPeriod, Unlimited if not capped
+	CPUQuota  int64
+	CPUPeriod int64
+	// CPUWeight is the relative share of CPU time under contention, 0 if unknown
+	CPUWeight int64
+	// MemoryMax is the memory limit in bytes, Unlimited if not capped, 0 if unknown
+	MemoryMax int64
+}
+
+// CPUQuotaMillis returns the CPU quota in millicores, the same unit of the kubernetes CPU limits.
+func (lim Limits) CPUQuotaMillis() int64 {
+	if lim.CPUQuota == Unlimited || lim.CPUPeriod <= 0 {
+		return Unlimited
+	}
+	return lim.CPUQuota * 1000 / lim.CPUPeriod
+}
+
+// Exclusive returns true if the CPU quota is integral and equal to the given number of CPUs,
+// which is how kubelet sets up the containers of Guaranteed QoS pods with exclusive CPUs.
+// An unlimited quota is not exclusive: BestEffort containers and the ones without CPU limit have it as well.
+func (lim Limits) Exclusive(cpus int) bool {
+	if lim.CPUQuota == Unlimited || lim.CPUPeriod <= 0 {
+		return false
+	}
+	return lim.CPUQuota%lim.CPUPeriod == 0 && lim.CPUQuota/lim.CPUPeriod == int64(cpus)
+}
+
+// Throttled returns true if the CPU quota is smaller than the given number of CPUs,
+// so a workload busy on all of them is throttled.
+func (lim Limits) Throttled(cpus int) bool {
+	if lim.CPUQuota == Unlimited || lim.CPUPeriod <= 0 {
+		return false
+	}
+	return lim.CPUQuota < int64(cpus)*lim.CPUPeriod
+}
+
+func CPUMaxPath(env *environ.Environ) string {
+	return filepath.Join(env.Root.Sys, CgroupPath, CPUMaxFile)
+}
+
+func CPUWeightPath(env *environ.Environ) string {
+	return filepath.Join(env.Root.Sys, CgroupPath, CPUWeightFile)
+}
+
+func MemoryMaxPath(env *environ.Environ) string {
+	return filepath.Join(env.Root.Sys, CgroupPath, MemoryMaxFile)
+}
+
+// ReadLimits reads the limits of the container cgroup. cpu.max is required; cpu.weight
+// and memory.max are optional, because their controllers may not be enabled.
+func ReadLimits(env *environ.Environ) (Limits, error) {
+	cpuMaxPath := CPUMaxPath(env)
+	env.Log.V(2).Info("reading CPU max", "path", cpuMaxPath)
+	data, err := os.ReadFile(cpuMaxPath)
+	if err != nil {
+		env.Log.V(1).Info("failed to read CPU max", "path", cpuMaxPath, "error", err)
+		return Limits{}, err
+	}
+	quota, period, err := parseCPUMax(string(data))
+	if err != nil {
+		env.Log.V(1).Info("failed to parse CPU max", "path", cpuMaxPath, "error", err)
+		return Limits{}, err
+	}
+	lim := Limits{
+		CPUQuota:  quota,
+		CPUPeriod: period,
+	}
+
+	data, err = os.ReadFile(CPUWeightPath(env))
+	if err == nil {
+		lim.CPUWeight, err = strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	}
+	if err != nil {
+		env.Log.V(1).Info("cannot read CPU weight, skipping", "error", err)
+	}
+
+	data, err = os.ReadFile(MemoryMaxPath(env))
+	if err == nil {
+		lim.MemoryMax, err = parseMax(strings.TrimSpace(string(data)))
+	}
+	if err != nil {
+		env.Log.V(1).Info("cannot read memory max, skipping", "error", err)
+	}
+
+	env.Log.V(2).Info("parsed limits", "cpuQuota", lim.CPUQuota, "cpuPeriod", lim.CPUPeriod, "cpuWeight", lim.CPUWeight, "memoryMax", lim.MemoryMax)
+	return lim, nil
+}
+
+// parseCPUMax parses the "$QUOTA $PERIOD" format of cpu.max. The period may be omitted.
+func parseCPUMax(data string) (int64, int64, error) {
+	fields := strings.Fields(data)
+	if len(fields) < 1 || len(fields) > 2 {
+		return 0, 0, fmt.Errorf("malformed cpu.max: %q", data)
+	}
+	quota, err := parseMax(fields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	period := int64(100000) // kernel default
+	if len(fields) == 2 {
+		period, err = strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return quota, period, nil
+}
+
+func parseMax(val string) (int64, error) {
+	if val == "max" {
+		return Unlimited, nil
+	}
+	return strconv.ParseInt(val, 10, 64)
+}
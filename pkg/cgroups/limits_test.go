@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cgroups
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+func TestReadLimits(t *testing.T) {
+	testCases := []struct {
+		name        string
+		files       map[string]string
+		expected    Limits
+		expectedErr bool
+	}{
+		{
+			name: "guaranteed",
+			files: map[string]string{
+				CPUMaxFile:    "400000 100000\n",
+				CPUWeightFile: "157\n",
+				MemoryMaxFile: "1073741824\n",
+			},
+			expected: Limits{CPUQuota: 400000, CPUPeriod: 100000, CPUWeight: 157, MemoryMax: 1073741824},
+		},
+		{
+			name: "unlimited",
+			files: map[string]string{
+				CPUMaxFile:    "max 100000\n",
+				CPUWeightFile: "100\n",
+				MemoryMaxFile: "max\n",
+			},
+			expected: Limits{CPUQuota: Unlimited, CPUPeriod: 100000, CPUWeight: 100, MemoryMax: Unlimited},
+		},
+		{
+			name: "optional files missing",
+			files: map[string]string{
+				CPUMaxFile: "150000 100000\n",
+			},
+			expected: Limits{CPUQuota: 150000, CPUPeriod: 100000},
+		},
+		{
+			name:        "cpu.max missing",
+			files:       map[string]string{MemoryMaxFile: "max\n"},
+			expectedErr: true,
+		},
+		{
+			name:        "cpu.max malformed",
+			files:       map[string]string{CPUMaxFile: "foo 100000\n"},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			env := environ.Environ{
+				Root: environ.FS{
+					Sys: t.TempDir(),
+				},
+				Log: environ.DefaultLog(),
+			}
+			dir := filepath.Join(env.Root.Sys, CgroupPath)
+			err := os.MkdirAll(dir, os.ModePerm)
+			if err != nil {
+				t.Fatalf("cannot prepare the fake data path at %v: %v", dir, err)
+			}
+			for name, content := range tt.files {
+				err = os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+				if err != nil {
+					t.Fatalf("cannot prepare the fake data file %v: %v", name, err)
+				}
+			}
+
+			got, err := ReadLimits(&env)
+			if tt.expectedErr && err == nil {
+				t.Fatalf("expected error, got success")
+			}
+			if !tt.expectedErr && err != nil {
+				t.Fatalf("expected success, got err=%v", err)
+			}
+			if got != tt.expected {
+				t.Fatalf("expected limits %+v got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLimitsCPUs(t *testing.T) {
+	testCases := []struct {
+		name              string
+		limits            Limits
+		cpus              int
+		expectedMillis    int64
+		expectedExclusive bool
+		expectedThrottled bool
+	}{
+		{
+			name:              "exclusive",
+			limits:            Limits{CPUQuota: 400000, CPUPeriod: 100000},
+			cpus:              4,
+			expectedMillis:    4000,
+			expectedExclusive: true,
+		},
+		{
+			name:              "quota smaller than cpuset",
+			limits:            Limits{CPUQuota: 200000, CPUPeriod: 100000},
+			cpus:              4,
+			expectedMillis:    2000,
+			expectedThrottled: true,
+		},
+		{
+			name:              "fractional quota",
+			limits:            Limits{CPUQuota: 150000, CPUPeriod: 100000},
+			cpus:              2,
+			expectedMillis:    1500,
+			expectedThrottled: true,
+		},
+		{
+			name:           "quota larger than cpuset",
+			limits:         Limits{CPUQuota: 800000, CPUPeriod: 100000},
+			cpus:           4,
+			expectedMillis: 8000,
+		},
+		{
+			name:           "unlimited",
+			limits:         Limits{CPUQuota: Unlimited, CPUPeriod: 100000},
+			cpus:           4,
+			expectedMillis: Unlimited,
+		},
+		{
+			// BestEffort, or Burstable without CPU limit, running on the shared pool
+			name:           "shared pool without CPU limit",
+			limits:         Limits{CPUQuota: Unlimited, CPUPeriod: 100000, CPUWeight: 1},
+			cpus:           30,
+			expectedMillis: Unlimited,
+		},
+		{
+			name:           "unknown period",
+			limits:         Limits{CPUQuota: 400000},
+			cpus:           4,
+			expectedMillis: Unlimited,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.limits.CPUQuotaMillis(); got != tt.expectedMillis {
+				t.Errorf("expected quota %v millis got %v", tt.expectedMillis, got)
+			}
+			if got := tt.limits.Exclusive(tt.cpus); got != tt.expectedExclusive {
+				t.Errorf("expected exclusive %v got %v", tt.expectedExclusive, got)
+			}
+			if got := tt.limits.Throttled(tt.cpus); got != tt.expectedThrottled {
+				t.Errorf("expected throttled %v got %v", tt.expectedThrottled, got)
+			}
+		})
+	}
+}
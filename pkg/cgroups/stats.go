@@ -0,0 +1,277 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cgroups
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+const (
+	CPUStatFile        = "cpu.stat"
+	CPUPressureFile    = "cpu.pressure"
+	MemoryPressureFile = "memory.pressure"
+	IOPressureFile     = "io.pressure"
+)
+
+// CPUStat are the cumulative CPU counters of the cgroup. The throttling counters are
+// zero if the cpu controller is not enabled or there is no CPU quota.
+type CPUStat struct {
+	UsageUSec  uint64
+	UserUSec   uint64
+	SystemUSec uint64
+	// NrPeriods is the number of enforcement periods elapsed
+	NrPeriods uint64
+	// NrThrottled is the number of periods the cgroup ran out of quota
+	NrThrottled   uint64
+	ThrottledUSec uint64
+}
+
+// PressureLine is a line of the pressure stall information (PSI) files
+type PressureLine struct {
+	// Avg10, Avg60 and Avg300 are the percentage of time stalled over the last 10, 60 and 300 seconds
+	Avg10  float64
+	Avg60  float64
+	Avg300 float64
+	// Total is the cumulative stall time in microseconds
+	Total uint64
+}
+
+// Pressure tracks the time some or all of the tasks of the cgroup stalled waiting for a resource.
+type Pressure struct {
+	Some PressureLine
+	// Full is nil if the kernel doesn't report it
+	Full *PressureLine
+}
+
+// Stats is a sample of the cgroup CPU statistics and resource pressure.
+type Stats struct {
+	Timestamp time.Time
+	CPU       CPUStat
+	// the pressures are nil if the kernel has PSI disabled
+	CPUPressure    *Pressure
+	MemoryPressure *Pressure
+	IOPressure     *Pressure
+}
+
+func CPUStatPath(env *environ.Environ) string {
+	return filepath.Join(env.Root.Sys, CgroupPath, CPUStatFile)
+}
+
+func PressurePath(env *environ.Environ, file string) string {
+	return filepath.Join(env.Root.Sys, CgroupPath, file)
+}
+
+// ReadStats samples the cgroup statistics. cpu.stat is required, the pressure files are optional.
+func ReadStats(env *environ.Environ) (Stats, error) {
+	st := Stats{
+		Timestamp: time.Now(),
+	}
+	var err error
+	st.CPU, err = ReadCPUStat(env)
+	if err != nil {
+		return Stats{}, err
+	}
+	st.CPUPressure = readOptionalPressure(env, CPUPressureFile)
+	st.MemoryPressure = readOptionalPressure(env, MemoryPressureFile)
+	st.IOPressure = readOptionalPressure(env, IOPressureFile)
+	return st, nil
+}
+
+func ReadCPUStat(env *environ.Environ) (CPUStat, error) {
+	cpuStatPath := CPUStatPath(env)
+	env.Log.V(2).Info("reading CPU stat", "path", cpuStatPath)
+	fh, err := os.Open(cpuStatPath)
+	if err != nil {
+		env.Log.V(1).Info("failed to read CPU stat", "path", cpuStatPath, "error", err)
+		return CPUStat{}, err
+	}
+	defer fh.Close()
+
+	var cs CPUStat
+	fields := map[string]*uint64{
+		"usage_usec":     &cs.UsageUSec,
+		"user_usec":      &cs.UserUSec,
+		"system_usec":    &cs.SystemUSec,
+		"nr_periods":     &cs.NrPeriods,
+		"nr_throttled":   &cs.NrThrottled,
+		"throttled_usec": &cs.ThrottledUSec,
+	}
+
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		key, val, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if !ok {
+			continue
+		}
+		dst, ok := fields[key]
+		if !ok {
+			// e.g. nr_bursts, core_sched.force_idle_usec
+			continue
+		}
+		*dst, err = strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			env.Log.V(1).Info("failed to parse CPU stat", "path", cpuStatPath, "key", key, "error", err)
+			return CPUStat{}, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return CPUStat{}, err
+	}
+	env.Log.V(2).Info("parsed CPU stat", "path", cpuStatPath, "usageUSec", cs.UsageUSec, "nrThrottled", cs.NrThrottled, "throttledUSec", cs.ThrottledUSec)
+	return cs, nil
+}
+
+// ReadPressure reads one of the PSI files of the cgroup, e.g. CPUPressureFile.
+func ReadPressure(env *environ.Environ, file string) (Pressure, error) {
+	pressurePath := PressurePath(env, file)
+	env.Log.V(2).Info("reading pressure", "path", pressurePath)
+	data, err := os.ReadFile(pressurePath)
+	if err != nil {
+		env.Log.V(1).Info("failed to read pressure", "path", pressurePath, "error", err)
+		return Pressure{}, err
+	}
+	pr, err := parsePressure(string(data))
+	if err != nil {
+		env.Log.V(1).Info("failed to parse pressure", "path", pressurePath, "error", err)
+		return Pressure{}, err
+	}
+	return pr, nil
+}
+
+func readOptionalPressure(env *environ.Environ, file string) *Pressure {
+	pr, err := ReadPressure(env, file)
+	if err != nil {
+		env.Log.V(1).Info("cannot read pressure, skipping", "file", file, "error", err)
+		return nil
+	}
+	return &pr
+}
+
+// parsePressure parses the PSI format, e.g.
+// some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+// full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func parsePressure(data string) (Pressure, error) {
+	var pr Pressure
+	var hasSome bool
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		kind, rest, _ := strings.Cut(strings.TrimSpace(line), " ")
+		pl, err := parsePressureLine(rest)
+		if err != nil {
+			return Pressure{}, err
+		}
+		switch kind {
+		case "some":
+			pr.Some = pl
+			hasSome = true
+		case "full":
+			pr.Full = &pl
+		default:
+			return Pressure{}, fmt.Errorf("unknown pressure line %q", line)
+		}
+	}
+	if !hasSome {
+		return Pressure{}, fmt.Errorf("missing some pressure line")
+	}
+	return pr, nil
+}
+
+func parsePressureLine(data string) (PressureLine, error) {
+	var pl PressureLine
+	for _, field := range strings.Fields(data) {
+		key, val, ok := strings.Cut(field, "=")
+		if !ok {
+			return PressureLine{}, fmt.Errorf("malformed pressure field %q", field)
+		}
+		var err error
+		switch key {
+		case "avg10":
+			pl.Avg10, err = strconv.ParseFloat(val, 64)
+		case "avg60":
+			pl.Avg60, err = strconv.ParseFloat(val, 64)
+		case "avg300":
+			pl.Avg300, err = strconv.ParseFloat(val, 64)
+		case "total":
+			pl.Total, err = strconv.ParseUint(val, 10, 64)
+		}
+		if err != nil {
+			return PressureLine{}, err
+		}
+	}
+	return pl, nil
+}
+
+// StallRate is the percentage of the sampling interval some or all the tasks were stalled.
+type StallRate struct {
+	SomePercent float64
+	// FullPercent is meaningful only if the kernel reports the full pressure
+	FullPercent float64
+}
+
+// Rates are the changes of the cumulative counters between two samples, averaged over the interval.
+type Rates struct {
+	Interval time.Duration
+	// UsageCPUs is the average number of CPUs used
+	UsageCPUs float64
+	// ThrottledPeriodsPercent is the percentage of the enforcement periods the cgroup ran out of quota
+	ThrottledPeriodsPercent float64
+	NrThrottled             uint64
+	ThrottledUSec           uint64
+	// the stall rates are nil if the pressure is unknown in either sample
+	CPUStall    *StallRate
+	MemoryStall *StallRate
+	IOStall     *StallRate
+}
+
+// RatesSince computes the rates from the prev sample to st. Counters going backwards,
+// e.g. because the cgroup was recreated, count as no change.
+func (st Stats) RatesSince(prev Stats) Rates {
+	rt := Rates{
+		Interval:      st.Timestamp.Sub(prev.Timestamp),
+		NrThrottled:   delta(prev.CPU.NrThrottled, st.CPU.NrThrottled),
+		ThrottledUSec: delta(prev.CPU.ThrottledUSec, st.CPU.ThrottledUSec),
+	}
+	if periods := delta(prev.CPU.NrPeriods, st.CPU.NrPeriods); periods > 0 {
+		rt.ThrottledPeriodsPercent = percent(rt.NrThrottled, periods)
+	}
+	intervalUSec := uint64(rt.Interval.Microseconds())
+	if intervalUSec == 0 {
+		return rt
+	}
+	rt.UsageCPUs = float64(delta(prev.CPU.UsageUSec, st.CPU.UsageUSec)) / float64(intervalUSec)
+	rt.CPUStall = stallRate(prev.CPUPressure, st.CPUPressure, intervalUSec)
+	rt.MemoryStall = stallRate(prev.MemoryPressure, st.MemoryPressure, intervalUSec)
+	rt.IOStall = stallRate(prev.IOPressure, st.IOPressure, intervalUSec)
+	return rt
+}
+
+func stallRate(prev, cur *Pressure, intervalUSec uint64) *StallRate {
+	if prev == nil || cur == nil {
+		return nil
+	}
+	sr := StallRate{
+		SomePercent: percent(delta(prev.Some.Total, cur.Some.Total), intervalUSec),
+	}
+	if prev.Full != nil && cur.Full != nil {
+		sr.FullPercent = percent(delta(prev.Full.Total, cur.Full.Total), intervalUSec)
+	}
+	return &sr
+}
+
+func delta(prev, cur uint64) uint64 {
+	if cur < prev {
+		return 0
+	}
+	return cur - prev
+}
+
+func percent(val, total uint64) float64 {
+	return float64(val) * 100 / float64(total)
+}
@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cgroups
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+const (
+	testCPUStat = `usage_usec 8214000
+user_usec 6100000
+system_usec 2114000
+core_sched.force_idle_usec 0
+nr_periods 1200
+nr_throttled 300
+throttled_usec 4500000
+nr_bursts 0
+burst_usec 0
+`
+	testCPUPressure = `some avg10=12.50 avg60=8.25 avg300=2.00 total=3000000
+full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+`
+	testMemoryPressure = `some avg10=0.10 avg60=0.05 avg300=0.00 total=1000
+full avg10=0.00 avg60=0.00 avg300=0.00 total=500
+`
+)
+
+func TestReadStats(t *testing.T) {
+	testCases := []struct {
+		name        string
+		files       map[string]string
+		expected    Stats
+		expectedErr bool
+	}{
+		{
+			name: "all files",
+			files: map[string]string{
+				CPUStatFile:        testCPUStat,
+				CPUPressureFile:    testCPUPressure,
+				MemoryPressureFile: testMemoryPressure,
+				IOPressureFile:     "some avg10=1.00 avg60=0.50 avg300=0.10 total=200000\n",
+			},
+			expected: Stats{
+				CPU: CPUStat{UsageUSec: 8214000, UserUSec: 6100000, SystemUSec: 2114000, NrPeriods: 1200, NrThrottled: 300, ThrottledUSec: 4500000},
+				CPUPressure: &Pressure{
+					Some: PressureLine{Avg10: 12.5, Avg60: 8.25, Avg300: 2, Total: 3000000},
+					Full: &PressureLine{},
+				},
+				MemoryPressure: &Pressure{
+					Some: PressureLine{Avg10: 0.1, Avg60: 0.05, Total: 1000},
+					Full: &PressureLine{Total: 500},
+				},
+				IOPressure: &Pressure{
+					Some: PressureLine{Avg10: 1, Avg60: 0.5, Avg300: 0.1, Total: 200000},
+				},
+			},
+		},
+		{
+			name: "PSI disabled, no cpu controller",
+			files: map[string]string{
+				CPUStatFile: "usage_usec 100\nuser_usec 60\nsystem_usec 40\n",
+			},
+			expected: Stats{
+				CPU: CPUStat{UsageUSec: 100, UserUSec: 60, SystemUSec: 40},
+			},
+		},
+		{
+			name: "malformed pressure is skipped",
+			files: map[string]string{
+				CPUStatFile:     "usage_usec 100\n",
+				CPUPressureFile: "some avg10=foo\n",
+			},
+			expected: Stats{
+				CPU: CPUStat{UsageUSec: 100},
+			},
+		},
+		{
+			name:        "cpu.stat missing",
+			files:       map[string]string{CPUPressureFile: testCPUPressure},
+			expectedErr: true,
+		},
+		{
+			name:        "cpu.stat malformed",
+			files:       map[string]string{CPUStatFile: "usage_usec foo\n"},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			env := environ.Environ{
+				Root: environ.FS{
+					Sys: t.TempDir(),
+				},
+				Log: environ.DefaultLog(),
+			}
+			dir := filepath.Join(env.Root.Sys, CgroupPath)
+			err := os.MkdirAll(dir, os.ModePerm)
+			if err != nil {
+				t.Fatalf("cannot prepare the fake data path at %v: %v", dir, err)
+			}
+			for name, content := range tt.files {
+				err = os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+				if err != nil {
+					t.Fatalf("cannot prepare the fake data file %v: %v", name, err)
+				}
+			}
+
+			got, err := ReadStats(&env)
+			if tt.expectedErr && err == nil {
+				t.Fatalf("expected error, got success")
+			}
+			if !tt.expectedErr && err != nil {
+				t.Fatalf("expected success, got err=%v", err)
+			}
+			got.Timestamp = time.Time{}
+			if diff := cmp.Diff(tt.expected, got); diff != "" {
+				t.Fatalf("unexpected stats (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRatesSince(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	prev := Stats{
+		Timestamp:      start,
+		CPU:            CPUStat{UsageUSec: 1000000, NrPeriods: 100, NrThrottled: 10, ThrottledUSec: 50000},
+		CPUPressure:    &Pressure{Some: PressureLine{Total: 100000}, Full: &PressureLine{Total: 0}},
+		MemoryPressure: &Pressure{Some: PressureLine{Total: 5000}, Full: &PressureLine{Total: 1000}},
+	}
+	cur := Stats{
+		Timestamp:      start.Add(2 * time.Second),
+		CPU:            CPUStat{UsageUSec: 5000000, NrPeriods: 120, NrThrottled: 15, ThrottledUSec: 250000},
+		CPUPressure:    &Pressure{Some: PressureLine{Total: 600000}, Full: &PressureLine{Total: 0}},
+		MemoryPressure: &Pressure{Some: PressureLine{Total: 25000}, Full: &PressureLine{Total: 21000}},
+		IOPressure:     &Pressure{Some: PressureLine{Total: 1000}},
+	}
+	expected := Rates{
+		Interval:                2 * time.Second,
+		UsageCPUs:               2,
+		ThrottledPeriodsPercent: 25,
+		NrThrottled:             5,
+		ThrottledUSec:           200000,
+		CPUStall:                &StallRate{SomePercent: 25},
+		MemoryStall:             &StallRate{SomePercent: 1, FullPercent: 1},
+	}
+	got := cur.RatesSince(prev)
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Fatalf("unexpected rates (-want +got):\n%s", diff)
+	}
+
+	// counters going backwards, e.g. the cgroup was recreated
+	got = prev.RatesSince(Stats{Timestamp: start.Add(-time.Second), CPU: CPUStat{UsageUSec: 2000000, NrPeriods: 200}})
+	expected = Rates{
+		Interval:      time.Second,
+		NrThrottled:   10,
+		ThrottledUSec: 50000,
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Fatalf("unexpected rates (-want +got):\n%s", diff)
+	}
+}
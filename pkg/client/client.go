@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ffromani/ctrreschk/api/schema"
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	apiv1 "github.com/ffromani/ctrreschk/api/v1"
+)
+
+// Validate checks the JSON document against the published schema with the given name, e.g. v1.Allocation.
+func Validate(name string, data []byte) error {
+	schemaData, err := schema.Get(name)
+	if err != nil {
+		return err
+	}
+	return validate(schemaData, data)
+}
+
+// Extract returns the JSON objects starting a line in the logs, in order of appearance.
+// ctrreschk writes its output on stdout and logs on stderr, so pod logs usually interleave both;
+// the lines prefixed by a RFC3339 timestamp, like `kubectl logs --timestamps` emits, are supported.
+func Extract(logs []byte) [][]byte {
+	var docs [][]byte
+	for len(logs) > 0 {
+		line := logs
+		next := len(logs)
+		if idx := bytes.IndexByte(logs, '\n'); idx >= 0 {
+			line = logs[:idx]
+			next = idx + 1
+		}
+		start := jsonStart(line)
+		if start < 0 {
+			logs = logs[next:]
+			continue
+		}
+		// the document may span multiple lines, if pretty printed
+		rest := logs[start:]
+		dec := json.NewDecoder(bytes.NewReader(rest))
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			logs = logs[next:]
+			continue
+		}
+		docs = append(docs, raw)
+		logs = rest[dec.InputOffset():]
+	}
+	return docs
+}
+
+// jsonStart returns the offset of the JSON object starting the line, or -1 if the line doesn't start with a JSON object.
+func jsonStart(line []byte) int {
+	trimmed := bytes.TrimLeft(line, " \t")
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return len(line) - len(trimmed)
+	}
+	stamp, rest, ok := bytes.Cut(trimmed, []byte(" "))
+	if !ok {
+		return -1
+	}
+	if _, err := time.Parse(time.RFC3339Nano, string(stamp)); err != nil {
+		return -1
+	}
+	trimmedRest := bytes.TrimLeft(rest, " \t")
+	if len(trimmedRest) == 0 || trimmedRest[0] != '{' {
+		return -1
+	}
+	return len(line) - len(trimmedRest)
+}
+
+// Decode finds the last document in the logs valid against the published schema with the given name,
+// and decodes it in obj.
+func Decode(logs []byte, name string, obj any) error {
+	docs := Extract(logs)
+	var errs []error
+	for idx := len(docs) - 1; idx >= 0; idx-- {
+		err := Validate(name, docs[idx])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return json.Unmarshal(docs[idx], obj)
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("no JSON document found")
+	}
+	return fmt.Errorf("no valid %s document found: %w", name, errors.Join(errs...))
+}
+
+// DecodeAllocation decodes the output of the align command. The v0 output is converted to v1,
+// with empty metadata.
+func DecodeAllocation(logs []byte) (apiv1.Allocation, error) {
+	var alloc apiv1.Allocation
+	errV1 := Decode(logs, "v1.Allocation", &alloc)
+	if errV1 == nil {
+		return alloc, nil
+	}
+	var allocV0 apiv0.Allocation
+	errV0 := Decode(logs, "v0.Allocation", &allocV0)
+	if errV0 != nil {
+		return apiv1.Allocation{}, errors.Join(errV1, errV0)
+	}
+	return apiv1.FromV0(allocV0, apiv1.Metadata{}), nil
+}
+
+// DecodeNUMAMaps decodes the output of the alignmem command.
+func DecodeNUMAMaps(logs []byte) (apiv0.NUMAMapsInfo, error) {
+	var info apiv0.NUMAMapsInfo
+	err := Decode(logs, "v0.NUMAMapsInfo", &info)
+	return info, err
+}
@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	apiv1 "github.com/ffromani/ctrreschk/api/v1"
+)
+
+func TestExtract(t *testing.T) {
+	logs := `2026/01/02 03:04:05 "msg"="reading cpuset" "path"="/sys/fs/cgroup/cpuset.cpus.effective"
+{"alignment":{"smt":true,"llc":true,"numa":true,"memory":false}}
+2026/01/02 03:04:05 "msg"="{not json"
+  {
+    "pages": 1
+  }
+2026-01-02T03:04:05.123456789Z {"nodes":{}}
+{"truncated":
+`
+	got := Extract([]byte(logs))
+	var gotStr []string
+	for _, doc := range got {
+		gotStr = append(gotStr, string(doc))
+	}
+	expected := []string{
+		`{"alignment":{"smt":true,"llc":true,"numa":true,"memory":false}}`,
+		"{\n    \"pages\": 1\n  }",
+		`{"nodes":{}}`,
+	}
+	if diff := cmp.Diff(expected, gotStr); diff != "" {
+		t.Errorf("unexpected documents (-want +got):\n%s", diff)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		schema      string
+		doc         string
+		expectedErr string
+	}{
+		{
+			name:   "valid v0",
+			schema: "v0.Allocation",
+			doc:    `{"alignment":{"smt":true,"llc":true,"numa":true,"memory":false},"aligned":{"numa":{"0":{"cpus":[0,16]}}},"newField":1}`,
+		},
+		{
+			name:        "missing required",
+			schema:      "v0.Allocation",
+			doc:         `{"alignment":{"smt":true,"llc":true,"numa":true}}`,
+			expectedErr: `$.alignment: missing required property "memory"`,
+		},
+		{
+			name:        "wrong type",
+			schema:      "v0.Allocation",
+			doc:         `{"alignment":{"smt":true,"llc":true,"numa":true,"memory":false},"aligned":{"numa":{"0":{"cpus":[0.5]}}}}`,
+			expectedErr: `$.aligned.numa.0.cpus[0]: expected integer, got number`,
+		},
+		{
+			name:        "invalid integer key",
+			schema:      "v0.Allocation",
+			doc:         `{"alignment":{"smt":true,"llc":true,"numa":true,"memory":false},"aligned":{"numa":{"zero":{}}}}`,
+			expectedErr: `$.aligned.numa: invalid property name "zero"`,
+		},
+		{
+			name:        "invalid enum",
+			schema:      "v1.Allocation",
+			doc:         `{"apiVersion":"ctrreschk/v1","kind":"Allocation","metadata":{"timestamp":"2026-01-02T03:04:05Z"},"status":"maybe","checks":[]}`,
+			expectedErr: `$.status: value maybe not in [aligned unaligned skipped error]`,
+		},
+		{
+			name:        "invalid timestamp",
+			schema:      "v1.Allocation",
+			doc:         `{"apiVersion":"ctrreschk/v1","kind":"Allocation","metadata":{"timestamp":"yesterday"},"status":"aligned","checks":[]}`,
+			expectedErr: `$.metadata.timestamp: invalid date-time`,
+		},
+		{
+			name:        "unknown schema",
+			schema:      "v0.Foobar",
+			doc:         `{}`,
+			expectedErr: `unknown schema "v0.Foobar"`,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.schema, []byte(tt.doc))
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestDecodeAllocation(t *testing.T) {
+	allocV0 := apiv0.Allocation{
+		Alignment: apiv0.Alignment{SMT: true, LLC: true, NUMA: true},
+		Aligned: &apiv0.AlignedInfo{
+			NUMA: map[int]apiv0.ContainerResourcesDetails{0: {CPUs: []int{0, 16}}},
+		},
+	}
+	meta := apiv1.Metadata{Hostname: "worker-0", Timestamp: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+	allocV1 := apiv1.FromV0(allocV0, meta)
+
+	t.Run("v1", func(t *testing.T) {
+		logs := "2026/01/02 03:04:05 \"msg\"=\"starting\"\n" + mustJSON(t, allocV1) + "\n2026/01/02 03:04:05 \"msg\"=\"done\"\n"
+		got, err := DecodeAllocation([]byte(logs))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(allocV1, got); diff != "" {
+			t.Errorf("unexpected allocation (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("v0 converted", func(t *testing.T) {
+		logs := mustJSON(t, allocV0) + "\n"
+		got, err := DecodeAllocation([]byte(logs))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Status != apiv1.StatusAligned || len(got.Checks) != len(allocV1.Checks) {
+			t.Errorf("unexpected allocation: %+v", got)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if _, err := DecodeAllocation([]byte("{\"pages\":1}\nsome log\n")); err == nil {
+			t.Fatalf("expected error, got nil")
+		}
+	})
+}
+
+func TestDecodeNUMAMaps(t *testing.T) {
+	info := apiv0.NUMAMapsInfo{}
+	logs := "2026/01/02 03:04:05 \"msg\"=\"reading numa_maps\"\n" + mustJSON(t, info) + "\n"
+	if _, err := DecodeNUMAMaps([]byte(logs)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func mustJSON(t *testing.T, obj any) string {
+	t.Helper()
+	data, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("cannot encode: %v", err)
+	}
+	return string(data)
+}
@@ -0,0 +1,179 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// validator checks a decoded JSON document against the subset of JSON Schema used by the published schemas:
+// type, enum, properties, required, items, additionalProperties, propertyNames, anyOf, $ref and the date-time format.
+type validator struct {
+	defs map[string]any
+}
+
+func validate(schemaData, data []byte) error {
+	var schema map[string]any
+	if err := json.Unmarshal(schemaData, &schema); err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// keep the numbers as they are, to tell the integers apart
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return err
+	}
+	vd := validator{}
+	if defs, ok := schema["$defs"].(map[string]any); ok {
+		vd.defs = defs
+	}
+	return vd.validate(schema, doc, "$")
+}
+
+func (vd validator) validate(schema map[string]any, value any, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		def, ok := vd.defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: unknown reference %q", path, ref)
+		}
+		return vd.validate(def, value, path)
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		var errs []string
+		for _, item := range anyOf {
+			sub, _ := item.(map[string]any)
+			err := vd.validate(sub, value, path)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return fmt.Errorf("%s: no alternative matches: %s", path, strings.Join(errs, "; "))
+	}
+	if err := checkType(schema["type"], value, path); err != nil {
+		return err
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		return fmt.Errorf("%s: value %v not in %v", path, value, enum)
+	}
+	if schema["format"] == "date-time" {
+		if str, ok := value.(string); ok {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				return fmt.Errorf("%s: invalid date-time: %w", path, err)
+			}
+		}
+	}
+
+	switch val := value.(type) {
+	case map[string]any:
+		return vd.validateObject(schema, val, path)
+	case []any:
+		items, ok := schema["items"].(map[string]any)
+		if !ok {
+			return nil
+		}
+		for idx, item := range val {
+			if err := vd.validate(items, item, fmt.Sprintf("%s[%d]", path, idx)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (vd validator) validateObject(schema map[string]any, obj map[string]any, path string) error {
+	required, _ := schema["required"].([]any)
+	for _, name := range required {
+		key, _ := name.(string)
+		if _, ok := obj[key]; !ok {
+			return fmt.Errorf("%s: missing required property %q", path, key)
+		}
+	}
+	props, _ := schema["properties"].(map[string]any)
+	additional, _ := schema["additionalProperties"].(map[string]any)
+	var namePattern *regexp.Regexp
+	if names, ok := schema["propertyNames"].(map[string]any); ok {
+		if pattern, ok := names["pattern"].(string); ok {
+			var err error
+			namePattern, err = regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("%s: invalid property names pattern: %w", path, err)
+			}
+		}
+	}
+
+	// sorted for reproducible errors
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if namePattern != nil && !namePattern.MatchString(key) {
+			return fmt.Errorf("%s: invalid property name %q", path, key)
+		}
+		sub, ok := props[key].(map[string]any)
+		if !ok {
+			sub = additional
+		}
+		if sub == nil {
+			// unknown properties are allowed, they may come from a newer version of the tool
+			continue
+		}
+		if err := vd.validate(sub, obj[key], path+"."+key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkType(typeSpec, value any, path string) error {
+	var types []string
+	switch ts := typeSpec.(type) {
+	case nil:
+		return nil
+	case string:
+		types = []string{ts}
+	case []any:
+		for _, item := range ts {
+			if str, ok := item.(string); ok {
+				types = append(types, str)
+			}
+		}
+	}
+	actual := jsonType(value)
+	for _, typ := range types {
+		if typ == actual || (typ == "number" && actual == "integer") {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), actual)
+}
+
+func jsonType(value any) string {
+	switch val := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := val.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "unknown"
+}
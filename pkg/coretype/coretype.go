@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package coretype
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+const (
+	// Performance and Efficiency are the core classes of the Intel hybrid CPUs,
+	// exposed by the kernel as the cpu_core and cpu_atom PMUs
+	Performance = "performance"
+	Efficiency  = "efficiency"
+
+	// CapacityPrefix prefixes the core classes derived from cpu_capacity, e.g. on ARM big.LITTLE
+	CapacityPrefix = "capacity-"
+)
+
+// Info maps each core class to its CPUs.
+type Info struct {
+	Classes map[string]cpuset.CPUSet
+}
+
+func (info Info) MarshalJSON() ([]byte, error) {
+	classes := make(map[string]string, len(info.Classes))
+	for class, cpus := range info.Classes {
+		classes[class] = cpus.String()
+	}
+	return json.Marshal(classes)
+}
+
+func (info *Info) UnmarshalJSON(data []byte) error {
+	var classes map[string]string
+	err := json.Unmarshal(data, &classes)
+	if err != nil {
+		return err
+	}
+	info.Classes = make(map[string]cpuset.CPUSet, len(classes))
+	for class, cpuList := range classes {
+		cpus, err := cpuset.Parse(cpuList)
+		if err != nil {
+			return err
+		}
+		info.Classes[class] = cpus
+	}
+	return nil
+}
+
+// Discover detects the core classes of the CPUs. The hybrid PMUs are preferred, because they
+// are authoritative on Intel hybrid CPUs; otherwise the CPUs are grouped by cpu_capacity.
+// Returns nil if all the CPUs have the same class, so there is nothing to check.
+func Discover(env *environ.Environ) (*Info, error) {
+	info, err := discoverHybrid(env)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		info, err = discoverCapacity(env)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if info == nil || len(info.Classes) < 2 {
+		env.Log.V(2).Info("homogeneous cores")
+		return nil, nil
+	}
+	env.Log.V(2).Info("heterogeneous cores", "classes", len(info.Classes))
+	return info, nil
+}
+
+func discoverHybrid(env *environ.Environ) (*Info, error) {
+	pmus := map[string]string{
+		Performance: "cpu_core",
+		Efficiency:  "cpu_atom",
+	}
+	info := Info{Classes: make(map[string]cpuset.CPUSet)}
+	for class, pmu := range pmus {
+		data, err := os.ReadFile(filepath.Join(env.Root.Sys, "devices", pmu, "cpus"))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		cpus, err := cpuset.Parse(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, err
+		}
+		env.Log.V(4).Info("hybrid core class", "class", class, "cpus", cpus.String())
+		info.Classes[class] = cpus
+	}
+	if len(info.Classes) == 0 {
+		return nil, nil
+	}
+	return &info, nil
+}
+
+func discoverCapacity(env *environ.Environ) (*Info, error) {
+	cpuDir := filepath.Join(env.Root.Sys, "devices", "system", "cpu")
+	entries, err := os.ReadDir(cpuDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	byCapacity := make(map[int][]int)
+	for _, entry := range entries {
+		idStr, ok := strings.CutPrefix(entry.Name(), "cpu")
+		if !ok {
+			continue
+		}
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			// cpufreq, cpuidle...
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(cpuDir, entry.Name(), "cpu_capacity"))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		capacity, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, err
+		}
+		byCapacity[capacity] = append(byCapacity[capacity], id)
+	}
+	if len(byCapacity) == 0 {
+		return nil, nil
+	}
+	info := Info{Classes: make(map[string]cpuset.CPUSet)}
+	for capacity, cpuIDs := range byCapacity {
+		class := CapacityPrefix + strconv.Itoa(capacity)
+		info.Classes[class] = cpuset.New(cpuIDs...)
+		env.Log.V(4).Info("capacity core class", "class", class, "cpus", info.Classes[class].String())
+	}
+	return &info, nil
+}
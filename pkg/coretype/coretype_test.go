@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package coretype
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+var cpuSetComparer = cmp.Comparer(func(a, b cpuset.CPUSet) bool { return a.Equals(b) })
+
+func TestDiscover(t *testing.T) {
+	testCases := []struct {
+		name     string
+		files    map[string]string
+		expected *Info
+	}{
+		{
+			name: "intel hybrid",
+			files: map[string]string{
+				"devices/cpu_core/cpus":                "0-15\n",
+				"devices/cpu_atom/cpus":                "16-23\n",
+				"devices/system/cpu/cpu0/cpu_capacity": "1024\n",
+			},
+			expected: &Info{Classes: map[string]cpuset.CPUSet{
+				Performance: cpuset.New(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15),
+				Efficiency:  cpuset.New(16, 17, 18, 19, 20, 21, 22, 23),
+			}},
+		},
+		{
+			name: "arm big.LITTLE",
+			files: map[string]string{
+				"devices/system/cpu/cpu0/cpu_capacity": "446\n",
+				"devices/system/cpu/cpu1/cpu_capacity": "446\n",
+				"devices/system/cpu/cpu2/cpu_capacity": "1024\n",
+				"devices/system/cpu/cpu3/cpu_capacity": "1024\n",
+				"devices/system/cpu/cpufreq/boost":     "0\n",
+			},
+			expected: &Info{Classes: map[string]cpuset.CPUSet{
+				CapacityPrefix + "446":  cpuset.New(0, 1),
+				CapacityPrefix + "1024": cpuset.New(2, 3),
+			}},
+		},
+		{
+			name: "homogeneous capacity",
+			files: map[string]string{
+				"devices/system/cpu/cpu0/cpu_capacity": "1024\n",
+				"devices/system/cpu/cpu1/cpu_capacity": "1024\n",
+			},
+		},
+		{
+			name: "only one hybrid PMU",
+			files: map[string]string{
+				"devices/cpu_core/cpus": "0-7\n",
+			},
+		},
+		{
+			name: "nothing exposed",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			sysDir := t.TempDir()
+			env := &environ.Environ{
+				Root: environ.FS{Sys: sysDir},
+				Log:  environ.DefaultLog(),
+			}
+			for path, content := range tt.files {
+				mustWriteFile(t, filepath.Join(sysDir, path), content)
+			}
+			got, err := Discover(env)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, got, cpuSetComparer); diff != "" {
+				t.Errorf("unexpected core types (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiscoverMalformed(t *testing.T) {
+	sysDir := t.TempDir()
+	env := &environ.Environ{
+		Root: environ.FS{Sys: sysDir},
+		Log:  environ.DefaultLog(),
+	}
+	mustWriteFile(t, filepath.Join(sysDir, "devices", "cpu_core", "cpus"), "foo\n")
+	if _, err := Discover(env); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	info := Info{Classes: map[string]cpuset.CPUSet{
+		Performance: cpuset.New(0, 1, 2, 3),
+		Efficiency:  cpuset.New(4, 5, 6, 7),
+	}}
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"efficiency":"4-7","performance":"0-3"}` {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+	var got Info
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(info, got, cpuSetComparer); diff != "" {
+		t.Errorf("unexpected round trip (-want +got):\n%s", diff)
+	}
+}
+
+func mustWriteFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		t.Fatalf("cannot create dir for %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("cannot write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,273 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cpupower
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+const (
+	AttrDriver                      = "driver"
+	AttrGovernor                    = "governor"
+	AttrEnergyPerformancePreference = "energyPerformancePreference"
+	AttrMinFreq                     = "minFreqKHz"
+	AttrMaxFreq                     = "maxFreqKHz"
+	AttrEnabledIdleStates           = "enabledIdleStates"
+)
+
+const (
+	// FreqUncapped is the value of AttrMaxFreq when the max scaling frequency is the max hardware frequency
+	FreqUncapped = "uncapped"
+	// FreqUnrestricted is the value of AttrMinFreq when the min scaling frequency is the min hardware frequency
+	FreqUnrestricted = "unrestricted"
+)
+
+type IdleState struct {
+	Name string
+	// LatencyUS is the exit latency in microseconds
+	LatencyUS int64
+	Disabled  bool
+}
+
+// CPUState is the frequency scaling and idle configuration of a CPU.
+// The string fields are empty and the numeric fields are zero if the kernel doesn't expose them,
+// e.g. on virtual machines without cpufreq.
+type CPUState struct {
+	CPU                         int
+	Driver                      string
+	Governor                    string
+	EnergyPerformancePreference string
+	MinFreqKHz                  int64
+	MaxFreqKHz                  int64
+	// HWMinFreqKHz is the min frequency supported by the hardware (cpuinfo_min_freq)
+	HWMinFreqKHz int64
+	// HWMaxFreqKHz is the max frequency supported by the hardware (cpuinfo_max_freq)
+	HWMaxFreqKHz int64
+	IdleStates   []IdleState
+}
+
+// EnabledIdleStates returns the names of the idle states the CPU is allowed to enter.
+func (st CPUState) EnabledIdleStates() []string {
+	var names []string
+	for _, idle := range st.IdleStates {
+		if idle.Disabled {
+			continue
+		}
+		names = append(names, idle.Name)
+	}
+	return names
+}
+
+// Attributes returns the values of the attributes expected to be the same on all the container CPUs.
+// The hardware frequency range differs across the cores of hybrid CPUs or with ITMT (Turbo Boost Max 3.0),
+// so the scaling frequencies are reported relative to the hardware limits: FreqUncapped and FreqUnrestricted
+// if they match them, the scaling frequency in kHz otherwise.
+func (st CPUState) Attributes() map[string]string {
+	return map[string]string{
+		AttrDriver:                      st.Driver,
+		AttrGovernor:                    st.Governor,
+		AttrEnergyPerformancePreference: st.EnergyPerformancePreference,
+		AttrMinFreq:                     relativeFreq(st.MinFreqKHz, st.HWMinFreqKHz, FreqUnrestricted, func(freq, hwFreq int64) bool { return freq <= hwFreq }),
+		AttrMaxFreq:                     relativeFreq(st.MaxFreqKHz, st.HWMaxFreqKHz, FreqUncapped, func(freq, hwFreq int64) bool { return freq >= hwFreq }),
+		AttrEnabledIdleStates:           strings.Join(st.EnabledIdleStates(), ","),
+	}
+}
+
+// relativeFreq returns atLimit if the scaling frequency reaches the hardware limit, the frequency in kHz otherwise.
+// The hardware limit is unknown if zero, e.g. on virtual machines without cpufreq.
+func relativeFreq(freqKHz, hwFreqKHz int64, atLimit string, reaches func(freq, hwFreq int64) bool) string {
+	if hwFreqKHz > 0 && reaches(freqKHz, hwFreqKHz) {
+		return atLimit
+	}
+	return strconv.FormatInt(freqKHz, 10)
+}
+
+// Read returns the state of the given CPUs, sorted by CPU id.
+func Read(env *environ.Environ, cpus cpuset.CPUSet) ([]CPUState, error) {
+	var states []CPUState
+	for _, cpu := range cpus.List() {
+		st, err := readCPUState(env, cpu)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, st)
+	}
+	return states, nil
+}
+
+// Heterogeneous returns, for each attribute which differs across the given CPUs, the CPUs having each value.
+func Heterogeneous(states []CPUState) map[string]map[string]cpuset.CPUSet {
+	values := make(map[string]map[string][]int)
+	for _, st := range states {
+		for attr, value := range st.Attributes() {
+			if values[attr] == nil {
+				values[attr] = make(map[string][]int)
+			}
+			values[attr][value] = append(values[attr][value], st.CPU)
+		}
+	}
+	ret := make(map[string]map[string]cpuset.CPUSet)
+	for attr, cpusByValue := range values {
+		if len(cpusByValue) < 2 {
+			continue
+		}
+		ret[attr] = make(map[string]cpuset.CPUSet)
+		for value, cpuIDs := range cpusByValue {
+			ret[attr][value] = cpuset.New(cpuIDs...)
+		}
+	}
+	return ret
+}
+
+// Profile is the desired power configuration of the container CPUs.
+type Profile struct {
+	// Governor is not checked if empty
+	Governor string
+	// EnergyPerformancePreference is not checked if empty
+	EnergyPerformancePreference string
+	// UncappedMaxFreq requires the max scaling frequency to be the max hardware frequency
+	UncappedMaxFreq bool
+	// MaxIdleLatencyUS is the max exit latency allowed for the enabled idle states. Not checked if negative.
+	MaxIdleLatencyUS int64
+}
+
+type Deviation struct {
+	CPU       int
+	Attribute string
+	Expected  string
+	Actual    string
+}
+
+// Deviations returns the differences between the CPU state and the profile.
+func (prof Profile) Deviations(st CPUState) []Deviation {
+	var devs []Deviation
+	if prof.Governor != "" && st.Governor != prof.Governor {
+		devs = append(devs, Deviation{CPU: st.CPU, Attribute: AttrGovernor, Expected: prof.Governor, Actual: st.Governor})
+	}
+	if prof.EnergyPerformancePreference != "" && st.EnergyPerformancePreference != prof.EnergyPerformancePreference {
+		devs = append(devs, Deviation{CPU: st.CPU, Attribute: AttrEnergyPerformancePreference, Expected: prof.EnergyPerformancePreference, Actual: st.EnergyPerformancePreference})
+	}
+	if prof.UncappedMaxFreq && st.MaxFreqKHz < st.HWMaxFreqKHz {
+		devs = append(devs, Deviation{CPU: st.CPU, Attribute: AttrMaxFreq, Expected: strconv.FormatInt(st.HWMaxFreqKHz, 10), Actual: strconv.FormatInt(st.MaxFreqKHz, 10)})
+	}
+	if prof.MaxIdleLatencyUS >= 0 {
+		for _, idle := range st.IdleStates {
+			if idle.Disabled || idle.LatencyUS <= prof.MaxIdleLatencyUS {
+				continue
+			}
+			devs = append(devs, Deviation{CPU: st.CPU, Attribute: AttrEnabledIdleStates, Expected: "latency<=" + strconv.FormatInt(prof.MaxIdleLatencyUS, 10) + "us", Actual: idle.Name + "=" + strconv.FormatInt(idle.LatencyUS, 10) + "us"})
+		}
+	}
+	return devs
+}
+
+func readCPUState(env *environ.Environ, cpu int) (CPUState, error) {
+	cpuDir := filepath.Join(env.Root.Sys, "devices", "system", "cpu", "cpu"+strconv.Itoa(cpu))
+	if _, err := os.Stat(cpuDir); err != nil {
+		return CPUState{}, err
+	}
+	st := CPUState{CPU: cpu}
+	freqDir := filepath.Join(cpuDir, "cpufreq")
+
+	var errs []error
+	var err error
+	st.Driver, err = readOptionalString(filepath.Join(freqDir, "scaling_driver"))
+	errs = append(errs, err)
+	st.Governor, err = readOptionalString(filepath.Join(freqDir, "scaling_governor"))
+	errs = append(errs, err)
+	st.EnergyPerformancePreference, err = readOptionalString(filepath.Join(freqDir, "energy_performance_preference"))
+	errs = append(errs, err)
+	st.MinFreqKHz, err = readOptionalInt(filepath.Join(freqDir, "scaling_min_freq"))
+	errs = append(errs, err)
+	st.MaxFreqKHz, err = readOptionalInt(filepath.Join(freqDir, "scaling_max_freq"))
+	errs = append(errs, err)
+	st.HWMinFreqKHz, err = readOptionalInt(filepath.Join(freqDir, "cpuinfo_min_freq"))
+	errs = append(errs, err)
+	st.HWMaxFreqKHz, err = readOptionalInt(filepath.Join(freqDir, "cpuinfo_max_freq"))
+	errs = append(errs, err)
+	if err := errors.Join(errs...); err != nil {
+		return CPUState{}, fmt.Errorf("cpu %d: %w", cpu, err)
+	}
+
+	st.IdleStates, err = readIdleStates(filepath.Join(cpuDir, "cpuidle"))
+	if err != nil {
+		return CPUState{}, fmt.Errorf("cpu %d: %w", cpu, err)
+	}
+	env.Log.V(4).Info("cpu power state", "cpu", cpu, "governor", st.Governor, "epp", st.EnergyPerformancePreference, "idleStates", len(st.IdleStates))
+	return st, nil
+}
+
+func readIdleStates(idleDir string) ([]IdleState, error) {
+	entries, err := os.ReadDir(idleDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []int
+	for _, entry := range entries {
+		idStr, ok := strings.CutPrefix(entry.Name(), "state")
+		if !ok {
+			continue
+		}
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	// lexical order would put state10 before state2
+	slices.Sort(ids)
+
+	var states []IdleState
+	for _, id := range ids {
+		stateDir := filepath.Join(idleDir, "state"+strconv.Itoa(id))
+		var errs []error
+		name, err := readOptionalString(filepath.Join(stateDir, "name"))
+		errs = append(errs, err)
+		latency, err := readOptionalInt(filepath.Join(stateDir, "latency"))
+		errs = append(errs, err)
+		disabled, err := readOptionalInt(filepath.Join(stateDir, "disable"))
+		errs = append(errs, err)
+		if err := errors.Join(errs...); err != nil {
+			return nil, err
+		}
+		states = append(states, IdleState{Name: name, LatencyUS: latency, Disabled: disabled != 0})
+	}
+	return states, nil
+}
+
+func readOptionalString(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func readOptionalInt(path string) (int64, error) {
+	value, err := readOptionalString(path)
+	if err != nil || value == "" {
+		return 0, err
+	}
+	num, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s: %w", value, path, err)
+	}
+	return num, nil
+}
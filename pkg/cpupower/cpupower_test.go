@@ -0,0 +1,204 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cpupower
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+func TestRead(t *testing.T) {
+	sysDir := t.TempDir()
+	env := &environ.Environ{
+		Root: environ.FS{Sys: sysDir},
+		Log:  environ.DefaultLog(),
+	}
+
+	cpuDir := filepath.Join(sysDir, "devices", "system", "cpu")
+	for _, cpu := range []int{2, 3} {
+		base := filepath.Join(cpuDir, "cpu"+strconv.Itoa(cpu))
+		mustWriteFile(t, filepath.Join(base, "cpufreq", "scaling_driver"), "intel_pstate\n")
+		mustWriteFile(t, filepath.Join(base, "cpufreq", "scaling_governor"), "performance\n")
+		mustWriteFile(t, filepath.Join(base, "cpufreq", "energy_performance_preference"), "performance\n")
+		mustWriteFile(t, filepath.Join(base, "cpufreq", "scaling_min_freq"), "800000\n")
+		mustWriteFile(t, filepath.Join(base, "cpufreq", "scaling_max_freq"), "3500000\n")
+		mustWriteFile(t, filepath.Join(base, "cpufreq", "cpuinfo_min_freq"), "800000\n")
+		mustWriteFile(t, filepath.Join(base, "cpufreq", "cpuinfo_max_freq"), "3500000\n")
+		for id, idle := range []IdleState{{"POLL", 0, false}, {"C1", 2, false}, {"C6", 170, cpu == 2}} {
+			stateDir := filepath.Join(base, "cpuidle", "state"+strconv.Itoa(id))
+			disable := "0\n"
+			if idle.Disabled {
+				disable = "1\n"
+			}
+			mustWriteFile(t, filepath.Join(stateDir, "name"), idle.Name+"\n")
+			mustWriteFile(t, filepath.Join(stateDir, "latency"), strconv.FormatInt(idle.LatencyUS, 10)+"\n")
+			mustWriteFile(t, filepath.Join(stateDir, "disable"), disable)
+		}
+	}
+	// no cpufreq, no cpuidle, like on most VMs
+	if err := os.MkdirAll(filepath.Join(cpuDir, "cpu4"), os.ModePerm); err != nil {
+		t.Fatalf("cannot create cpu dir: %v", err)
+	}
+
+	got, err := Read(env, cpuset.New(2, 3, 4))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []CPUState{
+		{
+			CPU: 2, Driver: "intel_pstate", Governor: "performance", EnergyPerformancePreference: "performance",
+			MinFreqKHz: 800000, MaxFreqKHz: 3500000, HWMinFreqKHz: 800000, HWMaxFreqKHz: 3500000,
+			IdleStates: []IdleState{{"POLL", 0, false}, {"C1", 2, false}, {"C6", 170, true}},
+		},
+		{
+			CPU: 3, Driver: "intel_pstate", Governor: "performance", EnergyPerformancePreference: "performance",
+			MinFreqKHz: 800000, MaxFreqKHz: 3500000, HWMinFreqKHz: 800000, HWMaxFreqKHz: 3500000,
+			IdleStates: []IdleState{{"POLL", 0, false}, {"C1", 2, false}, {"C6", 170, false}},
+		},
+		{
+			CPU: 4,
+		},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected states (-want +got):\n%s", diff)
+	}
+
+	if _, err := Read(env, cpuset.New(5)); err == nil {
+		t.Errorf("expected error for missing CPU, got nil")
+	}
+}
+
+func TestHeterogeneous(t *testing.T) {
+	testCases := []struct {
+		name     string
+		states   []CPUState
+		expected map[string]map[string]cpuset.CPUSet
+	}{
+		{
+			name: "governor and idle states",
+			states: []CPUState{
+				{CPU: 2, Governor: "performance", MaxFreqKHz: 3500000, IdleStates: []IdleState{{Name: "C1"}, {Name: "C6", Disabled: true}}},
+				{CPU: 3, Governor: "performance", MaxFreqKHz: 3500000, IdleStates: []IdleState{{Name: "C1"}, {Name: "C6"}}},
+				{CPU: 4, Governor: "powersave", MaxFreqKHz: 3500000, IdleStates: []IdleState{{Name: "C1"}, {Name: "C6"}}},
+			},
+			expected: map[string]map[string]cpuset.CPUSet{
+				AttrGovernor: {
+					"performance": cpuset.New(2, 3),
+					"powersave":   cpuset.New(4),
+				},
+				AttrEnabledIdleStates: {
+					"C1":    cpuset.New(2),
+					"C1,C6": cpuset.New(3, 4),
+				},
+			},
+		},
+		{
+			// P-cores and E-cores have different hardware limits, but all are uncapped
+			name: "hybrid uncapped",
+			states: []CPUState{
+				{CPU: 0, MinFreqKHz: 400000, MaxFreqKHz: 5400000, HWMinFreqKHz: 400000, HWMaxFreqKHz: 5400000},
+				{CPU: 1, MinFreqKHz: 400000, MaxFreqKHz: 5600000, HWMinFreqKHz: 400000, HWMaxFreqKHz: 5600000},
+				{CPU: 16, MinFreqKHz: 800000, MaxFreqKHz: 4200000, HWMinFreqKHz: 800000, HWMaxFreqKHz: 4200000},
+			},
+			expected: map[string]map[string]cpuset.CPUSet{},
+		},
+		{
+			name: "hybrid capped",
+			states: []CPUState{
+				{CPU: 0, MinFreqKHz: 400000, MaxFreqKHz: 5400000, HWMinFreqKHz: 400000, HWMaxFreqKHz: 5400000},
+				{CPU: 1, MinFreqKHz: 1200000, MaxFreqKHz: 3000000, HWMinFreqKHz: 400000, HWMaxFreqKHz: 5600000},
+				{CPU: 16, MinFreqKHz: 800000, MaxFreqKHz: 4200000, HWMinFreqKHz: 800000, HWMaxFreqKHz: 4200000},
+			},
+			expected: map[string]map[string]cpuset.CPUSet{
+				AttrMinFreq: {
+					FreqUnrestricted: cpuset.New(0, 16),
+					"1200000":        cpuset.New(1),
+				},
+				AttrMaxFreq: {
+					FreqUncapped: cpuset.New(0, 16),
+					"3000000":    cpuset.New(1),
+				},
+			},
+		},
+		{
+			name: "hardware limits unknown",
+			states: []CPUState{
+				{CPU: 0, MaxFreqKHz: 3000000},
+				{CPU: 1, MaxFreqKHz: 3500000},
+			},
+			expected: map[string]map[string]cpuset.CPUSet{
+				AttrMaxFreq: {
+					"3000000": cpuset.New(0),
+					"3500000": cpuset.New(1),
+				},
+			},
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Heterogeneous(tt.states)
+			if diff := cmp.Diff(tt.expected, got, cmp.Comparer(func(a, b cpuset.CPUSet) bool { return a.Equals(b) })); diff != "" {
+				t.Errorf("unexpected heterogeneity (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDeviations(t *testing.T) {
+	st := CPUState{
+		CPU:                         7,
+		Governor:                    "powersave",
+		EnergyPerformancePreference: "balance_performance",
+		MaxFreqKHz:                  2000000,
+		HWMaxFreqKHz:                3500000,
+		IdleStates:                  []IdleState{{"POLL", 0, false}, {"C1", 2, false}, {"C6", 170, false}, {"C10", 890, true}},
+	}
+	testCases := []struct {
+		name     string
+		prof     Profile
+		expected []Deviation
+	}{
+		{
+			name: "no checks",
+			prof: Profile{MaxIdleLatencyUS: -1},
+		},
+		{
+			name: "all checks",
+			prof: Profile{Governor: "performance", EnergyPerformancePreference: "performance", UncappedMaxFreq: true, MaxIdleLatencyUS: 10},
+			expected: []Deviation{
+				{CPU: 7, Attribute: AttrGovernor, Expected: "performance", Actual: "powersave"},
+				{CPU: 7, Attribute: AttrEnergyPerformancePreference, Expected: "performance", Actual: "balance_performance"},
+				{CPU: 7, Attribute: AttrMaxFreq, Expected: "3500000", Actual: "2000000"},
+				{CPU: 7, Attribute: AttrEnabledIdleStates, Expected: "latency<=10us", Actual: "C6=170us"},
+			},
+		},
+		{
+			name: "matching profile",
+			prof: Profile{Governor: "powersave", MaxIdleLatencyUS: 200},
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.expected, tt.prof.Deviations(st)); diff != "" {
+				t.Errorf("unexpected deviations (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func mustWriteFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		t.Fatalf("cannot create dir for %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("cannot write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// IOMMUGroupDevice is a PCI device belonging to an IOMMU group.
+type IOMMUGroupDevice struct {
+	PCIEDevice
+	Driver   string
+	NUMANode int // -1 if unknown
+	PCIERoot string
+}
+
+// IsVFIOBound returns true if the device is bound to vfio-pci or to one of its vendor variants (e.g. mlx5_vfio_pci).
+func (dev IOMMUGroupDevice) IsVFIOBound() bool {
+	return strings.Contains(dev.Driver, "vfio")
+}
+
+// isVFIOCompatible tells if the device doesn't prevent the group to be used through vfio.
+// The kernel allows devices bound to pci-stub, and the bridges bound to pcieport, besides the unbound devices.
+func (dev IOMMUGroupDevice) isVFIOCompatible() bool {
+	return dev.Driver == "" || dev.Driver == "pci-stub" || dev.Driver == "pcieport" || dev.IsVFIOBound()
+}
+
+// IOMMUGroup is the set of devices the IOMMU can't isolate from each other.
+// vfio assigns whole groups, so all the devices in a group must be vfio compatible for the group to be usable.
+type IOMMUGroup struct {
+	ID      string
+	Devices []IOMMUGroupDevice
+}
+
+// Viable returns true if the group can be used through vfio.
+func (grp IOMMUGroup) Viable() bool {
+	for _, dev := range grp.Devices {
+		if !dev.isVFIOCompatible() {
+			return false
+		}
+	}
+	return true
+}
+
+// IOMMUGroupsFromFS returns all the IOMMU groups with at least one PCI device, keyed by group ID.
+// Returns an empty map if the IOMMU is disabled.
+func IOMMUGroupsFromFS(lh logr.Logger, sysfs SysFS) (map[string]IOMMUGroup, error) {
+	groups := make(map[string]IOMMUGroup)
+	err := ScanPCIDevices(sysfs, func(pciDev PCIEDevice) error {
+		groupID, err := DeviceIOMMUGroup(sysfs, pciDev.Address)
+		if err != nil {
+			lh.V(4).Info("cannot find device IOMMU group, skipping", "address", pciDev.Address, "error", err)
+			return nil
+		}
+		dev := IOMMUGroupDevice{
+			PCIEDevice: pciDev,
+			Driver:     readPCIEDeviceDriver(sysfs, pciDev),
+			NUMANode:   readPCIEDeviceNUMANode(lh, sysfs, pciDev),
+		}
+		devPath, err := PCIEDevicePath(sysfs, pciDev.Address)
+		if err == nil {
+			dev.PCIERoot = devPath[0]
+		}
+		grp := groups[groupID]
+		grp.ID = groupID
+		grp.Devices = append(grp.Devices, dev)
+		groups[groupID] = grp
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, groupID := range slices.Collect(maps.Keys(groups)) {
+		grp := groups[groupID]
+		slices.SortFunc(grp.Devices, func(a, b IOMMUGroupDevice) int {
+			return strings.Compare(a.Address, b.Address)
+		})
+		groups[groupID] = grp
+		lh.V(4).Info("IOMMU group", "id", groupID, "devices", len(grp.Devices), "viable", grp.Viable())
+	}
+	return groups, nil
+}
+
+// DeviceIOMMUGroup returns the IOMMU group ID of the given PCI device.
+func DeviceIOMMUGroup(sysfs fs.ReadLinkFS, pciAddress string) (string, error) {
+	target, err := fs.ReadLink(sysfs, filepath.Join("bus", "pci", "devices", pciAddress, "iommu_group"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the IOMMU group of %s: %w", pciAddress, err)
+	}
+	return filepath.Base(target), nil
+}
@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIOMMUGroupsFromFS(t *testing.T) {
+	withGroups := func(groups map[string]string, drivers map[string]string) fstest.MapFS {
+		sysfs := makeBasePCSysFSFixture()
+		for devPath, groupID := range groups {
+			sysfs[devPath+"/iommu_group"] = &fstest.MapFile{
+				Data: []byte("../../../kernel/iommu_groups/" + groupID),
+				Mode: fs.ModeSymlink,
+			}
+		}
+		for devPath, driver := range drivers {
+			sysfs[devPath+"/driver"] = &fstest.MapFile{
+				Data: []byte("../../../bus/pci/drivers/" + driver),
+				Mode: fs.ModeSymlink,
+			}
+		}
+		return sysfs
+	}
+
+	tests := []struct {
+		name     string
+		fs       fstest.MapFS
+		expected map[string]IOMMUGroup
+	}{
+		{
+			name:     "IOMMU disabled",
+			fs:       makeBasePCSysFSFixture(),
+			expected: map[string]IOMMUGroup{},
+		},
+		{
+			name: "groups with vfio and kernel drivers",
+			fs: withGroups(
+				map[string]string{
+					"devices/pci0000:00/0000:00:14.0": "7",
+					"devices/pci0000:00/0000:00:14.2": "7",
+					"devices/pci0000:00/0000:00:1f.0": "12",
+					"devices/pci0000:00/0000:00:1f.3": "12",
+					"devices/pci0000:00/0000:00:1f.4": "12",
+				},
+				map[string]string{
+					"devices/pci0000:00/0000:00:14.0": "vfio-pci",
+					"devices/pci0000:00/0000:00:1f.0": "vfio-pci",
+					"devices/pci0000:00/0000:00:1f.3": "snd_hda_intel",
+				},
+			),
+			expected: map[string]IOMMUGroup{
+				"7": {
+					ID: "7",
+					Devices: []IOMMUGroupDevice{
+						{PCIEDevice: PCIEDevice{Address: "0000:00:14.0", ClassID: "0c", SubclassID: "03", ProgIF: "30"}, Driver: "vfio-pci", NUMANode: -1, PCIERoot: "pci0000:00"},
+						{PCIEDevice: PCIEDevice{Address: "0000:00:14.2", ClassID: "05", SubclassID: "00", ProgIF: "00"}, NUMANode: -1, PCIERoot: "pci0000:00"},
+					},
+				},
+				"12": {
+					ID: "12",
+					Devices: []IOMMUGroupDevice{
+						{PCIEDevice: PCIEDevice{Address: "0000:00:1f.0", ClassID: "06", SubclassID: "01", ProgIF: "00"}, Driver: "vfio-pci", NUMANode: -1, PCIERoot: "pci0000:00"},
+						{PCIEDevice: PCIEDevice{Address: "0000:00:1f.3", ClassID: "04", SubclassID: "03", ProgIF: "80"}, Driver: "snd_hda_intel", NUMANode: -1, PCIERoot: "pci0000:00"},
+						{PCIEDevice: PCIEDevice{Address: "0000:00:1f.4", ClassID: "0c", SubclassID: "05", ProgIF: "00"}, NUMANode: -1, PCIERoot: "pci0000:00"},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IOMMUGroupsFromFS(testr.New(t), tt.fs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, got); diff != "" {
+				t.Errorf("IOMMU groups mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIOMMUGroupViable(t *testing.T) {
+	tests := []struct {
+		name     string
+		drivers  []string
+		expected bool
+	}{
+		{
+			name:     "all bound to vfio",
+			drivers:  []string{"vfio-pci", "mlx5_vfio_pci"},
+			expected: true,
+		},
+		{
+			name:     "unbound, stub and bridges are allowed",
+			drivers:  []string{"vfio-pci", "", "pci-stub", "pcieport"},
+			expected: true,
+		},
+		{
+			name:     "device bound to a kernel driver",
+			drivers:  []string{"vfio-pci", "ice"},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grp := IOMMUGroup{ID: "1"}
+			for _, driver := range tt.drivers {
+				grp.Devices = append(grp.Devices, IOMMUGroupDevice{Driver: driver})
+			}
+			if got := grp.Viable(); got != tt.expected {
+				t.Errorf("expected viable=%v got %v", tt.expected, got)
+			}
+		})
+	}
+}
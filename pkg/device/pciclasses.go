@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"bufio"
+	_ "embed"
+	"strconv"
+	"strings"
+)
+
+//go:embed pciclasses.ids
+var pciClassesData string
+
+// pciClassNames maps the class ("02"), class+subclass ("0200") and class+subclass+prog-if ("010802")
+// codes to their human readable names.
+var pciClassNames = parsePCIClasses(pciClassesData)
+
+// parsePCIClasses parses the class section of the pci.ids format. Malformed lines are skipped.
+func parsePCIClasses(data string) map[string]string {
+	names := make(map[string]string)
+	var classID, subclassID string
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		depth := len(line) - len(strings.TrimLeft(line, "\t"))
+		id, name, ok := strings.Cut(strings.TrimLeft(line, "\t"), " ")
+		if depth == 0 {
+			// C class  class_name
+			if id != "C" {
+				// not a class, e.g. a vendor in the full pci.ids database
+				classID, subclassID = "", ""
+				continue
+			}
+			id, name, ok = strings.Cut(strings.TrimSpace(name), " ")
+		}
+		if !ok || !isPCIClassCode(id) {
+			if depth == 0 {
+				// don't attach the following subclasses to the previous class
+				classID, subclassID = "", ""
+			}
+			continue
+		}
+		name = strings.TrimSpace(name)
+		switch depth {
+		case 0:
+			classID, subclassID = id, ""
+			names[classID] = name
+		case 1:
+			if classID == "" {
+				continue
+			}
+			subclassID = id
+			names[classID+subclassID] = name
+		case 2:
+			if subclassID == "" {
+				continue
+			}
+			names[classID+subclassID+id] = name
+		}
+	}
+	return names
+}
+
+func isPCIClassCode(id string) bool {
+	if len(id) != 2 {
+		return false
+	}
+	_, err := strconv.ParseUint(id, 16, 8)
+	return err == nil
+}
+
+// ClassName returns the name of the device base class, e.g. "Mass storage controller".
+func (pciDev PCIEDevice) ClassName() string {
+	return pciClassNames[pciDev.ClassID]
+}
+
+// SubclassName returns the name of the device subclass, e.g. "Non-Volatile memory controller".
+func (pciDev PCIEDevice) SubclassName() string {
+	return pciClassNames[pciDev.ClassID+pciDev.SubclassID]
+}
+
+// ProgIFName returns the name of the device programming interface, e.g. "NVM Express".
+// Most devices don't have a named programming interface.
+func (pciDev PCIEDevice) ProgIFName() string {
+	return pciClassNames[pciDev.ClassID+pciDev.SubclassID+pciDev.ProgIF]
+}
+
+// Description returns the most specific name we know for the device class,
+// in the same format lspci uses, e.g. "Non-Volatile memory controller (NVM Express)".
+func (pciDev PCIEDevice) Description() string {
+	desc := pciDev.SubclassName()
+	if desc == "" {
+		return pciDev.ClassName()
+	}
+	if progIF := pciDev.ProgIFName(); progIF != "" {
+		desc += " (" + progIF + ")"
+	}
+	return desc
+}
@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"testing"
+)
+
+func TestParsePCIClasses(t *testing.T) {
+	data := `# comment
+C 01  Mass storage controller
+	08  Non-Volatile memory controller
+		02  NVM Express
+C zz  Malformed
+	00  Orphaned subclass
+		00  Orphaned prog-if
+C 06  Bridge
+	04  PCI bridge
+`
+	names := parsePCIClasses(data)
+	expected := map[string]string{
+		"01":     "Mass storage controller",
+		"0108":   "Non-Volatile memory controller",
+		"010802": "NVM Express",
+		"06":     "Bridge",
+		"0604":   "PCI bridge",
+	}
+	if len(names) != len(expected) {
+		t.Errorf("expected %d names, got %d: %v", len(expected), len(names), names)
+	}
+	for id, name := range expected {
+		if names[id] != name {
+			t.Errorf("class %q: expected %q got %q", id, name, names[id])
+		}
+	}
+}
+
+func TestPCIEDeviceNames(t *testing.T) {
+	tests := []struct {
+		name         string
+		dev          PCIEDevice
+		className    string
+		subclassName string
+		progIFName   string
+		description  string
+	}{
+		{
+			name:         "NVMe",
+			dev:          PCIEDevice{ClassID: "01", SubclassID: "08", ProgIF: "02"},
+			className:    "Mass storage controller",
+			subclassName: "Non-Volatile memory controller",
+			progIFName:   "NVM Express",
+			description:  "Non-Volatile memory controller (NVM Express)",
+		},
+		{
+			name:         "ethernet, no named prog-if",
+			dev:          PCIEDevice{ClassID: "02", SubclassID: "00", ProgIF: "00"},
+			className:    "Network controller",
+			subclassName: "Ethernet controller",
+			description:  "Ethernet controller",
+		},
+		{
+			name:        "unknown subclass",
+			dev:         PCIEDevice{ClassID: "12", SubclassID: "42", ProgIF: "00"},
+			className:   "Processing accelerators",
+			description: "Processing accelerators",
+		},
+		{
+			name: "unknown class",
+			dev:  PCIEDevice{ClassID: "42", SubclassID: "00", ProgIF: "00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.dev.ClassName(); got != tt.className {
+				t.Errorf("class name: expected %q got %q", tt.className, got)
+			}
+			if got := tt.dev.SubclassName(); got != tt.subclassName {
+				t.Errorf("subclass name: expected %q got %q", tt.subclassName, got)
+			}
+			if got := tt.dev.ProgIFName(); got != tt.progIFName {
+				t.Errorf("prog-if name: expected %q got %q", tt.progIFName, got)
+			}
+			if got := tt.dev.Description(); got != tt.description {
+				t.Errorf("description: expected %q got %q", tt.description, got)
+			}
+		})
+	}
+}
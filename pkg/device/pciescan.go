@@ -17,6 +17,7 @@ limitations under the License.
 package device
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
 	"maps"
@@ -66,6 +67,18 @@ func FindOrphanedCPUs(domains []PCIEDomain, allCPUs cpuset.CPUSet) cpuset.CPUSet
 	return orphanedCPUs
 }
 
+// FindLocalDomains returns the domains whose local CPUs include at least one of the given CPUs.
+func FindLocalDomains(domains []PCIEDomain, cpus cpuset.CPUSet) []PCIEDomain {
+	var localDomains []PCIEDomain
+	for _, dom := range domains {
+		if dom.LocalCPUs.Intersection(cpus).IsEmpty() {
+			continue
+		}
+		localDomains = append(localDomains, dom)
+	}
+	return localDomains
+}
+
 type PCIEDomain struct {
 	PCIERootAttr deviceattribute.DeviceAttribute
 	LocalCPUs    cpuset.CPUSet
@@ -89,6 +102,11 @@ type PCIEDevice struct {
 	Address    string
 	ClassID    string
 	SubclassID string
+	ProgIF     string
+	// VendorID and DeviceID are lowercase hex strings without the 0x prefix, e.g. "8086".
+	// Empty if the kernel doesn't expose them.
+	VendorID string
+	DeviceID string
 }
 
 func (pciDev PCIEDevice) BaseDirPath() string {
@@ -104,7 +122,8 @@ type SysFS interface {
 	fs.ReadDirFS
 }
 
-func ScanPCIDevices(sysfs SysFS, processDevice func(PCIEDevice) error) error {
+// ScanPCIDevices calls processDevice for all the PCI devices matching all the given predicates.
+func ScanPCIDevices(sysfs SysFS, processDevice func(PCIEDevice) error, predicates ...PCIEDevicePredicate) error {
 	pciDevicesDir := filepath.Join("bus", "pci", "devices")
 	entries, err := fs.ReadDir(sysfs, pciDevicesDir)
 	if err != nil {
@@ -130,6 +149,20 @@ func ScanPCIDevices(sysfs SysFS, processDevice func(PCIEDevice) error) error {
 			Address:    pciAddress,
 			ClassID:    pciClassInfo[2:4],
 			SubclassID: pciClassInfo[4:6],
+			ProgIF:     pciClassInfo[6:8],
+		}
+
+		pciDev.VendorID, err = readPCIID(sysfs, filepath.Join(pciDevicesDir, pciAddress, "vendor"))
+		if err != nil {
+			return err
+		}
+		pciDev.DeviceID, err = readPCIID(sysfs, filepath.Join(pciDevicesDir, pciAddress, "device"))
+		if err != nil {
+			return err
+		}
+
+		if !matchesAll(sysfs, pciDev, predicates) {
+			continue
 		}
 
 		err = processDevice(pciDev)
@@ -211,9 +244,32 @@ func PCIEDomainsFromFS(lh logr.Logger, sysfs SysFS) ([]PCIEDomain, error) {
 // Reference: https://pci-ids.ucw.cz/
 func isPCIBridge(dev PCIEDevice) bool {
 	// class 06: Bridge
+	if dev.ClassID != "06" {
+		return false
+	}
 	// subclass 04: PCI bridge
-	// TODO: what about subclasses 09 (semi-transparent PCI bridge) and 0a (Infiniband to PCI)?
-	return dev.ClassID == "06" && dev.SubclassID == "04"
+	// subclass 09: Semi-transparent PCI-to-PCI bridge
+	// subclass 0a: InfiniBand to PCI host bridge
+	return dev.SubclassID == "04" || dev.SubclassID == "09" || dev.SubclassID == "0a"
+}
+
+// readPCIID reads a vendor or device ID, in the "0xVVVV" format the kernel uses.
+func readPCIID(sysfs fs.FS, path string) (string, error) {
+	data, err := fs.ReadFile(sysfs, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	pciID := strings.ToLower(strings.TrimSpace(string(data)))
+	if len(pciID) != 6 || !strings.HasPrefix(pciID, "0x") { // format: "0xVVVV"
+		return "", fmt.Errorf("invalid PCI ID data: %q", pciID)
+	}
+	if _, err := strconv.ParseUint(pciID[2:], 16, 16); err != nil {
+		return "", fmt.Errorf("invalid PCI ID data: %q: %w", pciID, err)
+	}
+	return pciID[2:], nil
 }
 
 // isValidPCIAddress checks if s matches the format
@@ -159,6 +159,56 @@ func TestFindOrphanedCPUs(t *testing.T) {
 	}
 }
 
+func TestFindLocalDomains(t *testing.T) {
+	domains := []PCIEDomain{
+		{
+			PCIERootAttr: newDeviceAttr("pci0000:00"),
+			LocalCPUs:    mustParseCPUSet(t, "0-7"),
+		},
+		{
+			PCIERootAttr: newDeviceAttr("pci0000:80"),
+			LocalCPUs:    mustParseCPUSet(t, "8-14"),
+		},
+	}
+
+	tests := []struct {
+		name          string
+		cpus          cpuset.CPUSet
+		expectedRoots []string
+	}{
+		{
+			name: "no cpus",
+			cpus: cpuset.New(),
+		},
+		{
+			name:          "single domain",
+			cpus:          cpuset.New(2, 3),
+			expectedRoots: []string{"pci0000:00"},
+		},
+		{
+			name:          "spanning domains",
+			cpus:          cpuset.New(7, 8),
+			expectedRoots: []string{"pci0000:00", "pci0000:80"},
+		},
+		{
+			name: "orphaned cpus only",
+			cpus: cpuset.New(15),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotRoots []string
+			for _, dom := range FindLocalDomains(domains, tt.cpus) {
+				gotRoots = append(gotRoots, dom.Root())
+			}
+			if diff := cmp.Diff(tt.expectedRoots, gotRoots); diff != "" {
+				t.Errorf("local domains mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestPCIEDomainsFromFS(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -207,6 +257,48 @@ func TestPCIEDomainsFromFS(t *testing.T) {
 	}
 }
 
+func TestIsPCIBridge(t *testing.T) {
+	tests := []struct {
+		name     string
+		dev      PCIEDevice
+		expected bool
+	}{
+		{
+			name:     "PCI bridge",
+			dev:      PCIEDevice{ClassID: "06", SubclassID: "04", ProgIF: "00"},
+			expected: true,
+		},
+		{
+			name:     "semi-transparent PCI-to-PCI bridge",
+			dev:      PCIEDevice{ClassID: "06", SubclassID: "09", ProgIF: "40"},
+			expected: true,
+		},
+		{
+			name:     "InfiniBand to PCI host bridge",
+			dev:      PCIEDevice{ClassID: "06", SubclassID: "0a", ProgIF: "00"},
+			expected: true,
+		},
+		{
+			name:     "host bridge",
+			dev:      PCIEDevice{ClassID: "06", SubclassID: "00", ProgIF: "00"},
+			expected: false,
+		},
+		{
+			name:     "not a bridge",
+			dev:      PCIEDevice{ClassID: "02", SubclassID: "04", ProgIF: "00"},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPCIBridge(tt.dev); got != tt.expected {
+				t.Errorf("expected %v got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
 func newDeviceAttr(pcidom string) deviceattribute.DeviceAttribute {
 	return deviceattribute.DeviceAttribute{
 		Name:  deviceattribute.StandardDeviceAttributePCIeRoot,
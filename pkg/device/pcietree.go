@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"k8s.io/utils/cpuset"
+)
+
+// PCIENode is a PCI device placed in the hierarchy exposed by the kernel under /sys/devices.
+type PCIENode struct {
+	PCIEDevice
+	Driver    string
+	NUMANode  int // -1 if unknown
+	LocalCPUs cpuset.CPUSet
+	Children  []*PCIENode
+}
+
+// PCIETree is the hierarchy of PCI devices (bridges and endpoints) hanging off a PCIe root complex.
+type PCIETree struct {
+	Root     string
+	Children []*PCIENode
+}
+
+func PCIETreesFromFS(lh logr.Logger, sysfs SysFS) ([]PCIETree, error) {
+	nodes := make(map[string]*PCIENode)
+	parents := make(map[string]string)
+	roots := make(map[string]string)
+
+	err := ScanPCIDevices(sysfs, func(pciDev PCIEDevice) error {
+		devPath, err := PCIEDevicePath(sysfs, pciDev.Address)
+		if err != nil {
+			return err
+		}
+		node := &PCIENode{
+			PCIEDevice: pciDev,
+			Driver:     readPCIEDeviceDriver(sysfs, pciDev),
+			NUMANode:   readPCIEDeviceNUMANode(lh, sysfs, pciDev),
+			LocalCPUs:  readPCIEDeviceLocalCPUs(lh, sysfs, pciDev),
+		}
+		nodes[pciDev.Address] = node
+		roots[pciDev.Address] = devPath[0]
+		if len(devPath) > 2 {
+			parents[pciDev.Address] = devPath[len(devPath)-2]
+		}
+		lh.V(4).Info("PCIE device", "address", pciDev.Address, "path", strings.Join(devPath, "/"))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	trees := make(map[string]*PCIETree)
+	for _, addr := range slices.Sorted(maps.Keys(nodes)) {
+		node := nodes[addr]
+		// the parent may not be a PCI device we know about (e.g. a platform device in between),
+		// in this case we just attach the device directly to its root complex.
+		if parent, ok := nodes[parents[addr]]; ok {
+			parent.Children = append(parent.Children, node)
+			continue
+		}
+		root := roots[addr]
+		tree, ok := trees[root]
+		if !ok {
+			tree = &PCIETree{Root: root}
+			trees[root] = tree
+		}
+		tree.Children = append(tree.Children, node)
+	}
+
+	var res []PCIETree
+	for _, tree := range trees {
+		res = append(res, *tree)
+	}
+	slices.SortFunc(res, func(a, b PCIETree) int {
+		return strings.Compare(a.Root, b.Root)
+	})
+	return res, nil
+}
+
+// PCIEDevicePath returns the chain of sysfs device names from the PCIe root complex
+// down to the given device, e.g. [pci0000:00 0000:00:07.2 0000:50:00.0].
+// This follows the same approach of deviceattribute.GetPCIeRootAttributeByPCIBusID,
+// but keeps all the intermediate devices instead of just the root complex.
+func PCIEDevicePath(sysfs fs.ReadLinkFS, pciAddress string) ([]string, error) {
+	sysBusPath := filepath.Join("bus", "pci", "devices", pciAddress)
+	target, err := fs.ReadLink(sysfs, sysBusPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read symlink for PCI Bus ID %s: %w", sysBusPath, err)
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Clean(filepath.Join(filepath.Dir(sysBusPath), target))
+	}
+	devicePathPrefix := filepath.Join("devices", "pci")
+	if !strings.HasPrefix(target, devicePathPrefix) || filepath.Base(target) != pciAddress {
+		return nil, fmt.Errorf("symlink target for PCI Bus ID %s is invalid: %s", pciAddress, target)
+	}
+	target = strings.TrimPrefix(target, "devices"+string(filepath.Separator))
+	return strings.Split(target, string(filepath.Separator)), nil
+}
+
+func readPCIEDeviceDriver(sysfs fs.ReadLinkFS, pciDev PCIEDevice) string {
+	target, err := fs.ReadLink(sysfs, filepath.Join(pciDev.SysfsPath(), "driver"))
+	if err != nil {
+		// unbound devices are pretty common, so this is not worth logging
+		return ""
+	}
+	return filepath.Base(target)
+}
+
+func readPCIEDeviceNUMANode(lh logr.Logger, sysfs fs.FS, pciDev PCIEDevice) int {
+	data, err := fs.ReadFile(sysfs, filepath.Join(pciDev.SysfsPath(), "numa_node"))
+	if err != nil {
+		lh.V(4).Info("cannot read device NUMA node", "address", pciDev.Address, "error", err)
+		return -1
+	}
+	numaNode, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		lh.V(2).Info("cannot parse device NUMA node", "address", pciDev.Address, "error", err)
+		return -1
+	}
+	return numaNode
+}
+
+func readPCIEDeviceLocalCPUs(lh logr.Logger, sysfs fs.FS, pciDev PCIEDevice) cpuset.CPUSet {
+	data, err := fs.ReadFile(sysfs, filepath.Join(pciDev.SysfsPath(), "local_cpulist"))
+	if err != nil {
+		lh.V(4).Info("cannot read device local CPUs", "address", pciDev.Address, "error", err)
+		return cpuset.New()
+	}
+	cpus, err := cpuset.Parse(strings.TrimSpace(string(data)))
+	if err != nil {
+		lh.V(2).Info("cannot parse device local CPUs", "address", pciDev.Address, "error", err)
+		return cpuset.New()
+	}
+	return cpus
+}
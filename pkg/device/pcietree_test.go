@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/utils/cpuset"
+)
+
+func TestPCIETreesFromFS(t *testing.T) {
+	withDriver := makeBasePCSysFSFixture()
+	withDriver["devices/pci0000:00/0000:00:06.0/0000:04:00.0/driver"] = &fstest.MapFile{
+		Data: []byte("../../../../bus/pci/drivers/nvme"),
+		Mode: fs.ModeSymlink,
+	}
+	withDriver["devices/pci0000:00/0000:00:06.0/0000:04:00.0/vendor"] = &fstest.MapFile{
+		Data: []byte("0x144d\n"),
+	}
+	withDriver["devices/pci0000:00/0000:00:06.0/0000:04:00.0/device"] = &fstest.MapFile{
+		Data: []byte("0xA80A\n"),
+	}
+
+	tests := []struct {
+		name        string
+		fs          fstest.MapFS
+		wantParents map[string]string // address -> parent address or root
+		wantDevice  *PCIENode         // optional spot check, children ignored
+		wantErr     bool
+	}{
+		{
+			name: "empty FS",
+			fs:   mapFSFromDevices([]PCIEDevice{}),
+		},
+		{
+			name: "broken device symlink",
+			fs: fstest.MapFS{
+				"bus/pci/devices/0000:00:00.0": &fstest.MapFile{
+					Data: []byte("../../../devices/platform/0000:00:00.0"),
+					Mode: fs.ModeSymlink,
+				},
+				"devices/platform/0000:00:00.0/class": &fstest.MapFile{
+					Data: []byte("0x060000\n"),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "laptop single PCIe root",
+			fs:   withDriver,
+			wantParents: map[string]string{
+				"0000:00:00.0": "pci0000:00",
+				"0000:00:02.0": "pci0000:00",
+				"0000:00:04.0": "pci0000:00",
+				"0000:00:06.0": "pci0000:00",
+				"0000:00:07.0": "pci0000:00",
+				"0000:00:07.2": "pci0000:00",
+				"0000:00:08.0": "pci0000:00",
+				"0000:00:0d.0": "pci0000:00",
+				"0000:00:0d.2": "pci0000:00",
+				"0000:00:0d.3": "pci0000:00",
+				"0000:00:14.0": "pci0000:00",
+				"0000:00:14.2": "pci0000:00",
+				"0000:00:14.3": "pci0000:00",
+				"0000:00:15.0": "pci0000:00",
+				"0000:00:15.1": "pci0000:00",
+				"0000:00:16.0": "pci0000:00",
+				"0000:00:16.3": "pci0000:00",
+				"0000:00:1f.0": "pci0000:00",
+				"0000:00:1f.3": "pci0000:00",
+				"0000:00:1f.4": "pci0000:00",
+				"0000:00:1f.5": "pci0000:00",
+				"0000:00:1f.6": "pci0000:00",
+				"0000:04:00.0": "0000:00:06.0",
+				"0000:50:00.0": "0000:00:07.2",
+				"0000:51:02.0": "0000:50:00.0",
+				"0000:51:04.0": "0000:50:00.0",
+				"0000:52:00.0": "0000:51:02.0",
+			},
+			wantDevice: &PCIENode{
+				PCIEDevice: PCIEDevice{
+					Address:    "0000:04:00.0",
+					ClassID:    "01",
+					SubclassID: "08",
+					ProgIF:     "02",
+					VendorID:   "144d",
+					DeviceID:   "a80a",
+				},
+				Driver:    "nvme",
+				NUMANode:  -1,
+				LocalCPUs: cpuset.New(0, 1, 2, 3, 4, 5, 6, 7),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PCIETreesFromFS(testr.New(t), tt.fs)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			gotParents := make(map[string]string)
+			gotNodes := make(map[string]*PCIENode)
+			for _, tree := range got {
+				collectPCIENodes(tree.Root, tree.Children, gotParents, gotNodes)
+			}
+			if len(tt.wantParents) == 0 && len(gotParents) == 0 {
+				return
+			}
+			if diff := cmp.Diff(tt.wantParents, gotParents); diff != "" {
+				t.Errorf("PCIE hierarchy mismatch (-want +got):\n%s", diff)
+			}
+			if tt.wantDevice == nil {
+				return
+			}
+			gotDev, ok := gotNodes[tt.wantDevice.Address]
+			if !ok {
+				t.Fatalf("missing device %q", tt.wantDevice.Address)
+			}
+			gotDevCopy := *gotDev
+			gotDevCopy.Children = nil
+			if diff := cmp.Diff(*tt.wantDevice, gotDevCopy, cpuSetComparer); diff != "" {
+				t.Errorf("PCIE device mismatch (-want +got):\n%s", diff)
+			}
+			if name := gotDevCopy.ClassName(); name != "Mass storage controller" {
+				t.Errorf("unexpected class name %q", name)
+			}
+			if desc := gotDevCopy.Description(); desc != "Non-Volatile memory controller (NVM Express)" {
+				t.Errorf("unexpected description %q", desc)
+			}
+		})
+	}
+}
+
+func collectPCIENodes(parent string, nodes []*PCIENode, parents map[string]string, all map[string]*PCIENode) {
+	for _, node := range nodes {
+		parents[node.Address] = parent
+		all[node.Address] = node
+		collectPCIENodes(node.Address, node.Children, parents, all)
+	}
+}
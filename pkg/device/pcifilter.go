@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"io/fs"
+	"slices"
+	"strings"
+)
+
+// PCIEDevicePredicate tells if a PCI device should be processed by ScanPCIDevices.
+type PCIEDevicePredicate func(sysfs fs.ReadLinkFS, pciDev PCIEDevice) bool
+
+// MatchClass matches the devices with any of the given class codes. Each code can be
+// the class ("02"), the class and subclass ("0200") or the full class code including the prog-if ("020000").
+func MatchClass(classIDs ...string) PCIEDevicePredicate {
+	return func(_ fs.ReadLinkFS, pciDev PCIEDevice) bool {
+		fullID := pciDev.ClassID + pciDev.SubclassID + pciDev.ProgIF
+		return slices.ContainsFunc(classIDs, func(classID string) bool {
+			classID = strings.ToLower(classID)
+			return len(classID) >= 2 && len(classID)%2 == 0 && strings.HasPrefix(fullID, classID)
+		})
+	}
+}
+
+// MatchVendor matches the devices with any of the given vendor IDs, with or without the 0x prefix.
+func MatchVendor(vendorIDs ...string) PCIEDevicePredicate {
+	return func(_ fs.ReadLinkFS, pciDev PCIEDevice) bool {
+		return slices.ContainsFunc(vendorIDs, func(vendorID string) bool {
+			return strings.TrimPrefix(strings.ToLower(vendorID), "0x") == pciDev.VendorID
+		})
+	}
+}
+
+// MatchDriver matches the devices bound to any of the given kernel drivers.
+// Use the empty string to match unbound devices.
+func MatchDriver(drivers ...string) PCIEDevicePredicate {
+	return func(sysfs fs.ReadLinkFS, pciDev PCIEDevice) bool {
+		return slices.Contains(drivers, readPCIEDeviceDriver(sysfs, pciDev))
+	}
+}
+
+func matchesAll(sysfs fs.ReadLinkFS, pciDev PCIEDevice, predicates []PCIEDevicePredicate) bool {
+	for _, pred := range predicates {
+		if !pred(sysfs, pciDev) {
+			return false
+		}
+	}
+	return true
+}
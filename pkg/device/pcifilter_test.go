@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScanPCIDevicesPredicates(t *testing.T) {
+	sysfs := makeBasePCSysFSFixture()
+	sysfs["devices/pci0000:00/0000:00:06.0/0000:04:00.0/driver"] = &fstest.MapFile{
+		Data: []byte("../../../../bus/pci/drivers/nvme"),
+		Mode: fs.ModeSymlink,
+	}
+	sysfs["devices/pci0000:00/0000:00:06.0/0000:04:00.0/vendor"] = &fstest.MapFile{
+		Data: []byte("0x144d\n"),
+	}
+	sysfs["devices/pci0000:00/0000:00:1f.6/vendor"] = &fstest.MapFile{
+		Data: []byte("0x8086\n"),
+	}
+	sysfs["devices/pci0000:00/0000:00:14.3/vendor"] = &fstest.MapFile{
+		Data: []byte("0x8086\n"),
+	}
+
+	tests := []struct {
+		name       string
+		predicates []PCIEDevicePredicate
+		expected   []string
+	}{
+		{
+			name:       "by class",
+			predicates: []PCIEDevicePredicate{MatchClass("01")},
+			expected:   []string{"0000:04:00.0"},
+		},
+		{
+			name:       "by full class code",
+			predicates: []PCIEDevicePredicate{MatchClass("010802")},
+			expected:   []string{"0000:04:00.0"},
+		},
+		{
+			name:       "by full class code, wrong prog-if",
+			predicates: []PCIEDevicePredicate{MatchClass("010801")},
+		},
+		{
+			name:       "malformed class code never matches",
+			predicates: []PCIEDevicePredicate{MatchClass("0")},
+		},
+		{
+			name:       "by vendor, with prefix",
+			predicates: []PCIEDevicePredicate{MatchVendor("0x8086")},
+			expected:   []string{"0000:00:14.3", "0000:00:1f.6"},
+		},
+		{
+			name:       "by vendor and class",
+			predicates: []PCIEDevicePredicate{MatchVendor("8086"), MatchClass("0200")},
+			expected:   []string{"0000:00:1f.6"},
+		},
+		{
+			name:       "by driver",
+			predicates: []PCIEDevicePredicate{MatchDriver("nvme", "vfio-pci")},
+			expected:   []string{"0000:04:00.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := ScanPCIDevices(sysfs, func(pciDev PCIEDevice) error {
+				got = append(got, pciDev.Address)
+				return nil
+			}, tt.predicates...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, got); diff != "" {
+				t.Errorf("devices mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadPCIID(t *testing.T) {
+	sysfs := fstest.MapFS{
+		"valid":     &fstest.MapFile{Data: []byte("0x15B3\n")},
+		"malformed": &fstest.MapFile{Data: []byte("15b3\n")},
+		"not hex":   &fstest.MapFile{Data: []byte("0xzzzz\n")},
+	}
+
+	got, err := readPCIID(sysfs, "valid")
+	if err != nil || got != "15b3" {
+		t.Errorf("valid: got %q err %v", got, err)
+	}
+	got, err = readPCIID(sysfs, "missing")
+	if err != nil || got != "" {
+		t.Errorf("missing: got %q err %v", got, err)
+	}
+	for _, path := range []string{"malformed", "not hex"} {
+		if _, err := readPCIID(sysfs, path); err == nil {
+			t.Errorf("%s: expected error, got nil", path)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-logr/logr"
+	resourceapi "k8s.io/api/resource/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/ffromani/ctrreschk/internal/deviceattribute"
+)
+
+const (
+	// DeviceAttributeNUMANode and DeviceAttributeLocalCPUs are not standard attributes,
+	// so they are unqualified and thus implicitly in the driver domain.
+	DeviceAttributeNUMANode  resourceapi.QualifiedName = "numaNode"
+	DeviceAttributeLocalCPUs resourceapi.QualifiedName = "localCPUs"
+)
+
+type ResourceSliceOptions struct {
+	DriverName string
+	NodeName   string
+	// ClassIDs matches the class ("02"), the class and subclass ("0200") or the full class code ("020000")
+	ClassIDs  []string
+	VendorIDs []string
+	// Drivers are the kernel drivers the devices are bound to
+	Drivers []string
+}
+
+func (opts ResourceSliceOptions) predicates() []PCIEDevicePredicate {
+	var preds []PCIEDevicePredicate
+	if len(opts.ClassIDs) > 0 {
+		preds = append(preds, MatchClass(opts.ClassIDs...))
+	}
+	if len(opts.VendorIDs) > 0 {
+		preds = append(preds, MatchVendor(opts.VendorIDs...))
+	}
+	if len(opts.Drivers) > 0 {
+		preds = append(preds, MatchDriver(opts.Drivers...))
+	}
+	return preds
+}
+
+// ResourceSlicesFromFS builds the ResourceSlices a DRA driver exposing the matching PCI devices should publish.
+// The slices are not meant to be applied to a cluster, but to be compared with what the DRA drivers actually publish.
+func ResourceSlicesFromFS(lh logr.Logger, sysfs SysFS, opts ResourceSliceOptions) ([]resourceapi.ResourceSlice, error) {
+	var devices []resourceapi.Device
+	err := ScanPCIDevices(sysfs, func(pciDev PCIEDevice) error {
+		dev, err := resourceSliceDevice(lh, sysfs, pciDev)
+		if err != nil {
+			return err
+		}
+		devices = append(devices, dev)
+		return nil
+	}, opts.predicates()...)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := slices.Collect(slices.Chunk(devices, resourceapi.ResourceSliceMaxDevices))
+	if len(chunks) == 0 {
+		// we still want a valid, albeit empty, pool
+		chunks = append(chunks, nil)
+	}
+	var resSlices []resourceapi.ResourceSlice
+	for idx, chunk := range chunks {
+		resSlices = append(resSlices, resourceapi.ResourceSlice{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: resourceapi.SchemeGroupVersion.String(),
+				Kind:       "ResourceSlice",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: fmt.Sprintf("%s-%s-%d", opts.NodeName, opts.DriverName, idx),
+			},
+			Spec: resourceapi.ResourceSliceSpec{
+				Driver:   opts.DriverName,
+				NodeName: &opts.NodeName,
+				Pool: resourceapi.ResourcePool{
+					Name:               opts.NodeName,
+					ResourceSliceCount: int64(len(chunks)),
+				},
+				Devices: chunk,
+			},
+		})
+	}
+	return resSlices, nil
+}
+
+func resourceSliceDevice(lh logr.Logger, sysfs SysFS, pciDev PCIEDevice) (resourceapi.Device, error) {
+	busIDAttr, err := deviceattribute.GetPCIBusIDAttribute(pciDev.Address)
+	if err != nil {
+		return resourceapi.Device{}, err
+	}
+	pcieRootAttr, err := deviceattribute.GetPCIeRootAttributeByPCIBusID(pciDev.Address, deviceattribute.WithFS(sysfs))
+	if err != nil {
+		return resourceapi.Device{}, err
+	}
+	numaNode := int64(readPCIEDeviceNUMANode(lh, sysfs, pciDev))
+	localCPUs := readPCIEDeviceLocalCPUs(lh, sysfs, pciDev).String()
+
+	return resourceapi.Device{
+		// device names must be DNS labels, so we can't use the PCI address as-is
+		Name: "pci-" + strings.NewReplacer(":", "-", ".", "-").Replace(pciDev.Address),
+		Attributes: map[resourceapi.QualifiedName]resourceapi.DeviceAttribute{
+			busIDAttr.Name:           busIDAttr.Value,
+			pcieRootAttr.Name:        pcieRootAttr.Value,
+			DeviceAttributeNUMANode:  {IntValue: &numaNode},
+			DeviceAttributeLocalCPUs: {StringValue: &localCPUs},
+		},
+	}, nil
+}
@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package device
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/ffromani/ctrreschk/internal/deviceattribute"
+)
+
+func TestResourceSlicesFromFS(t *testing.T) {
+	sysfs := makeBasePCSysFSFixture()
+	sysfs["devices/pci0000:00/0000:00:06.0/0000:04:00.0/driver"] = &fstest.MapFile{
+		Data: []byte("../../../../bus/pci/drivers/nvme"),
+		Mode: fs.ModeSymlink,
+	}
+	sysfs["devices/pci0000:00/0000:00:06.0/0000:04:00.0/vendor"] = &fstest.MapFile{
+		Data: []byte("0x144d\n"),
+	}
+	sysfs["devices/pci0000:00/0000:00:1f.6/vendor"] = &fstest.MapFile{
+		Data: []byte("0x8086\n"),
+	}
+
+	nvmeDevice := resourceapi.Device{
+		Name: "pci-0000-04-00-0",
+		Attributes: map[resourceapi.QualifiedName]resourceapi.DeviceAttribute{
+			deviceattribute.StandardDeviceAttributePCIBusID: {StringValue: ptr.To("0000:04:00.0")},
+			deviceattribute.StandardDeviceAttributePCIeRoot: {StringValue: ptr.To("pci0000:00")},
+			DeviceAttributeNUMANode:                         {IntValue: ptr.To[int64](-1)},
+			DeviceAttributeLocalCPUs:                        {StringValue: ptr.To("0-7")},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		opts          ResourceSliceOptions
+		expectedNames []string
+		expectedDev   *resourceapi.Device
+	}{
+		{
+			name:          "no matching devices",
+			opts:          ResourceSliceOptions{ClassIDs: []string{"12"}},
+			expectedNames: nil,
+		},
+		{
+			name:          "by class",
+			opts:          ResourceSliceOptions{ClassIDs: []string{"01"}},
+			expectedNames: []string{"pci-0000-04-00-0"},
+			expectedDev:   &nvmeDevice,
+		},
+		{
+			name:          "by class and subclass",
+			opts:          ResourceSliceOptions{ClassIDs: []string{"0604"}},
+			expectedNames: []string{"pci-0000-00-06-0", "pci-0000-00-07-0", "pci-0000-00-07-2", "pci-0000-50-00-0", "pci-0000-51-02-0", "pci-0000-51-04-0"},
+		},
+		{
+			name:          "by vendor",
+			opts:          ResourceSliceOptions{VendorIDs: []string{"8086"}},
+			expectedNames: []string{"pci-0000-00-1f-6"},
+		},
+		{
+			name:          "by driver",
+			opts:          ResourceSliceOptions{Drivers: []string{"nvme"}},
+			expectedNames: []string{"pci-0000-04-00-0"},
+			expectedDev:   &nvmeDevice,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.DriverName = "ctrreschk.local"
+			tt.opts.NodeName = "node-0"
+			got, err := ResourceSlicesFromFS(testr.New(t), sysfs, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != 1 {
+				t.Fatalf("expected 1 slice, got %d", len(got))
+			}
+			resSlice := got[0]
+			if resSlice.Spec.Driver != "ctrreschk.local" || resSlice.Spec.Pool.Name != "node-0" || *resSlice.Spec.NodeName != "node-0" {
+				t.Errorf("unexpected slice spec: %+v", resSlice.Spec)
+			}
+
+			var gotNames []string
+			for _, dev := range resSlice.Spec.Devices {
+				gotNames = append(gotNames, dev.Name)
+			}
+			if diff := cmp.Diff(tt.expectedNames, gotNames); diff != "" {
+				t.Errorf("devices mismatch (-want +got):\n%s", diff)
+			}
+			if tt.expectedDev == nil {
+				return
+			}
+			if diff := cmp.Diff(*tt.expectedDev, resSlice.Spec.Devices[0]); diff != "" {
+				t.Errorf("device mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
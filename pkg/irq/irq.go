@@ -0,0 +1,196 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package irq
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+const (
+	InterruptsFile            = "interrupts"
+	IRQDir                    = "irq"
+	AffinityListFile          = "smp_affinity_list"
+	EffectiveAffinityListFile = "effective_affinity_list"
+)
+
+type IRQ struct {
+	Number int
+	// Name is the interrupt controller, the hardware IRQ and the handlers, as reported by /proc/interrupts
+	Name string
+	// Counts are the interrupts served by each CPU since boot
+	Counts map[int]uint64
+	// Affinity is the set of CPUs the IRQ is allowed to land on
+	Affinity cpuset.CPUSet
+	// EffectiveAffinity is the set of CPUs the IRQ is actually routed to.
+	// This is a subset of Affinity, and it is equal to Affinity on kernels which don't report it.
+	EffectiveAffinity cpuset.CPUSet
+}
+
+// CountOn returns the number of interrupts served by the given CPUs.
+func (irq IRQ) CountOn(cpus cpuset.CPUSet) uint64 {
+	var count uint64
+	for cpu, cnt := range irq.Counts {
+		if cpus.Contains(cpu) {
+			count += cnt
+		}
+	}
+	return count
+}
+
+func InterruptsPath(env *environ.Environ) string {
+	return filepath.Join(env.Root.Proc, InterruptsFile)
+}
+
+// Read returns all the numbered IRQs with their affinities.
+// The architecture specific interrupts (NMI, LOC...) have no affinity we can tune, so they are skipped.
+func Read(env *environ.Environ) ([]IRQ, error) {
+	path := InterruptsPath(env)
+	env.Log.V(2).Info("reading interrupts", "path", path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	irqs, err := parseInterrupts(f)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range irqs {
+		irq := &irqs[idx]
+		irq.Affinity, err = readAffinity(env, irq.Number, AffinityListFile)
+		if errors.Is(err, fs.ErrNotExist) {
+			// some IRQs (e.g. the legacy cascade) are not exposed in procfs, we can't tell where they land
+			env.Log.V(4).Info("affinity not available, skipping", "irq", irq.Number)
+			irq.EffectiveAffinity = cpuset.New()
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		irq.EffectiveAffinity, err = readAffinity(env, irq.Number, EffectiveAffinityListFile)
+		if errors.Is(err, fs.ErrNotExist) {
+			env.Log.V(4).Info("effective affinity not available, using affinity", "irq", irq.Number)
+			irq.EffectiveAffinity = irq.Affinity.Clone()
+			err = nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return irqs, nil
+}
+
+// DeviceIRQs returns the IRQs of the PCI device: the MSI/MSI-X vectors if enabled, otherwise the legacy INTx IRQ.
+func DeviceIRQs(env *environ.Environ, pciAddress string) ([]int, error) {
+	devPath := filepath.Join(env.Root.Sys, "bus", "pci", "devices", pciAddress)
+	var irqs []int
+	entries, err := os.ReadDir(filepath.Join(devPath, "msi_irqs"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		num, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		irqs = append(irqs, num)
+	}
+	if len(irqs) > 0 {
+		slices.Sort(irqs)
+		return irqs, nil
+	}
+	data, err := os.ReadFile(filepath.Join(devPath, "irq"))
+	if err != nil {
+		return nil, err
+	}
+	num, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid IRQ %q: %w", strings.TrimSpace(string(data)), err)
+	}
+	if num == 0 {
+		// no IRQ assigned
+		return nil, nil
+	}
+	return []int{num}, nil
+}
+
+func readAffinity(env *environ.Environ, num int, fileName string) (cpuset.CPUSet, error) {
+	data, err := os.ReadFile(filepath.Join(env.Root.Proc, IRQDir, strconv.Itoa(num), fileName))
+	if err != nil {
+		return cpuset.New(), err
+	}
+	return cpuset.Parse(strings.TrimSpace(string(data)))
+}
+
+// parseInterrupts parses /proc/interrupts. The first line lists the online CPUs, e.g. "CPU0 CPU1 CPU4",
+// the following lines are "IRQ: count per CPU... name".
+func parseInterrupts(r io.Reader) ([]IRQ, error) {
+	scanner := bufio.NewScanner(r)
+	// lines can be very long on machines with many CPUs
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("missing header")
+	}
+	var cpuIDs []int
+	for _, field := range strings.Fields(scanner.Text()) {
+		id, err := strconv.Atoi(strings.TrimPrefix(field, "CPU"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPU column %q: %w", field, err)
+		}
+		cpuIDs = append(cpuIDs, id)
+	}
+
+	var irqs []IRQ
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		num, err := strconv.Atoi(strings.TrimSuffix(fields[0], ":"))
+		if err != nil {
+			// NMI, LOC, ERR...
+			continue
+		}
+		irq := IRQ{
+			Number: num,
+			Counts: make(map[int]uint64),
+		}
+		fields = fields[1:]
+		for idx, cpuID := range cpuIDs {
+			if idx >= len(fields) {
+				break
+			}
+			cnt, err := strconv.ParseUint(fields[idx], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid count %q for IRQ %d: %w", fields[idx], num, err)
+			}
+			irq.Counts[cpuID] = cnt
+		}
+		if len(fields) > len(cpuIDs) {
+			irq.Name = strings.Join(fields[len(cpuIDs):], " ")
+		}
+		irqs = append(irqs, irq)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return irqs, nil
+}
@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package irq
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+const testInterrupts = `           CPU0       CPU1       CPU2       CPU4
+  0:         44          0          0          0   IO-APIC   2-edge      timer
+  8:          0          0          1          0   IO-APIC   8-edge      rtc0
+ 24:       1200          3          0         17   PCI-MSIX-0000:04:00.0   0-edge      nvme0q0
+ 25:          0        900          0          0   PCI-MSIX-0000:04:00.0   1-edge      nvme0q1
+ 26:          5          0          0          0   PCI-MSIX-0000:05:10.2   0-edge      iavf-0000:05:10.2:mbx
+NMI:          0          0          0          0   Non-maskable interrupts
+LOC:     123456     234567     345678     456789   Local timer interrupts
+ERR:          0
+`
+
+func TestRead(t *testing.T) {
+	procDir := t.TempDir()
+	env := &environ.Environ{
+		Root: environ.FS{Proc: procDir},
+		Log:  environ.DefaultLog(),
+	}
+
+	mustWriteFile(t, filepath.Join(procDir, InterruptsFile), testInterrupts)
+	affinities := map[string]string{
+		"0/" + AffinityListFile:           "0-2,4\n",
+		"0/" + EffectiveAffinityListFile:  "0\n",
+		"24/" + AffinityListFile:          "0-2,4\n",
+		"24/" + EffectiveAffinityListFile: "4\n",
+		"25/" + AffinityListFile:          "1\n",
+		"25/" + EffectiveAffinityListFile: "1\n",
+		// older kernels don't have the effective affinity
+		"26/" + AffinityListFile: "0-1\n",
+		// IRQ 8 not exposed at all
+	}
+	for path, content := range affinities {
+		mustWriteFile(t, filepath.Join(procDir, IRQDir, path), content)
+	}
+
+	irqs, err := Read(env)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []IRQ{
+		{Number: 0, Name: "IO-APIC 2-edge timer", Counts: map[int]uint64{0: 44, 1: 0, 2: 0, 4: 0}, Affinity: cpuset.New(0, 1, 2, 4), EffectiveAffinity: cpuset.New(0)},
+		{Number: 8, Name: "IO-APIC 8-edge rtc0", Counts: map[int]uint64{0: 0, 1: 0, 2: 1, 4: 0}, Affinity: cpuset.New(), EffectiveAffinity: cpuset.New()},
+		{Number: 24, Name: "PCI-MSIX-0000:04:00.0 0-edge nvme0q0", Counts: map[int]uint64{0: 1200, 1: 3, 2: 0, 4: 17}, Affinity: cpuset.New(0, 1, 2, 4), EffectiveAffinity: cpuset.New(4)},
+		{Number: 25, Name: "PCI-MSIX-0000:04:00.0 1-edge nvme0q1", Counts: map[int]uint64{0: 0, 1: 900, 2: 0, 4: 0}, Affinity: cpuset.New(1), EffectiveAffinity: cpuset.New(1)},
+		{Number: 26, Name: "PCI-MSIX-0000:05:10.2 0-edge iavf-0000:05:10.2:mbx", Counts: map[int]uint64{0: 5, 1: 0, 2: 0, 4: 0}, Affinity: cpuset.New(0, 1), EffectiveAffinity: cpuset.New(0, 1)},
+	}
+	if len(irqs) != len(expected) {
+		t.Fatalf("expected %d IRQs, got %d: %+v", len(expected), len(irqs), irqs)
+	}
+	for i, exp := range expected {
+		got := irqs[i]
+		if got.Number != exp.Number || got.Name != exp.Name {
+			t.Errorf("irq[%d]: expected %d %q, got %d %q", i, exp.Number, exp.Name, got.Number, got.Name)
+		}
+		if !reflect.DeepEqual(got.Counts, exp.Counts) {
+			t.Errorf("irq[%d] counts: expected %v, got %v", i, exp.Counts, got.Counts)
+		}
+		if !got.Affinity.Equals(exp.Affinity) {
+			t.Errorf("irq[%d] affinity: expected %v, got %v", i, exp.Affinity, got.Affinity)
+		}
+		if !got.EffectiveAffinity.Equals(exp.EffectiveAffinity) {
+			t.Errorf("irq[%d] effective affinity: expected %v, got %v", i, exp.EffectiveAffinity, got.EffectiveAffinity)
+		}
+	}
+
+	if cnt := irqs[2].CountOn(cpuset.New(1, 4)); cnt != 20 {
+		t.Errorf("expected 20 interrupts on CPUs 1,4, got %d", cnt)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+	}{
+		{
+			name: "missing file",
+		},
+		{
+			name:    "empty file",
+			content: "",
+		},
+		{
+			name:    "malformed header",
+			content: "CPU0 CPUx\n",
+		},
+		{
+			name:    "malformed count",
+			content: "CPU0\n 24: abc PCI-MSIX 0-edge nvme0q0\n",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			procDir := t.TempDir()
+			env := &environ.Environ{
+				Root: environ.FS{Proc: procDir},
+				Log:  environ.DefaultLog(),
+			}
+			if tt.name != "missing file" {
+				mustWriteFile(t, filepath.Join(procDir, InterruptsFile), tt.content)
+			}
+			if _, err := Read(env); err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestDeviceIRQs(t *testing.T) {
+	sysDir := t.TempDir()
+	env := &environ.Environ{
+		Root: environ.FS{Sys: sysDir},
+		Log:  environ.DefaultLog(),
+	}
+
+	devicesDir := filepath.Join(sysDir, "bus", "pci", "devices")
+	for _, num := range []string{"25", "24", "133"} {
+		mustWriteFile(t, filepath.Join(devicesDir, "0000:04:00.0", "msi_irqs", num), "msix\n")
+	}
+	mustWriteFile(t, filepath.Join(devicesDir, "0000:04:00.0", "irq"), "16\n")
+	mustWriteFile(t, filepath.Join(devicesDir, "0000:00:1f.3", "irq"), "17\n")
+	mustWriteFile(t, filepath.Join(devicesDir, "0000:00:1f.4", "irq"), "0\n")
+
+	testCases := []struct {
+		pciAddress  string
+		expected    []int
+		expectedErr bool
+	}{
+		{pciAddress: "0000:04:00.0", expected: []int{24, 25, 133}},
+		{pciAddress: "0000:00:1f.3", expected: []int{17}},
+		{pciAddress: "0000:00:1f.4", expected: nil},
+		{pciAddress: "0000:99:00.0", expectedErr: true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.pciAddress, func(t *testing.T) {
+			got, err := DeviceIRQs(env, tt.pciAddress)
+			if tt.expectedErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func mustWriteFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		t.Fatalf("cannot create dir for %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("cannot write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package isolation
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+const (
+	CmdlineFile = "cmdline"
+)
+
+// Info describes how the kernel isolates the CPUs from the system activities.
+type Info struct {
+	Online cpuset.CPUSet
+	// Isolated are the CPUs removed from the scheduler domains (isolcpus=domain)
+	Isolated cpuset.CPUSet
+	// NoHZFull are the CPUs which stop the scheduler tick when running a single task (nohz_full)
+	NoHZFull cpuset.CPUSet
+	// RCUNoCBs are the CPUs whose RCU callbacks are offloaded to kthreads (rcu_nocbs, implied by nohz_full)
+	RCUNoCBs cpuset.CPUSet
+}
+
+// Housekeeping returns the online CPUs which are neither isolated nor tickless, so they run the system activities.
+func (info Info) Housekeeping() cpuset.CPUSet {
+	return info.Online.Difference(info.Isolated.Union(info.NoHZFull))
+}
+
+// infoJSON is the serialized form of Info, using the same cpulist format of the kernel
+type infoJSON struct {
+	Online   string `json:"online"`
+	Isolated string `json:"isolated,omitempty"`
+	NoHZFull string `json:"nohzFull,omitempty"`
+	RCUNoCBs string `json:"rcuNoCBs,omitempty"`
+}
+
+func (info Info) MarshalJSON() ([]byte, error) {
+	return json.Marshal(infoJSON{
+		Online:   info.Online.String(),
+		Isolated: info.Isolated.String(),
+		NoHZFull: info.NoHZFull.String(),
+		RCUNoCBs: info.RCUNoCBs.String(),
+	})
+}
+
+func (info *Info) UnmarshalJSON(data []byte) error {
+	var ij infoJSON
+	err := json.Unmarshal(data, &ij)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	info.Online, err = cpuset.Parse(ij.Online)
+	errs = append(errs, err)
+	info.Isolated, err = cpuset.Parse(ij.Isolated)
+	errs = append(errs, err)
+	info.NoHZFull, err = cpuset.Parse(ij.NoHZFull)
+	errs = append(errs, err)
+	info.RCUNoCBs, err = cpuset.Parse(ij.RCUNoCBs)
+	errs = append(errs, err)
+	return errors.Join(errs...)
+}
+
+// Discover reads the CPU isolation settings. The runtime state exposed in sysfs is preferred,
+// the kernel command line is used for the settings sysfs doesn't expose, or if sysfs is not available.
+func Discover(env *environ.Environ) (Info, error) {
+	cpuDir := filepath.Join(env.Root.Sys, "devices", "system", "cpu")
+	online, err := readCPUList(filepath.Join(cpuDir, "online"))
+	if err != nil {
+		return Info{}, err
+	}
+
+	cmdline, err := os.ReadFile(filepath.Join(env.Root.Proc, CmdlineFile))
+	if err != nil {
+		return Info{}, err
+	}
+	info := parseCmdline(env, string(cmdline), online)
+	info.Online = online
+
+	if isolated, err := readCPUList(filepath.Join(cpuDir, "isolated")); err == nil {
+		info.Isolated = isolated
+	} else {
+		env.Log.V(2).Info("cannot read isolated CPUs from sysfs, using the kernel command line", "error", err)
+	}
+	if nohzFull, err := readCPUList(filepath.Join(cpuDir, "nohz_full")); err == nil {
+		info.NoHZFull = nohzFull
+	} else {
+		env.Log.V(2).Info("cannot read tickless CPUs from sysfs, using the kernel command line", "error", err)
+	}
+	// since linux 5.x nohz_full implies rcu_nocbs
+	info.RCUNoCBs = info.RCUNoCBs.Union(info.NoHZFull)
+
+	env.Log.V(2).Info("CPU isolation", "online", info.Online.String(), "isolated", info.Isolated.String(), "nohzFull", info.NoHZFull.String(), "rcuNoCBs", info.RCUNoCBs.String())
+	return info, nil
+}
+
+// parseCmdline extracts the CPU isolation settings from the kernel command line.
+// Malformed values are logged and ignored, like the kernel does.
+func parseCmdline(env *environ.Environ, cmdline string, online cpuset.CPUSet) Info {
+	info := Info{
+		Isolated: cpuset.New(),
+		NoHZFull: cpuset.New(),
+		RCUNoCBs: cpuset.New(),
+	}
+	for _, param := range strings.Fields(cmdline) {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "isolcpus":
+			// isolcpus=[flag,...,]cpulist, where flags are nohz, domain, managed_irq
+			var flags, cpuList []string
+			for _, item := range strings.Split(value, ",") {
+				if item == "nohz" || item == "domain" || item == "managed_irq" {
+					flags = append(flags, item)
+				} else {
+					cpuList = append(cpuList, item)
+				}
+			}
+			cpus, ok := parseCmdlineCPUList(env, key, strings.Join(cpuList, ","), online)
+			if !ok {
+				continue
+			}
+			// no flags means domain
+			if len(flags) == 0 || slices.Contains(flags, "domain") {
+				info.Isolated = info.Isolated.Union(cpus)
+			}
+			if slices.Contains(flags, "nohz") {
+				info.NoHZFull = info.NoHZFull.Union(cpus)
+			}
+		case "nohz_full":
+			if cpus, ok := parseCmdlineCPUList(env, key, value, online); ok {
+				info.NoHZFull = info.NoHZFull.Union(cpus)
+			}
+		case "rcu_nocbs":
+			if cpus, ok := parseCmdlineCPUList(env, key, value, online); ok {
+				info.RCUNoCBs = info.RCUNoCBs.Union(cpus)
+			}
+		}
+	}
+	return info
+}
+
+func parseCmdlineCPUList(env *environ.Environ, key, value string, online cpuset.CPUSet) (cpuset.CPUSet, bool) {
+	if value == "all" {
+		return online.Clone(), true
+	}
+	cpus, err := cpuset.Parse(value)
+	if err != nil {
+		env.Log.V(1).Info("cannot parse kernel command line CPU list, skipping", "param", key, "value", value, "error", err)
+		return cpuset.New(), false
+	}
+	return cpus, true
+}
+
+func readCPUList(path string) (cpuset.CPUSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cpuset.New(), err
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "(null)" {
+		// older kernels report this when nohz_full is not set
+		return cpuset.New(), nil
+	}
+	return cpuset.Parse(value)
+}
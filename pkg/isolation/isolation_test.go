@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package isolation
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+func TestDiscover(t *testing.T) {
+	testCases := []struct {
+		name        string
+		cmdline     string
+		sysfs       map[string]string
+		expected    Info
+		expectedErr bool
+	}{
+		{
+			name:    "no isolation",
+			cmdline: "BOOT_IMAGE=/vmlinuz root=/dev/sda1 ro quiet\n",
+			sysfs:   map[string]string{"online": "0-7\n", "isolated": "\n", "nohz_full": "(null)\n"},
+			expected: Info{
+				Online:   cpuset.New(0, 1, 2, 3, 4, 5, 6, 7),
+				Isolated: cpuset.New(),
+				NoHZFull: cpuset.New(),
+				RCUNoCBs: cpuset.New(),
+			},
+		},
+		{
+			name:    "command line only",
+			cmdline: "root=/dev/sda1 isolcpus=managed_irq,domain,2-5 nohz_full=4-7 rcu_nocbs=2-3\n",
+			sysfs:   map[string]string{"online": "0-7\n"},
+			expected: Info{
+				Online:   cpuset.New(0, 1, 2, 3, 4, 5, 6, 7),
+				Isolated: cpuset.New(2, 3, 4, 5),
+				NoHZFull: cpuset.New(4, 5, 6, 7),
+				RCUNoCBs: cpuset.New(2, 3, 4, 5, 6, 7),
+			},
+		},
+		{
+			name:    "isolcpus nohz flag only",
+			cmdline: "isolcpus=nohz,6-7\n",
+			sysfs:   map[string]string{"online": "0-7\n"},
+			expected: Info{
+				Online:   cpuset.New(0, 1, 2, 3, 4, 5, 6, 7),
+				Isolated: cpuset.New(),
+				NoHZFull: cpuset.New(6, 7),
+				RCUNoCBs: cpuset.New(6, 7),
+			},
+		},
+		{
+			name:    "sysfs takes precedence",
+			cmdline: "isolcpus=2-5 nohz_full=2-5 rcu_nocbs=all\n",
+			sysfs:   map[string]string{"online": "0-7\n", "isolated": "4-5\n", "nohz_full": "4-7\n"},
+			expected: Info{
+				Online:   cpuset.New(0, 1, 2, 3, 4, 5, 6, 7),
+				Isolated: cpuset.New(4, 5),
+				NoHZFull: cpuset.New(4, 5, 6, 7),
+				RCUNoCBs: cpuset.New(0, 1, 2, 3, 4, 5, 6, 7),
+			},
+		},
+		{
+			name:    "malformed command line values are ignored",
+			cmdline: "isolcpus=foo nohz_full=2-3\n",
+			sysfs:   map[string]string{"online": "0-3\n"},
+			expected: Info{
+				Online:   cpuset.New(0, 1, 2, 3),
+				Isolated: cpuset.New(),
+				NoHZFull: cpuset.New(2, 3),
+				RCUNoCBs: cpuset.New(2, 3),
+			},
+		},
+		{
+			name:        "missing online CPUs",
+			cmdline:     "root=/dev/sda1\n",
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			rootDir := t.TempDir()
+			env := &environ.Environ{
+				Root: environ.FS{Sys: filepath.Join(rootDir, "sys"), Proc: filepath.Join(rootDir, "proc")},
+				Log:  environ.DefaultLog(),
+			}
+			mustWriteFile(t, filepath.Join(env.Root.Proc, CmdlineFile), tt.cmdline)
+			for name, content := range tt.sysfs {
+				mustWriteFile(t, filepath.Join(env.Root.Sys, "devices", "system", "cpu", name), content)
+			}
+
+			got, err := Discover(env)
+			if tt.expectedErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Online.Equals(tt.expected.Online) || !got.Isolated.Equals(tt.expected.Isolated) ||
+				!got.NoHZFull.Equals(tt.expected.NoHZFull) || !got.RCUNoCBs.Equals(tt.expected.RCUNoCBs) {
+				t.Errorf("expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestHousekeeping(t *testing.T) {
+	info := Info{
+		Online:   cpuset.New(0, 1, 2, 3, 4, 5, 6, 7),
+		Isolated: cpuset.New(2, 3),
+		NoHZFull: cpuset.New(3, 4),
+	}
+	if got := info.Housekeeping(); !got.Equals(cpuset.New(0, 1, 5, 6, 7)) {
+		t.Errorf("unexpected housekeeping CPUs: %v", got)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	info := Info{
+		Online:   cpuset.New(0, 1, 2, 3, 4, 5, 6, 7),
+		Isolated: cpuset.New(2, 3),
+		NoHZFull: cpuset.New(2, 3, 6),
+		RCUNoCBs: cpuset.New(),
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"online":"0-7","isolated":"2-3","nohzFull":"2-3,6"}` {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+	var got Info
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.Online.Equals(info.Online) || !got.Isolated.Equals(info.Isolated) ||
+		!got.NoHZFull.Equals(info.NoHZFull) || !got.RCUNoCBs.Equals(info.RCUNoCBs) {
+		t.Errorf("expected %+v, got %+v", info, got)
+	}
+}
+
+func mustWriteFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		t.Fatalf("cannot create dir for %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("cannot write %s: %v", path, err)
+	}
+}
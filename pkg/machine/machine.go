@@ -21,7 +21,9 @@ import (
 	"os"
 	"strings"
 
+	"github.com/ffromani/ctrreschk/pkg/coretype"
 	"github.com/ffromani/ctrreschk/pkg/environ"
+	"github.com/ffromani/ctrreschk/pkg/isolation"
 	"github.com/jaypipes/ghw/pkg/cpu"
 	"github.com/jaypipes/ghw/pkg/topology"
 )
@@ -29,6 +31,13 @@ import (
 type Machine struct {
 	CPU      *cpu.Info      `json:"cpu"`
 	Topology *topology.Info `json:"topology"`
+	// Isolation is nil if the CPU isolation settings are unknown
+	Isolation *isolation.Info `json:"isolation,omitempty"`
+	// CoreTypes is nil if all the cores have the same class or if the class is unknown
+	CoreTypes *coretype.Info `json:"coreTypes,omitempty"`
+	// IsolationErr and CoreTypesErr are why the corresponding settings could not be detected
+	IsolationErr error `json:"-"`
+	CoreTypesErr error `json:"-"`
 }
 
 func (ma Machine) ToJSON() (string, error) {
@@ -67,6 +76,22 @@ func FromSystem(env *environ.Environ) (Machine, error) {
 	mc.Topology = topo
 	env.Log.V(2).Info("detected machine", "topology", topo)
 
+	isol, err := isolation.Discover(env)
+	if err != nil {
+		// not fatal: we just can't tell if the CPUs are isolated
+		env.Log.V(1).Info("cannot detect CPU isolation, skipping", "error", err)
+		mc.IsolationErr = err
+	} else {
+		mc.Isolation = &isol
+	}
+
+	coreTypes, err := coretype.Discover(env)
+	if err != nil {
+		env.Log.V(1).Info("cannot detect core types, skipping", "error", err)
+		mc.CoreTypesErr = err
+	}
+	mc.CoreTypes = coreTypes
+
 	return mc, nil
 }
 
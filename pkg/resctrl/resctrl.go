@@ -0,0 +1,306 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package resctrl
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+const (
+	ResctrlPath = "fs/resctrl"
+	// DefaultGroup is the name of the group at the root of the filesystem, which owns all the tasks not assigned elsewhere
+	DefaultGroup = "/"
+
+	SchemataFile = "schemata"
+	TasksFile    = "tasks"
+	CPUsListFile = "cpus_list"
+	ModeFile     = "mode"
+
+	// ResourceL3 is the L3 Cache Allocation Technology resource; with CDP enabled it is split in L3CODE and L3DATA
+	ResourceL3     = "L3"
+	ResourceL3Data = "L3DATA"
+	// ResourceMB is the Memory Bandwidth Allocation resource
+	ResourceMB = "MB"
+)
+
+type Group struct {
+	// Name is the path of the group relative to the resctrl root, or DefaultGroup
+	Name string
+	// Mode is the cache allocation sharing mode: shareable, exclusive, pseudo-locksetup or pseudo-locked
+	Mode string
+	// CPUs are the CPUs assigned to the group: the tasks not explicitly assigned to a group use the group of their CPU
+	CPUs cpuset.CPUSet
+	// Tasks are the task IDs explicitly assigned to the group
+	Tasks []int
+	// Schemata maps each resource (e.g. L3, MB) to the allocation per domain, as reported by the kernel
+	Schemata map[string]map[int]string
+}
+
+// L3CBM returns the L3 cache capacity bitmask of the domain. With CDP enabled, the data mask is returned.
+func (gr Group) L3CBM(domain int) (uint64, bool) {
+	value, ok := gr.Schemata[ResourceL3][domain]
+	if !ok {
+		value, ok = gr.Schemata[ResourceL3Data][domain]
+	}
+	if !ok {
+		return 0, false
+	}
+	cbm, err := strconv.ParseUint(value, 16, 64)
+	return cbm, err == nil
+}
+
+// MBPercent returns the memory bandwidth allocated to the domain, in percentage of the total.
+// On AMD and with the MBA software controller the value is an absolute bandwidth rather than a percentage.
+func (gr Group) MBPercent(domain int) (int, bool) {
+	value, ok := gr.Schemata[ResourceMB][domain]
+	if !ok {
+		return 0, false
+	}
+	pct, err := strconv.Atoi(value)
+	return pct, err == nil
+}
+
+type Info struct {
+	Groups []Group
+	// L3CBMMask is the bitmask of all the L3 cache ways which can be allocated, 0 if L3 CAT is not supported
+	L3CBMMask uint64
+	// L3Domains maps the L3 domains, which are the L3 cache IDs, to their CPUs
+	L3Domains map[int]cpuset.CPUSet
+	// Tasks maps the group names to the given tasks assigned to them
+	Tasks map[string][]int
+}
+
+// CacheWays returns the number of ways set in the cache capacity bitmask.
+func CacheWays(cbm uint64) int {
+	return bits.OnesCount64(cbm)
+}
+
+// Read returns the resctrl groups and the groups the given tasks (thread IDs) are assigned to.
+// Threads can be assigned to groups independently of their process, so all of them should be given.
+// Fails if resctrl is not mounted.
+func Read(env *environ.Environ, tids []int) (Info, error) {
+	root := filepath.Join(env.Root.Sys, ResctrlPath)
+	env.Log.V(2).Info("reading resctrl", "path", root)
+
+	info := Info{
+		Tasks: make(map[string][]int),
+	}
+	var err error
+	info.Groups, err = readGroups(root)
+	if err != nil {
+		return Info{}, err
+	}
+	info.L3CBMMask, err = readCBMMask(root)
+	if err != nil {
+		return Info{}, err
+	}
+	info.L3Domains, err = readL3Domains(env)
+	if err != nil {
+		return Info{}, err
+	}
+
+	taskGroup := make(map[int]string)
+	for _, gr := range info.Groups {
+		for _, tid := range gr.Tasks {
+			taskGroup[tid] = gr.Name
+		}
+	}
+	for _, tid := range tids {
+		name, ok := taskGroup[tid]
+		if !ok {
+			// can only happen if the task exited meanwhile
+			env.Log.V(2).Info("task not found in any resctrl group", "tid", tid)
+			continue
+		}
+		info.Tasks[name] = append(info.Tasks[name], tid)
+	}
+	env.Log.V(2).Info("read resctrl", "groups", len(info.Groups), "l3CBMMask", fmt.Sprintf("%x", info.L3CBMMask), "l3Domains", len(info.L3Domains))
+	return info, nil
+}
+
+// Group returns the group with the given name.
+func (info Info) Group(name string) (Group, bool) {
+	for _, gr := range info.Groups {
+		if gr.Name == name {
+			return gr, true
+		}
+	}
+	return Group{}, false
+}
+
+// CPUGroup returns the group the CPU is assigned to through cpus_list, or DefaultGroup.
+// The tasks of the default group use the allocations of the group of the CPU they run on.
+func (info Info) CPUGroup(cpu int) string {
+	for _, gr := range info.Groups {
+		if gr.Name != DefaultGroup && gr.CPUs.Contains(cpu) {
+			return gr.Name
+		}
+	}
+	return DefaultGroup
+}
+
+func readGroups(root string) ([]Group, error) {
+	gr, err := readGroup(root, DefaultGroup)
+	if err != nil {
+		return nil, err
+	}
+	groups := []Group{gr}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		// info, mon_groups and mon_data have no schemata
+		if _, err := os.Stat(filepath.Join(root, entry.Name(), SchemataFile)); err != nil {
+			continue
+		}
+		gr, err := readGroup(filepath.Join(root, entry.Name()), entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, gr)
+	}
+	return groups, nil
+}
+
+func readGroup(dir, name string) (Group, error) {
+	gr := Group{
+		Name: name,
+	}
+	data, err := os.ReadFile(filepath.Join(dir, SchemataFile))
+	if err != nil {
+		return Group{}, err
+	}
+	gr.Schemata, err = parseSchemata(string(data))
+	if err != nil {
+		return Group{}, fmt.Errorf("group %q: %w", name, err)
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, TasksFile))
+	if err != nil {
+		return Group{}, err
+	}
+	for _, field := range strings.Fields(string(data)) {
+		tid, err := strconv.Atoi(field)
+		if err != nil {
+			return Group{}, fmt.Errorf("group %q: invalid task %q: %w", name, field, err)
+		}
+		gr.Tasks = append(gr.Tasks, tid)
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, CPUsListFile))
+	if err != nil {
+		return Group{}, err
+	}
+	gr.CPUs, err = cpuset.Parse(strings.TrimSpace(string(data)))
+	if err != nil {
+		return Group{}, fmt.Errorf("group %q: %w", name, err)
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, ModeFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Group{}, err
+	}
+	gr.Mode = strings.TrimSpace(string(data))
+	return gr, nil
+}
+
+// parseSchemata parses lines like "L3:0=7ff;1=7ff" or "MB:0=100;1=100".
+// The resource name may be padded with spaces.
+func parseSchemata(data string) (map[string]map[int]string, error) {
+	schemata := make(map[string]map[int]string)
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		resource, domains, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid schemata line %q", line)
+		}
+		resource = strings.TrimSpace(resource)
+		schemata[resource] = make(map[int]string)
+		for _, item := range strings.Split(domains, ";") {
+			domainStr, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid schemata domain %q for %s", item, resource)
+			}
+			domain, err := strconv.Atoi(domainStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid schemata domain %q for %s: %w", item, resource, err)
+			}
+			schemata[resource][domain] = value
+		}
+	}
+	return schemata, nil
+}
+
+func readCBMMask(root string) (uint64, error) {
+	for _, resource := range []string{ResourceL3, ResourceL3Data} {
+		data, err := os.ReadFile(filepath.Join(root, "info", resource, "cbm_mask"))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		return strconv.ParseUint(strings.TrimSpace(string(data)), 16, 64)
+	}
+	return 0, nil
+}
+
+// readL3Domains maps the L3 cache IDs, which resctrl uses as domain IDs, to their CPUs.
+func readL3Domains(env *environ.Environ) (map[int]cpuset.CPUSet, error) {
+	cpuDir := filepath.Join(env.Root.Sys, "devices", "system", "cpu")
+	matches, err := filepath.Glob(filepath.Join(cpuDir, "cpu*", "cache", "index*", "level"))
+	if err != nil {
+		return nil, err
+	}
+	cpusByDomain := make(map[int][]int)
+	for _, levelPath := range matches {
+		cpuStr, ok := strings.CutPrefix(filepath.Base(filepath.Dir(filepath.Dir(filepath.Dir(levelPath)))), "cpu")
+		if !ok {
+			continue
+		}
+		cpu, err := strconv.Atoi(cpuStr)
+		if err != nil {
+			continue
+		}
+		level, err := os.ReadFile(levelPath)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(string(level)) != "3" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(levelPath), "id"))
+		if err != nil {
+			return nil, err
+		}
+		domain, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, err
+		}
+		cpusByDomain[domain] = append(cpusByDomain[domain], cpu)
+	}
+	domains := make(map[int]cpuset.CPUSet, len(cpusByDomain))
+	for domain, cpuIDs := range cpusByDomain {
+		domains[domain] = cpuset.New(cpuIDs...)
+	}
+	return domains, nil
+}
@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package resctrl
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+var cpuSetComparer = cmp.Comparer(func(a, b cpuset.CPUSet) bool { return a.Equals(b) })
+
+func TestRead(t *testing.T) {
+	sysDir := t.TempDir()
+	env := &environ.Environ{
+		Root: environ.FS{Sys: sysDir},
+		Log:  environ.DefaultLog(),
+	}
+	root := filepath.Join(sysDir, ResctrlPath)
+
+	mustWriteFile(t, filepath.Join(root, SchemataFile), "    L3:0=7ff;1=7ff\n    MB:0=100;1=100\n")
+	mustWriteFile(t, filepath.Join(root, TasksFile), "1\n2\n100\n")
+	mustWriteFile(t, filepath.Join(root, CPUsListFile), "0-5\n")
+	mustWriteFile(t, filepath.Join(root, ModeFile), "shareable\n")
+	mustWriteFile(t, filepath.Join(root, "info", "L3", "cbm_mask"), "7ff\n")
+	mustWriteFile(t, filepath.Join(root, "info", "MB", "min_bandwidth"), "10\n")
+	mustWriteFile(t, filepath.Join(root, "mon_data", "mon_L3_00", "llc_occupancy"), "0\n")
+
+	mustWriteFile(t, filepath.Join(root, "latency", SchemataFile), "L3:0=00f;1=7ff\nMB:0=50;1=100\n")
+	mustWriteFile(t, filepath.Join(root, "latency", TasksFile), "200\n201\n")
+	mustWriteFile(t, filepath.Join(root, "latency", CPUsListFile), "6-7\n")
+	mustWriteFile(t, filepath.Join(root, "latency", ModeFile), "exclusive\n")
+
+	for cpu := 0; cpu < 8; cpu++ {
+		cacheDir := filepath.Join(sysDir, "devices", "system", "cpu", "cpu"+strconv.Itoa(cpu), "cache")
+		mustWriteFile(t, filepath.Join(cacheDir, "index2", "level"), "2\n")
+		mustWriteFile(t, filepath.Join(cacheDir, "index2", "id"), strconv.Itoa(cpu)+"\n")
+		mustWriteFile(t, filepath.Join(cacheDir, "index3", "level"), "3\n")
+		mustWriteFile(t, filepath.Join(cacheDir, "index3", "id"), strconv.Itoa(cpu/4)+"\n")
+	}
+
+	got, err := Read(env, []int{100, 200, 201, 999})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := Info{
+		Groups: []Group{
+			{
+				Name:     DefaultGroup,
+				Mode:     "shareable",
+				CPUs:     cpuset.New(0, 1, 2, 3, 4, 5),
+				Tasks:    []int{1, 2, 100},
+				Schemata: map[string]map[int]string{"L3": {0: "7ff", 1: "7ff"}, "MB": {0: "100", 1: "100"}},
+			},
+			{
+				Name:     "latency",
+				Mode:     "exclusive",
+				CPUs:     cpuset.New(6, 7),
+				Tasks:    []int{200, 201},
+				Schemata: map[string]map[int]string{"L3": {0: "00f", 1: "7ff"}, "MB": {0: "50", 1: "100"}},
+			},
+		},
+		L3CBMMask: 0x7ff,
+		L3Domains: map[int]cpuset.CPUSet{
+			0: cpuset.New(0, 1, 2, 3),
+			1: cpuset.New(4, 5, 6, 7),
+		},
+		Tasks: map[string][]int{
+			DefaultGroup: {100},
+			"latency":    {200, 201},
+		},
+	}
+	if diff := cmp.Diff(expected, got, cpuSetComparer); diff != "" {
+		t.Errorf("unexpected resctrl info (-want +got):\n%s", diff)
+	}
+
+	gr, ok := got.Group("latency")
+	if !ok {
+		t.Fatalf("group latency not found")
+	}
+	if cbm, ok := gr.L3CBM(0); !ok || CacheWays(cbm) != 4 {
+		t.Errorf("expected 4 cache ways on domain 0, got %d (%v)", CacheWays(cbm), ok)
+	}
+	if mb, ok := gr.MBPercent(0); !ok || mb != 50 {
+		t.Errorf("expected 50%% memory bandwidth on domain 0, got %d (%v)", mb, ok)
+	}
+	if _, ok := gr.L3CBM(2); ok {
+		t.Errorf("unexpected L3 CBM for missing domain 2")
+	}
+	if name := got.CPUGroup(7); name != "latency" {
+		t.Errorf("expected CPU 7 in group latency, got %q", name)
+	}
+	if name := got.CPUGroup(0); name != DefaultGroup {
+		t.Errorf("expected CPU 0 in the default group, got %q", name)
+	}
+}
+
+func TestReadNotMounted(t *testing.T) {
+	env := &environ.Environ{
+		Root: environ.FS{Sys: t.TempDir()},
+		Log:  environ.DefaultLog(),
+	}
+	if _, err := Read(env, []int{1}); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+func TestParseSchemata(t *testing.T) {
+	testCases := []struct {
+		name        string
+		data        string
+		expected    map[string]map[int]string
+		expectedErr bool
+	}{
+		{
+			name: "CDP enabled",
+			data: "L3CODE:0=ff0;1=ff0\nL3DATA:0=00f;1=00f\n",
+			expected: map[string]map[int]string{
+				"L3CODE": {0: "ff0", 1: "ff0"},
+				"L3DATA": {0: "00f", 1: "00f"},
+			},
+		},
+		{
+			name:        "missing domains",
+			data:        "L3\n",
+			expectedErr: true,
+		},
+		{
+			name:        "malformed domain",
+			data:        "L3:x=7ff\n",
+			expectedErr: true,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSchemata(tt.data)
+			if tt.expectedErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, got); diff != "" {
+				t.Errorf("unexpected schemata (-want +got):\n%s", diff)
+			}
+			gr := Group{Schemata: got}
+			if cbm, ok := gr.L3CBM(0); !ok || cbm != 0xf {
+				t.Errorf("expected the data CBM, got %x (%v)", cbm, ok)
+			}
+		})
+	}
+}
+
+func mustWriteFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		t.Fatalf("cannot create dir for %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("cannot write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+const (
+	// maxBlockDevDepth bounds the walk through stacked block devices (e.g. LVM on top of MD RAID)
+	maxBlockDevDepth = 8
+)
+
+type mountInfo struct {
+	MajorMinor string
+	MountPoint string
+}
+
+// DiscoverDevicesFromMounts enumerates the PCI storage controllers backing the block devices mounted in the container.
+// Virtual filesystems (overlay, tmpfs, proc...) have no backing block device and are skipped.
+// Device mapper and MD devices are resolved to the PCI controllers of their underlying devices.
+func DiscoverDevicesFromMounts(env *environ.Environ) []DeviceInfo {
+	mountInfoPath := filepath.Join(env.Root.Proc, "self", "mountinfo")
+	mounts, err := readMountInfo(mountInfoPath)
+	if err != nil {
+		env.Log.V(1).Info("cannot read mount info, skipping", "path", mountInfoPath, "error", err)
+		return nil
+	}
+
+	var devices []DeviceInfo
+	domains := newDomainsScanner(env)
+	for _, mnt := range mounts {
+		if strings.HasPrefix(mnt.MajorMinor, "0:") {
+			// anonymous devices, used by virtual filesystems
+			continue
+		}
+		blockPath, err := filepath.EvalSymlinks(filepath.Join(env.Root.Sys, "dev", "block", mnt.MajorMinor))
+		if err != nil {
+			env.Log.V(4).Info("cannot find block device, skipping", "device", mnt.MajorMinor, "mountPoint", mnt.MountPoint, "error", err)
+			continue
+		}
+		blockDev := filepath.Base(blockPath)
+		addrs := blockDevPCIAddresses(env, blockPath, 0)
+		if len(addrs) == 0 {
+			env.Log.V(4).Info("block device without PCI device, skipping", "blockdev", blockDev, "mountPoint", mnt.MountPoint)
+			continue
+		}
+		for _, addr := range addrs {
+			if slices.ContainsFunc(devices, func(d DeviceInfo) bool { return d.PCIAddress == addr }) {
+				// containers usually have many bind mounts (e.g. /etc/hosts) from the same disk
+				devices = AppendDevices(devices, DeviceInfo{PCIAddress: addr, BlockDevs: []string{blockDev}, MountPoints: []string{mnt.MountPoint}})
+				continue
+			}
+			dev := resolveDevice(env, addr, domains)
+			dev.BlockDevs = []string{blockDev}
+			dev.MountPoints = []string{mnt.MountPoint}
+			devices = AppendDevices(devices, dev)
+			env.Log.V(2).Info("discovered device", "blockdev", blockDev, "mountPoint", mnt.MountPoint, "pciAddress", addr, "numaNode", dev.NUMANode, "pcieRoot", dev.PCIERoot, "localCPUs", dev.LocalCPUs.String())
+		}
+	}
+	return devices
+}
+
+// blockDevPCIAddresses returns the PCI addresses of the controllers backing the block device.
+// Partitions are nested below their disk, so the controller is found in their path as well.
+// Virtual block devices (dm, md) have no PCI device in their path, so we follow their slaves.
+func blockDevPCIAddresses(env *environ.Environ, blockPath string, depth int) []string {
+	if addr, ok := closestPCIAddress(blockPath); ok {
+		return []string{addr}
+	}
+	if depth >= maxBlockDevDepth {
+		env.Log.V(1).Info("too many stacked block devices, giving up", "path", blockPath)
+		return nil
+	}
+	entries, err := os.ReadDir(filepath.Join(blockPath, "slaves"))
+	if err != nil {
+		return nil
+	}
+	var addrs []string
+	for _, entry := range entries {
+		slavePath, err := filepath.EvalSymlinks(filepath.Join(blockPath, "slaves", entry.Name()))
+		if err != nil {
+			env.Log.V(4).Info("cannot resolve block device slave, skipping", "path", blockPath, "slave", entry.Name(), "error", err)
+			continue
+		}
+		for _, addr := range blockDevPCIAddresses(env, slavePath, depth+1) {
+			if !slices.Contains(addrs, addr) {
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	return addrs
+}
+
+// readMountInfo parses the subset of proc(5) mountinfo we need.
+// Format: mountID parentID major:minor root mountPoint options [optional fields...] - fsType source superOptions
+func readMountInfo(path string) ([]mountInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var mounts []mountInfo
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		mounts = append(mounts, mountInfo{
+			MajorMinor: fields[2],
+			MountPoint: unescapeMountPoint(fields[4]),
+		})
+	}
+	return mounts, scanner.Err()
+}
+
+// the kernel escapes space, tab, newline and backslash in the mount points using octal sequences
+var mountPointUnescaper = strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`)
+
+func unescapeMountPoint(s string) string {
+	return mountPointUnescaper.Replace(s)
+}
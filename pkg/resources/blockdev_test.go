@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+func TestDiscoverDevicesFromMounts(t *testing.T) {
+	rootDir := t.TempDir()
+	sysDir := filepath.Join(rootDir, "sys")
+	procDir := filepath.Join(rootDir, "proc")
+	env := &environ.Environ{
+		Root: environ.FS{Sys: sysDir, Proc: procDir},
+		Log:  environ.DefaultLog(),
+	}
+
+	mustMkdirAll(t, filepath.Join(sysDir, "dev", "block"))
+	mustMkdirAll(t, filepath.Join(sysDir, "bus", "pci", "devices"))
+
+	// NVMe namespace partition, the node root disk
+	nvme0Path := filepath.Join("devices", "pci0000:00", "0000:00:06.0", "0000:04:00.0")
+	mustWriteFile(t, filepath.Join(sysDir, nvme0Path, "numa_node"), "0\n")
+	mustMkdirAll(t, filepath.Join(sysDir, nvme0Path, "nvme", "nvme0", "nvme0n1", "nvme0n1p4"))
+	mustSymlink(t, filepath.Join("..", "..", nvme0Path, "nvme", "nvme0", "nvme0n1", "nvme0n1p4"), filepath.Join(sysDir, "dev", "block", "259:4"))
+	// another partition on the same namespace
+	mustMkdirAll(t, filepath.Join(sysDir, nvme0Path, "nvme", "nvme0", "nvme0n1", "nvme0n1p5"))
+	mustSymlink(t, filepath.Join("..", "..", nvme0Path, "nvme", "nvme0", "nvme0n1", "nvme0n1p5"), filepath.Join(sysDir, "dev", "block", "259:5"))
+	mustSymlink(t, filepath.Join("..", "..", "..", nvme0Path), filepath.Join(sysDir, "bus", "pci", "devices", "0000:04:00.0"))
+
+	// LVM volume striped over two NVMe namespaces on another NUMA node
+	var dmSlaves []string
+	for _, nvme := range []struct{ addr, ctrl string }{{"0000:85:00.0", "nvme1"}, {"0000:86:00.0", "nvme2"}} {
+		devPath := filepath.Join("devices", "pci0000:80", "0000:80:01.0", nvme.addr)
+		blockDev := nvme.ctrl + "n1"
+		mustWriteFile(t, filepath.Join(sysDir, devPath, "numa_node"), "1\n")
+		mustMkdirAll(t, filepath.Join(sysDir, devPath, "nvme", nvme.ctrl, blockDev))
+		mustSymlink(t, filepath.Join("..", "..", "..", devPath), filepath.Join(sysDir, "bus", "pci", "devices", nvme.addr))
+		dmSlaves = append(dmSlaves, filepath.Join(devPath, "nvme", nvme.ctrl, blockDev))
+	}
+	dmPath := filepath.Join("devices", "virtual", "block", "dm-0")
+	mustMkdirAll(t, filepath.Join(sysDir, dmPath, "slaves"))
+	for _, slavePath := range dmSlaves {
+		mustSymlink(t, filepath.Join("..", "..", "..", "..", "..", slavePath), filepath.Join(sysDir, dmPath, "slaves", filepath.Base(slavePath)))
+	}
+	mustSymlink(t, filepath.Join("..", "..", dmPath), filepath.Join(sysDir, "dev", "block", "253:0"))
+
+	// virtual block device with no slaves, e.g. a loop device
+	loopPath := filepath.Join("devices", "virtual", "block", "loop0")
+	mustMkdirAll(t, filepath.Join(sysDir, loopPath))
+	mustSymlink(t, filepath.Join("..", "..", loopPath), filepath.Join(sysDir, "dev", "block", "7:0"))
+
+	mustWriteFile(t, filepath.Join(procDir, "self", "mountinfo"), `1021 950 0:112 / / rw,relatime master:1 - overlay overlay rw,lowerdir=/var/lib/containers/storage/overlay/l/A
+1022 1021 0:115 / /proc rw,nosuid,nodev,noexec,relatime - proc proc rw
+1031 1021 259:4 /ostree/deploy/rhcos/var/lib/kubelet/pods/1234/etc-hosts /etc/hosts rw,relatime - xfs /dev/nvme0n1p4 rw,attr2,inode64
+1032 1021 259:4 /ostree/deploy/rhcos/var/lib/kubelet/pods/1234/containers/app/0 /dev/termination-log rw,relatime - xfs /dev/nvme0n1p4 rw,attr2,inode64
+1036 1021 259:5 /scratch /var/scratch rw,relatime - xfs /dev/nvme0n1p5 rw,attr2,inode64
+1033 1021 253:0 /data /var/lib/my\040data rw,relatime - xfs /dev/mapper/vg-data rw,attr2,inode64
+1034 1021 7:0 / /opt/image rw,relatime - squashfs /dev/loop0 ro
+1035 1021 8:0 / /mnt/missing rw,relatime - xfs /dev/sda rw
+`)
+
+	expected := []DeviceInfo{
+		{BlockDevs: []string{"nvme0n1p4", "nvme0n1p5"}, MountPoints: []string{"/etc/hosts", "/dev/termination-log", "/var/scratch"}, PCIAddress: "0000:04:00.0", NUMANode: 0, PCIERoot: "pci0000:00"},
+		{BlockDevs: []string{"dm-0"}, MountPoints: []string{"/var/lib/my data"}, PCIAddress: "0000:85:00.0", NUMANode: 1, PCIERoot: "pci0000:80"},
+		{BlockDevs: []string{"dm-0"}, MountPoints: []string{"/var/lib/my data"}, PCIAddress: "0000:86:00.0", NUMANode: 1, PCIERoot: "pci0000:80"},
+	}
+
+	got := DiscoverDevicesFromMounts(env)
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d devices, got %d: %+v", len(expected), len(got), got)
+	}
+	for i, exp := range expected {
+		if diff := cmp.Diff(exp.BlockDevs, got[i].BlockDevs); diff != "" {
+			t.Errorf("device[%d] BlockDevs mismatch (-want +got):\n%s", i, diff)
+		}
+		if diff := cmp.Diff(exp.MountPoints, got[i].MountPoints); diff != "" {
+			t.Errorf("device[%d] MountPoints mismatch (-want +got):\n%s", i, diff)
+		}
+		if got[i].PCIAddress != exp.PCIAddress {
+			t.Errorf("device[%d] PCIAddress: expected %q, got %q", i, exp.PCIAddress, got[i].PCIAddress)
+		}
+		if got[i].NUMANode != exp.NUMANode {
+			t.Errorf("device[%d] NUMANode: expected %d, got %d", i, exp.NUMANode, got[i].NUMANode)
+		}
+		if got[i].PCIERoot != exp.PCIERoot {
+			t.Errorf("device[%d] PCIERoot: expected %q, got %q", i, exp.PCIERoot, got[i].PCIERoot)
+		}
+	}
+}
+
+func TestDiscoverDevicesFromMountsMissingMountInfo(t *testing.T) {
+	env := &environ.Environ{
+		Root: environ.FS{Sys: t.TempDir(), Proc: t.TempDir()},
+		Log:  environ.DefaultLog(),
+	}
+	if got := DiscoverDevicesFromMounts(env); got != nil {
+		t.Errorf("expected no devices, got %+v", got)
+	}
+}
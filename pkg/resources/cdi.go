@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ffromani/ctrreschk/internal/deviceattribute"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+const (
+	DefaultCDISpecDir = "/var/run/cdi"
+)
+
+// we only need a tiny subset of the CDI spec, so we don't depend on the CDI packages.
+// Reference: https://github.com/cncf-tags/container-device-interface/blob/main/SPEC.md
+type cdiSpec struct {
+	Kind    string      `json:"kind"`
+	Devices []cdiDevice `json:"devices"`
+}
+
+type cdiDevice struct {
+	Name           string            `json:"name"`
+	Annotations    map[string]string `json:"annotations,omitempty"`
+	ContainerEdits cdiContainerEdits `json:"containerEdits"`
+}
+
+type cdiContainerEdits struct {
+	Env         []string        `json:"env,omitempty"`
+	DeviceNodes []cdiDeviceNode `json:"deviceNodes,omitempty"`
+}
+
+type cdiDeviceNode struct {
+	// Path is the device node path in the container
+	Path string `json:"path"`
+}
+
+// DiscoverDevicesFromCDI enumerates the PCI devices allocated to the container by DRA drivers.
+// A CDI device is deemed allocated to this container if all the env vars it injects are
+// found in the container environment and all the device nodes it injects exist under rootDir,
+// which is "/" when running in the container. These are the only traces the CDI injection
+// leaves inside the container: the CDI annotations end up in the OCI runtime spec, which the
+// container can't read, so they can't tell if a device was injected. Privileged containers see all
+// the host device nodes, so the devices injecting only device nodes are always deemed allocated there.
+// The PCI address is taken from the standard resource.kubernetes.io/pciBusID annotation
+// if present, otherwise from the injected env vars.
+func DiscoverDevicesFromCDI(env *environ.Environ, specDir, rootDir string, osEnviron []string) []DeviceInfo {
+	specPaths, err := filepath.Glob(filepath.Join(specDir, "*.json"))
+	if err != nil {
+		env.Log.V(1).Info("cannot list CDI specs, skipping", "dir", specDir, "error", err)
+		return nil
+	}
+	slices.Sort(specPaths)
+
+	var devices []DeviceInfo
+	domains := newDomainsScanner(env)
+	for _, specPath := range specPaths {
+		spec, err := readCDISpec(specPath)
+		if err != nil {
+			env.Log.V(1).Info("cannot read CDI spec, skipping", "path", specPath, "error", err)
+			continue
+		}
+		for _, cdiDev := range spec.Devices {
+			qualifiedName := spec.Kind + "=" + cdiDev.Name
+			if !isCDIDeviceInjected(cdiDev, rootDir, osEnviron) {
+				env.Log.V(4).Info("CDI device not injected, skipping", "device", qualifiedName)
+				continue
+			}
+			for _, addr := range cdiDevicePCIAddresses(cdiDev) {
+				dev := resolveDevice(env, addr, domains)
+				dev.CDIDevice = qualifiedName
+				devices = append(devices, dev)
+				env.Log.V(2).Info("discovered device", "cdiDevice", qualifiedName, "pciAddress", addr, "numaNode", dev.NUMANode, "pcieRoot", dev.PCIERoot, "localCPUs", dev.LocalCPUs.String())
+			}
+		}
+	}
+	return devices
+}
+
+func readCDISpec(specPath string) (cdiSpec, error) {
+	var spec cdiSpec
+	data, err := os.ReadFile(specPath)
+	if err != nil {
+		return spec, err
+	}
+	err = json.Unmarshal(data, &spec)
+	return spec, err
+}
+
+func isCDIDeviceInjected(cdiDev cdiDevice, rootDir string, osEnviron []string) bool {
+	if len(cdiDev.ContainerEdits.Env) == 0 && len(cdiDev.ContainerEdits.DeviceNodes) == 0 {
+		// nothing we can check, so we can't tell
+		return false
+	}
+	for _, entry := range cdiDev.ContainerEdits.Env {
+		if !slices.Contains(osEnviron, entry) {
+			return false
+		}
+	}
+	for _, node := range cdiDev.ContainerEdits.DeviceNodes {
+		if node.Path == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(rootDir, node.Path)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func cdiDevicePCIAddresses(cdiDev cdiDevice) []string {
+	if value, ok := cdiDev.Annotations[string(deviceattribute.StandardDeviceAttributePCIBusID)]; ok {
+		if _, err := deviceattribute.GetPCIBusIDAttribute(value); err == nil {
+			return []string{value}
+		}
+	}
+	var addrs []string
+	for _, entry := range cdiDev.ContainerEdits.Env {
+		_, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		for _, addr := range strings.Split(value, ",") {
+			addr = strings.TrimSpace(addr)
+			if _, err := deviceattribute.GetPCIBusIDAttribute(addr); err != nil {
+				continue
+			}
+			if !slices.Contains(addrs, addr) {
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	return addrs
+}
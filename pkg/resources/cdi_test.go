@@ -0,0 +1,192 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+const cdiSpecGPU = `{
+  "cdiVersion": "0.6.0",
+  "kind": "gpu.example.com/gpu",
+  "devices": [
+    {
+      "name": "gpu-0",
+      "annotations": {"resource.kubernetes.io/pciBusID": "0000:3b:00.0"},
+      "containerEdits": {"env": ["GPU_DEVICE_0=gpu-0"]}
+    },
+    {
+      "name": "gpu-1",
+      "annotations": {"resource.kubernetes.io/pciBusID": "0000:af:00.0"},
+      "containerEdits": {"env": ["GPU_DEVICE_1=gpu-1"]}
+    }
+  ]
+}`
+
+const cdiSpecNIC = `{
+  "cdiVersion": "0.6.0",
+  "kind": "nic.example.com/vf",
+  "devices": [
+    {
+      "name": "vf-0",
+      "containerEdits": {"env": ["NIC_VF_PCI_ADDRESS=0000:05:10.2", "NIC_VF_CLAIM=claim-a"]}
+    },
+    {
+      "name": "no-env",
+      "annotations": {"resource.kubernetes.io/pciBusID": "0000:05:10.4"},
+      "containerEdits": {}
+    }
+  ]
+}`
+
+const cdiSpecAccel = `{
+  "cdiVersion": "0.6.0",
+  "kind": "accel.example.com/accel",
+  "devices": [
+    {
+      "name": "accel-0",
+      "annotations": {"resource.kubernetes.io/pciBusID": "0000:3d:00.0"},
+      "containerEdits": {"deviceNodes": [{"path": "/dev/accel/accel0", "hostPath": "/dev/accel/accel0"}]}
+    },
+    {
+      "name": "accel-1",
+      "annotations": {"resource.kubernetes.io/pciBusID": "0000:3e:00.0"},
+      "containerEdits": {"env": ["ACCEL_VISIBLE_DEVICES=1"], "deviceNodes": [{"path": "/dev/accel/accel1"}]}
+    }
+  ]
+}`
+
+func TestDiscoverDevicesFromCDI(t *testing.T) {
+	testCases := []struct {
+		name     string
+		specs    map[string]string
+		environ  []string
+		devNodes []string
+		sysfs    map[string]string // pciAddress -> numa_node content
+		expected []DeviceInfo
+	}{
+		{
+			name:    "missing spec dir",
+			environ: []string{"GPU_DEVICE_0=gpu-0"},
+		},
+		{
+			name:    "malformed spec skipped",
+			specs:   map[string]string{"broken.json": "{", "gpu.json": cdiSpecGPU},
+			environ: []string{"GPU_DEVICE_0=gpu-0"},
+			sysfs:   map[string]string{"0000:3b:00.0": "0\n"},
+			expected: []DeviceInfo{
+				{CDIDevice: "gpu.example.com/gpu=gpu-0", PCIAddress: "0000:3b:00.0", NUMANode: 0},
+			},
+		},
+		{
+			name:    "address from annotation",
+			specs:   map[string]string{"gpu.json": cdiSpecGPU},
+			environ: []string{"GPU_DEVICE_1=gpu-1", "HOME=/"},
+			sysfs:   map[string]string{"0000:af:00.0": "1\n"},
+			expected: []DeviceInfo{
+				{CDIDevice: "gpu.example.com/gpu=gpu-1", PCIAddress: "0000:af:00.0", NUMANode: 1},
+			},
+		},
+		{
+			name:    "address from env",
+			specs:   map[string]string{"gpu.json": cdiSpecGPU, "nic.json": cdiSpecNIC},
+			environ: []string{"NIC_VF_PCI_ADDRESS=0000:05:10.2", "NIC_VF_CLAIM=claim-a"},
+			sysfs:   map[string]string{"0000:05:10.2": "0\n"},
+			expected: []DeviceInfo{
+				{CDIDevice: "nic.example.com/vf=vf-0", PCIAddress: "0000:05:10.2", NUMANode: 0},
+			},
+		},
+		{
+			name:    "partial env match is not injected",
+			specs:   map[string]string{"nic.json": cdiSpecNIC},
+			environ: []string{"NIC_VF_PCI_ADDRESS=0000:05:10.2", "NIC_VF_CLAIM=claim-b"},
+		},
+		{
+			name:     "device nodes",
+			specs:    map[string]string{"accel.json": cdiSpecAccel},
+			devNodes: []string{"/dev/accel/accel0", "/dev/accel/accel1"},
+			sysfs:    map[string]string{"0000:3d:00.0": "0\n"},
+			expected: []DeviceInfo{
+				{CDIDevice: "accel.example.com/accel=accel-0", PCIAddress: "0000:3d:00.0", NUMANode: 0},
+			},
+		},
+		{
+			name:     "device nodes and env",
+			specs:    map[string]string{"accel.json": cdiSpecAccel},
+			environ:  []string{"ACCEL_VISIBLE_DEVICES=1"},
+			devNodes: []string{"/dev/accel/accel1"},
+			sysfs:    map[string]string{"0000:3e:00.0": "1\n"},
+			expected: []DeviceInfo{
+				{CDIDevice: "accel.example.com/accel=accel-1", PCIAddress: "0000:3e:00.0", NUMANode: 1},
+			},
+		},
+		{
+			name:    "env without device nodes is not injected",
+			specs:   map[string]string{"accel.json": cdiSpecAccel},
+			environ: []string{"ACCEL_VISIBLE_DEVICES=1"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			env := &environ.Environ{
+				Root: environ.FS{Sys: filepath.Join(tmpDir, "sys")},
+				Log:  environ.DefaultLog(),
+			}
+
+			specDir := filepath.Join(tmpDir, "cdi")
+			if len(tt.specs) > 0 {
+				if err := os.MkdirAll(specDir, os.ModePerm); err != nil {
+					t.Fatalf("cannot create spec dir: %v", err)
+				}
+			}
+			for name, content := range tt.specs {
+				if err := os.WriteFile(filepath.Join(specDir, name), []byte(content), 0o644); err != nil {
+					t.Fatalf("cannot write CDI spec: %v", err)
+				}
+			}
+			for addr, content := range tt.sysfs {
+				devDir := filepath.Join(env.Root.Sys, "bus", "pci", "devices", addr)
+				if err := os.MkdirAll(devDir, os.ModePerm); err != nil {
+					t.Fatalf("cannot create sysfs dir: %v", err)
+				}
+				if err := os.WriteFile(filepath.Join(devDir, "numa_node"), []byte(content), 0o644); err != nil {
+					t.Fatalf("cannot write numa_node: %v", err)
+				}
+			}
+
+			rootDir := filepath.Join(tmpDir, "root")
+			for _, node := range tt.devNodes {
+				nodePath := filepath.Join(rootDir, node)
+				if err := os.MkdirAll(filepath.Dir(nodePath), os.ModePerm); err != nil {
+					t.Fatalf("cannot create dev dir: %v", err)
+				}
+				if err := os.WriteFile(nodePath, nil, 0o644); err != nil {
+					t.Fatalf("cannot create device node: %v", err)
+				}
+			}
+
+			got := DiscoverDevicesFromCDI(env, specDir, rootDir, tt.environ)
+
+			if len(got) != len(tt.expected) {
+				t.Fatalf("expected %d devices, got %d: %+v", len(tt.expected), len(got), got)
+			}
+			for i, exp := range tt.expected {
+				if got[i].CDIDevice != exp.CDIDevice {
+					t.Errorf("device[%d] CDIDevice: expected %q, got %q", i, exp.CDIDevice, got[i].CDIDevice)
+				}
+				if got[i].PCIAddress != exp.PCIAddress {
+					t.Errorf("device[%d] PCIAddress: expected %q, got %q", i, exp.PCIAddress, got[i].PCIAddress)
+				}
+				if got[i].NUMANode != exp.NUMANode {
+					t.Errorf("device[%d] NUMANode: expected %d, got %d", i, exp.NUMANode, got[i].NUMANode)
+				}
+			}
+		})
+	}
+}
@@ -3,16 +3,24 @@
 package resources
 
 import (
+	"cmp"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/ctrreschk/internal/deviceattribute"
+	"github.com/ffromani/ctrreschk/pkg/device"
 	"github.com/ffromani/ctrreschk/pkg/environ"
 )
 
 func DiscoverDevicesFromEnv(env *environ.Environ, osEnviron []string, prefixes []string) []DeviceInfo {
 	var devices []DeviceInfo
+	domains := newDomainsScanner(env)
 	for _, entry := range osEnviron {
 		name, value, ok := strings.Cut(entry, "=")
 		if !ok || value == "" {
@@ -26,18 +34,93 @@ func DiscoverDevicesFromEnv(env *environ.Environ, osEnviron []string, prefixes [
 			if addr == "" {
 				continue
 			}
-			numaNode := readDeviceNUMANode(env, addr)
-			devices = append(devices, DeviceInfo{
-				EnvVar:     name,
-				PCIAddress: addr,
-				NUMANode:   numaNode,
-			})
-			env.Log.V(2).Info("discovered device", "envVar", name, "pciAddress", addr, "numaNode", numaNode)
+			dev := resolveDevice(env, addr, domains)
+			dev.EnvVar = name
+			devices = append(devices, dev)
+			env.Log.V(2).Info("discovered device", "envVar", name, "pciAddress", addr, "numaNode", dev.NUMANode, "pcieRoot", dev.PCIERoot, "localCPUs", dev.LocalCPUs.String())
+		}
+	}
+	return devices
+}
+
+// AppendDevices appends the given devices to the list, merging the ones already present.
+// The same device can be found by more than a discovery method, so we need to deduplicate,
+// but we want to keep all the names the device is known by.
+func AppendDevices(devices []DeviceInfo, newDevices ...DeviceInfo) []DeviceInfo {
+	for _, dev := range newDevices {
+		idx := slices.IndexFunc(devices, func(d DeviceInfo) bool { return d.PCIAddress == dev.PCIAddress })
+		if idx == -1 {
+			devices = append(devices, dev)
+			continue
+		}
+		cur := &devices[idx]
+		cur.EnvVar = cmp.Or(cur.EnvVar, dev.EnvVar)
+		cur.CDIDevice = cmp.Or(cur.CDIDevice, dev.CDIDevice)
+		cur.Network = cmp.Or(cur.Network, dev.Network)
+		cur.NetDev = cmp.Or(cur.NetDev, dev.NetDev)
+		// the PhysFn* fields and VFIndex are meaningful only together, see DeviceInfo
+		if cur.PhysFn == "" && dev.PhysFn != "" {
+			cur.PhysFn = dev.PhysFn
+			cur.PhysFnNetDev = dev.PhysFnNetDev
+			cur.PhysFnNUMANode = dev.PhysFnNUMANode
+			cur.PhysFnPCIERoot = dev.PhysFnPCIERoot
+			cur.VFIndex = dev.VFIndex
+		} else if cur.PhysFn == dev.PhysFn {
+			cur.PhysFnNetDev = cmp.Or(cur.PhysFnNetDev, dev.PhysFnNetDev)
+			cur.PhysFnPCIERoot = cmp.Or(cur.PhysFnPCIERoot, dev.PhysFnPCIERoot)
+		}
+		for _, blockDev := range dev.BlockDevs {
+			if !slices.Contains(cur.BlockDevs, blockDev) {
+				cur.BlockDevs = append(cur.BlockDevs, blockDev)
+			}
+		}
+		for _, mountPoint := range dev.MountPoints {
+			if !slices.Contains(cur.MountPoints, mountPoint) {
+				cur.MountPoints = append(cur.MountPoints, mountPoint)
+			}
 		}
 	}
 	return devices
 }
 
+// newDomainsScanner returns a function which scans the PCIe domains on first call.
+// Scanning the domains is expensive, so we do that only if we have devices to check.
+func newDomainsScanner(env *environ.Environ) func() []device.PCIEDomain {
+	return sync.OnceValue(func() []device.PCIEDomain {
+		return scanPCIEDomains(env)
+	})
+}
+
+// resolveDevice fills the locality information of the device with the given PCI address.
+func resolveDevice(env *environ.Environ, pciAddress string, domains func() []device.PCIEDomain) DeviceInfo {
+	if _, err := os.Stat(filepath.Join(env.Root.Sys, "bus", "pci", "devices", pciAddress)); err != nil {
+		env.Log.V(1).Info("cannot find device", "pciAddress", pciAddress, "error", err)
+		return DeviceInfo{
+			PCIAddress: pciAddress,
+			NUMANode:   -1,
+			VFIndex:    -1,
+			NotFound:   true,
+		}
+	}
+	pcieRoot := readDevicePCIERoot(env, pciAddress)
+	localCPUs := domainLocalCPUs(domains(), pcieRoot)
+	if localCPUs.IsEmpty() {
+		localCPUs = readDeviceLocalCPUs(env, pciAddress)
+	}
+	dev := DeviceInfo{
+		PCIAddress: pciAddress,
+		Driver:     readDeviceDriver(env, pciAddress),
+		NUMANode:   readDeviceNUMANode(env, pciAddress),
+		PCIERoot:   pcieRoot,
+		LocalCPUs:  localCPUs,
+		PCIEPath:   readDevicePCIEPath(env, pciAddress),
+		PhysFn:     readDevicePhysFn(env, pciAddress),
+		VFIndex:    -1,
+	}
+	resolvePhysFn(env, &dev)
+	return dev
+}
+
 func matchesAnyPrefix(name string, prefixes []string) bool {
 	for _, prefix := range prefixes {
 		if strings.HasPrefix(name, prefix) {
@@ -47,6 +130,74 @@ func matchesAnyPrefix(name string, prefixes []string) bool {
 	return false
 }
 
+func scanPCIEDomains(env *environ.Environ) []device.PCIEDomain {
+	sysfs, ok := os.DirFS(env.Root.Sys).(device.SysFS)
+	if !ok {
+		return nil
+	}
+	domains, err := device.PCIEDomainsFromFS(env.Log, sysfs)
+	if err != nil {
+		env.Log.V(1).Info("cannot scan PCIe domains, skipping", "error", err)
+		return nil
+	}
+	return domains
+}
+
+func domainLocalCPUs(domains []device.PCIEDomain, pcieRoot string) cpuset.CPUSet {
+	for _, dom := range domains {
+		if dom.Root() == pcieRoot {
+			return dom.LocalCPUs
+		}
+	}
+	return cpuset.New()
+}
+
+func readDevicePCIERoot(env *environ.Environ, pciAddress string) string {
+	attr, err := deviceattribute.GetPCIeRootAttributeByPCIBusID(pciAddress, deviceattribute.WithFSFromRoot(env.Root.Sys))
+	if err != nil {
+		env.Log.V(1).Info("cannot resolve device PCIe root, skipping", "pciAddress", pciAddress, "error", err)
+		return ""
+	}
+	return *attr.Value.StringValue
+}
+
+func readDeviceDriver(env *environ.Environ, pciAddress string) string {
+	target, err := os.Readlink(filepath.Join(env.Root.Sys, "bus", "pci", "devices", pciAddress, "driver"))
+	if err != nil {
+		env.Log.V(4).Info("cannot read device driver, skipping", "pciAddress", pciAddress, "error", err)
+		return ""
+	}
+	return filepath.Base(target)
+}
+
+func readDevicePCIEPath(env *environ.Environ, pciAddress string) []string {
+	sysfs, ok := os.DirFS(env.Root.Sys).(device.SysFS)
+	if !ok {
+		return nil
+	}
+	devPath, err := device.PCIEDevicePath(sysfs, pciAddress)
+	if err != nil {
+		env.Log.V(1).Info("cannot resolve device PCIe path, skipping", "pciAddress", pciAddress, "error", err)
+		return nil
+	}
+	return devPath
+}
+
+func readDeviceLocalCPUs(env *environ.Environ, pciAddress string) cpuset.CPUSet {
+	cpusPath := filepath.Join(env.Root.Sys, "bus", "pci", "devices", pciAddress, "local_cpulist")
+	data, err := os.ReadFile(cpusPath)
+	if err != nil {
+		env.Log.V(1).Info("cannot read device local CPUs, skipping", "pciAddress", pciAddress, "error", err)
+		return cpuset.New()
+	}
+	cpus, err := cpuset.Parse(strings.TrimSpace(string(data)))
+	if err != nil {
+		env.Log.V(1).Info("cannot parse device local CPUs, skipping", "pciAddress", pciAddress, "error", err)
+		return cpuset.New()
+	}
+	return cpus
+}
+
 func readDeviceNUMANode(env *environ.Environ, pciAddress string) int {
 	numaPath := filepath.Join(env.Root.Sys, "bus", "pci", "devices", pciAddress, "numa_node")
 	data, err := os.ReadFile(numaPath)
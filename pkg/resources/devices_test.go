@@ -7,6 +7,9 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/utils/cpuset"
+
 	"github.com/ffromani/ctrreschk/pkg/environ"
 )
 
@@ -49,7 +52,7 @@ func TestDiscoverDevicesFromEnv(t *testing.T) {
 			prefixes: []string{"SRIOVNETWORK_VF_"},
 			sysfs:    map[string]string{},
 			expected: []DeviceInfo{
-				{EnvVar: "SRIOVNETWORK_VF_DEV", PCIAddress: "0000:99:00.0", NUMANode: -1},
+				{EnvVar: "SRIOVNETWORK_VF_DEV", PCIAddress: "0000:99:00.0", NUMANode: -1, NotFound: true},
 			},
 		},
 		{
@@ -112,7 +115,107 @@ func TestDiscoverDevicesFromEnv(t *testing.T) {
 				if got[i].NUMANode != exp.NUMANode {
 					t.Errorf("device[%d] NUMANode: expected %d, got %d", i, exp.NUMANode, got[i].NUMANode)
 				}
+				if got[i].NotFound != exp.NotFound {
+					t.Errorf("device[%d] NotFound: expected %v, got %v", i, exp.NotFound, got[i].NotFound)
+				}
 			}
 		})
 	}
 }
+
+func TestDiscoverDevicesFromEnvPCIERoot(t *testing.T) {
+	tmpDir := t.TempDir()
+	env := &environ.Environ{
+		Root: environ.FS{Sys: tmpDir},
+		Log:  environ.DefaultLog(),
+	}
+
+	sysDevices := map[string]map[string]string{
+		filepath.Join("pci0000:00", "0000:00:06.0"): {
+			"class":         "0x060400\n",
+			"numa_node":     "0\n",
+			"local_cpulist": "0-3\n",
+		},
+		filepath.Join("pci0000:00", "0000:00:06.0", "0000:04:00.0"): {
+			"class":         "0x020000\n",
+			"numa_node":     "-1\n",
+			"local_cpulist": "0-7\n",
+		},
+	}
+	busDir := filepath.Join(tmpDir, "bus", "pci", "devices")
+	if err := os.MkdirAll(busDir, os.ModePerm); err != nil {
+		t.Fatalf("cannot create sysfs dir: %v", err)
+	}
+	for devPath, files := range sysDevices {
+		devDir := filepath.Join(tmpDir, "devices", devPath)
+		if err := os.MkdirAll(devDir, os.ModePerm); err != nil {
+			t.Fatalf("cannot create sysfs dir: %v", err)
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(devDir, name), []byte(content), 0o644); err != nil {
+				t.Fatalf("cannot write %s: %v", name, err)
+			}
+		}
+		if err := os.Symlink(filepath.Join("..", "..", "..", "devices", devPath), filepath.Join(busDir, filepath.Base(devPath))); err != nil {
+			t.Fatalf("cannot create sysfs symlink: %v", err)
+		}
+	}
+
+	got := DiscoverDevicesFromEnv(env, []string{"PCIDEVICE_NIC=0000:04:00.0"}, []string{"PCIDEVICE_"})
+	if len(got) != 1 {
+		t.Fatalf("expected 1 device, got %d: %+v", len(got), got)
+	}
+	if got[0].NUMANode != -1 {
+		t.Errorf("NUMANode: expected -1, got %d", got[0].NUMANode)
+	}
+	if got[0].PCIERoot != "pci0000:00" {
+		t.Errorf("PCIERoot: expected %q, got %q", "pci0000:00", got[0].PCIERoot)
+	}
+	if diff := cmp.Diff([]string{"pci0000:00", "0000:00:06.0", "0000:04:00.0"}, got[0].PCIEPath); diff != "" {
+		t.Errorf("PCIEPath mismatch (-want +got):\n%s", diff)
+	}
+	// the domain local CPUs take precedence over the device local CPUs
+	if expected := cpuset.New(0, 1, 2, 3); !got[0].LocalCPUs.Equals(expected) {
+		t.Errorf("LocalCPUs: expected %v, got %v", expected, got[0].LocalCPUs)
+	}
+}
+
+func TestAppendDevices(t *testing.T) {
+	devices := []DeviceInfo{{EnvVar: "PCIDEVICE_A", PCIAddress: "0000:05:10.2"}}
+	got := AppendDevices(devices,
+		DeviceInfo{CDIDevice: "nic.example.com/vf=vf-0", PCIAddress: "0000:05:10.2"},
+		DeviceInfo{CDIDevice: "nic.example.com/vf=vf-1", PCIAddress: "0000:05:10.4"},
+		DeviceInfo{Network: "default/sriov-net", NetDev: "net1", PCIAddress: "0000:05:10.4"},
+		DeviceInfo{
+			NetDev:         "net2",
+			PCIAddress:     "0000:05:10.2",
+			PhysFn:         "0000:05:00.0",
+			PhysFnNetDev:   "ens1f0",
+			PhysFnNUMANode: 1,
+			PhysFnPCIERoot: "pci0000:05",
+			VFIndex:        2,
+		},
+	)
+	expected := []DeviceInfo{
+		{
+			EnvVar:         "PCIDEVICE_A",
+			CDIDevice:      "nic.example.com/vf=vf-0",
+			NetDev:         "net2",
+			PCIAddress:     "0000:05:10.2",
+			PhysFn:         "0000:05:00.0",
+			PhysFnNetDev:   "ens1f0",
+			PhysFnNUMANode: 1,
+			PhysFnPCIERoot: "pci0000:05",
+			VFIndex:        2,
+		},
+		{CDIDevice: "nic.example.com/vf=vf-1", Network: "default/sriov-net", NetDev: "net1", PCIAddress: "0000:05:10.4"},
+	}
+	if diff := cmp.Diff(expected, got, cpuSetComparer); diff != "" {
+		t.Errorf("devices mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// cpuSetComparer lets cmp.Diff use cpuset.CPUSet.Equals, avoiding unexported values.
+var cpuSetComparer = cmp.Comparer(func(a, b cpuset.CPUSet) bool {
+	return a.Equals(b)
+})
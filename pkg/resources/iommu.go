@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	"github.com/ffromani/ctrreschk/pkg/device"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+// DiscoverIOMMUGroups reports the IOMMU groups matching either the given group IDs, typically from DiscoverVFIOGroups,
+// or the groups of the devices with the given PCI addresses. The group IDs and the addresses which can't be resolved
+// are reported as unresolved.
+func DiscoverIOMMUGroups(env *environ.Environ, groupIDs, addrs []string) (apiv0.IOMMUInfo, error) {
+	sysfs := os.DirFS(env.Root.Sys).(device.SysFS)
+	groups, err := device.IOMMUGroupsFromFS(env.Log, sysfs)
+	if err != nil {
+		return apiv0.IOMMUInfo{}, fmt.Errorf("failed to scan the IOMMU groups: %w", err)
+	}
+
+	info := apiv0.IOMMUInfo{}
+	wanted := make(map[string]bool)
+	// the container got the whole group opened through vfio, so all its devices are allocated
+	opened := make(map[string]bool)
+	for _, groupID := range groupIDs {
+		if _, ok := groups[groupID]; !ok {
+			info.Unresolved = append(info.Unresolved, groupID)
+			continue
+		}
+		wanted[groupID] = true
+		opened[groupID] = true
+	}
+	for _, addr := range addrs {
+		groupID, err := device.DeviceIOMMUGroup(sysfs, addr)
+		if err != nil {
+			env.Log.V(1).Info("cannot find device IOMMU group, skipping", "address", addr, "error", err)
+			info.Unresolved = append(info.Unresolved, addr)
+			continue
+		}
+		wanted[groupID] = true
+	}
+
+	var wantedIDs []string
+	for groupID := range wanted {
+		wantedIDs = append(wantedIDs, groupID)
+	}
+	slices.SortFunc(wantedIDs, func(a, b string) int {
+		// group IDs are integers in sysfs
+		x, _ := strconv.Atoi(a)
+		y, _ := strconv.Atoi(b)
+		return x - y
+	})
+	for _, groupID := range wantedIDs {
+		info.Groups = append(info.Groups, iommuGroupInfo(groups[groupID], addrs, opened[groupID]))
+	}
+	return info, nil
+}
+
+// iommuGroupInfo marks as allocated the devices with the given addresses, or all of them if the group is opened.
+func iommuGroupInfo(grp device.IOMMUGroup, allocated []string, opened bool) apiv0.IOMMUGroupInfo {
+	info := apiv0.IOMMUGroupInfo{
+		Group:  grp.ID,
+		Viable: grp.Viable(),
+	}
+	for _, dev := range grp.Devices {
+		info.Devices = append(info.Devices, apiv0.IOMMUDeviceInfo{
+			Address:    dev.Address,
+			ClassID:    dev.ClassID,
+			SubclassID: dev.SubclassID,
+			Driver:     dev.Driver,
+			VFIO:       dev.IsVFIOBound(),
+			NUMANode:   dev.NUMANode,
+			PCIERoot:   dev.PCIERoot,
+			Allocated:  opened || slices.Contains(allocated, dev.Address),
+		})
+	}
+	return info
+}
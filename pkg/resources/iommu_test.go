@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	"github.com/ffromani/ctrreschk/pkg/device"
+)
+
+func TestIOMMUGroupInfo(t *testing.T) {
+	grp := device.IOMMUGroup{
+		ID: "12",
+		Devices: []device.IOMMUGroupDevice{
+			{PCIEDevice: device.PCIEDevice{Address: "0000:41:00.0", ClassID: "03", SubclassID: "02"}, Driver: "vfio-pci", NUMANode: 0, PCIERoot: "pci0000:40"},
+			{PCIEDevice: device.PCIEDevice{Address: "0000:41:00.1", ClassID: "04", SubclassID: "03"}, Driver: "vfio-pci", NUMANode: 0, PCIERoot: "pci0000:40"},
+		},
+	}
+
+	testCases := []struct {
+		name      string
+		allocated []string
+		opened    bool
+		expected  []bool
+	}{
+		{
+			name:     "not allocated",
+			expected: []bool{false, false},
+		},
+		{
+			name:      "device allocated through env",
+			allocated: []string{"0000:41:00.0"},
+			expected:  []bool{true, false},
+		},
+		{
+			name:     "group opened through vfio",
+			opened:   true,
+			expected: []bool{true, true},
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := iommuGroupInfo(grp, tt.allocated, tt.opened)
+			if got.Group != "12" || !got.Viable || len(got.Devices) != 2 {
+				t.Fatalf("unexpected group: %+v", got)
+			}
+			expectedDev := apiv0.IOMMUDeviceInfo{Address: "0000:41:00.0", ClassID: "03", SubclassID: "02", Driver: "vfio-pci", VFIO: true, PCIERoot: "pci0000:40", Allocated: tt.expected[0]}
+			if diff := cmp.Diff(expectedDev, got.Devices[0]); diff != "" {
+				t.Errorf("unexpected device (-want +got):\n%s", diff)
+			}
+			if got.Devices[1].Allocated != tt.expected[1] {
+				t.Errorf("expected allocated=%v for %s", tt.expected[1], got.Devices[1].Address)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ffromani/ctrreschk/internal/deviceattribute"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+// DiscoverDevicesFromNetDevs enumerates the PCI devices backing the network interfaces of the container.
+// sysfs reflects the network namespace it was mounted in, so we only see the container interfaces.
+// Virtual interfaces (loopback, veth...) have no backing device and are skipped.
+func DiscoverDevicesFromNetDevs(env *environ.Environ) []DeviceInfo {
+	netDir := filepath.Join(env.Root.Sys, "class", "net")
+	entries, err := os.ReadDir(netDir)
+	if err != nil {
+		env.Log.V(1).Info("cannot list network interfaces, skipping", "dir", netDir, "error", err)
+		return nil
+	}
+
+	var devices []DeviceInfo
+	domains := newDomainsScanner(env)
+	for _, entry := range entries {
+		name := entry.Name()
+		addr, ok := netDevPCIAddress(env, name)
+		if !ok {
+			env.Log.V(4).Info("network interface without PCI device, skipping", "netdev", name)
+			continue
+		}
+		dev := resolveDevice(env, addr, domains)
+		dev.NetDev = name
+		devices = append(devices, dev)
+		env.Log.V(2).Info("discovered device", "netdev", name, "pciAddress", addr, "physfn", dev.PhysFn, "numaNode", dev.NUMANode, "pcieRoot", dev.PCIERoot, "localCPUs", dev.LocalCPUs.String())
+	}
+	return devices
+}
+
+// netDevPCIAddress returns the PCI address of the device backing the network interface.
+// The device link doesn't always point to a PCI device: for example with virtio the link points
+// to the virtio device, whose parent is the PCI device, so we look for the closest PCI device in the path.
+func netDevPCIAddress(env *environ.Environ, netDev string) (string, bool) {
+	devPath, err := filepath.EvalSymlinks(filepath.Join(env.Root.Sys, "class", "net", netDev, "device"))
+	if err != nil {
+		return "", false
+	}
+	return closestPCIAddress(devPath)
+}
+
+// closestPCIAddress returns the last PCI address found in the given sysfs device path.
+func closestPCIAddress(devPath string) (string, bool) {
+	parts := strings.Split(devPath, string(filepath.Separator))
+	for _, part := range slices.Backward(parts) {
+		if _, err := deviceattribute.GetPCIBusIDAttribute(part); err == nil {
+			return part, true
+		}
+	}
+	return "", false
+}
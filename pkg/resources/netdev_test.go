@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+func TestDiscoverDevicesFromNetDevs(t *testing.T) {
+	tmpDir := t.TempDir()
+	env := &environ.Environ{
+		Root: environ.FS{Sys: tmpDir},
+		Log:  environ.DefaultLog(),
+	}
+
+	mustMkdirAll(t, filepath.Join(tmpDir, "class", "net"))
+	mustMkdirAll(t, filepath.Join(tmpDir, "bus", "pci", "devices"))
+
+	// SR-IOV VF
+	vfPath := filepath.Join("devices", "pci0000:00", "0000:00:02.0", "0000:05:10.2")
+	mustWriteFile(t, filepath.Join(tmpDir, vfPath, "numa_node"), "1\n")
+	mustMkdirAll(t, filepath.Join(tmpDir, vfPath, "net", "net1"))
+	mustSymlink(t, filepath.Join("..", "..", "..", "0000:05:10.2"), filepath.Join(tmpDir, vfPath, "net", "net1", "device"))
+	mustSymlink(t, filepath.Join("..", "0000:05:00.0"), filepath.Join(tmpDir, vfPath, "physfn"))
+	mustSymlink(t, filepath.Join("..", "..", vfPath, "net", "net1"), filepath.Join(tmpDir, "class", "net", "net1"))
+	mustSymlink(t, filepath.Join("..", "..", "..", vfPath), filepath.Join(tmpDir, "bus", "pci", "devices", "0000:05:10.2"))
+
+	// virtio: the device link points to the virtio device, not to the PCI device
+	virtioPath := filepath.Join("devices", "pci0000:00", "0000:00:03.0")
+	mustWriteFile(t, filepath.Join(tmpDir, virtioPath, "numa_node"), "-1\n")
+	mustMkdirAll(t, filepath.Join(tmpDir, virtioPath, "virtio1", "net", "eth0"))
+	mustSymlink(t, filepath.Join("..", "..", "..", "virtio1"), filepath.Join(tmpDir, virtioPath, "virtio1", "net", "eth0", "device"))
+	mustSymlink(t, filepath.Join("..", "..", virtioPath, "virtio1", "net", "eth0"), filepath.Join(tmpDir, "class", "net", "eth0"))
+	mustSymlink(t, filepath.Join("..", "..", "..", virtioPath), filepath.Join(tmpDir, "bus", "pci", "devices", "0000:00:03.0"))
+
+	// loopback: no backing device
+	mustMkdirAll(t, filepath.Join(tmpDir, "devices", "virtual", "net", "lo"))
+	mustSymlink(t, filepath.Join("..", "..", "devices", "virtual", "net", "lo"), filepath.Join(tmpDir, "class", "net", "lo"))
+
+	expected := []DeviceInfo{
+		{NetDev: "eth0", PCIAddress: "0000:00:03.0", NUMANode: -1, PCIERoot: "pci0000:00"},
+		{NetDev: "net1", PCIAddress: "0000:05:10.2", NUMANode: 1, PCIERoot: "pci0000:00", PhysFn: "0000:05:00.0"},
+	}
+
+	got := DiscoverDevicesFromNetDevs(env)
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d devices, got %d: %+v", len(expected), len(got), got)
+	}
+	for i, exp := range expected {
+		if got[i].NetDev != exp.NetDev {
+			t.Errorf("device[%d] NetDev: expected %q, got %q", i, exp.NetDev, got[i].NetDev)
+		}
+		if got[i].PCIAddress != exp.PCIAddress {
+			t.Errorf("device[%d] PCIAddress: expected %q, got %q", i, exp.PCIAddress, got[i].PCIAddress)
+		}
+		if got[i].NUMANode != exp.NUMANode {
+			t.Errorf("device[%d] NUMANode: expected %d, got %d", i, exp.NUMANode, got[i].NUMANode)
+		}
+		if got[i].PCIERoot != exp.PCIERoot {
+			t.Errorf("device[%d] PCIERoot: expected %q, got %q", i, exp.PCIERoot, got[i].PCIERoot)
+		}
+		if got[i].PhysFn != exp.PhysFn {
+			t.Errorf("device[%d] PhysFn: expected %q, got %q", i, exp.PhysFn, got[i].PhysFn)
+		}
+	}
+}
+
+func mustMkdirAll(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		t.Fatalf("cannot create dir %s: %v", path, err)
+	}
+}
+
+func mustWriteFile(t *testing.T, path, content string) {
+	t.Helper()
+	mustMkdirAll(t, filepath.Dir(path))
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("cannot write %s: %v", path, err)
+	}
+}
+
+func mustSymlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatalf("cannot create symlink %s -> %s: %v", link, target, err)
+	}
+}
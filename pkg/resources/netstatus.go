@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ffromani/ctrreschk/internal/deviceattribute"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+const (
+	NetworkStatusAnnotation = "k8s.v1.cni.cncf.io/network-status"
+)
+
+// we only need a tiny subset of the network-status annotation, so we don't depend on the multus packages.
+// Reference: https://github.com/k8snetworkplumbingwg/device-info-spec
+type networkStatus struct {
+	Name       string             `json:"name"`
+	Interface  string             `json:"interface,omitempty"`
+	DeviceInfo *networkDeviceInfo `json:"device-info,omitempty"`
+}
+
+type networkDeviceInfo struct {
+	Type string                `json:"type"`
+	PCI  *networkPCIDeviceInfo `json:"pci,omitempty"`
+}
+
+type networkPCIDeviceInfo struct {
+	PCIAddress   string `json:"pci-address,omitempty"`
+	PFPCIAddress string `json:"pf-pci-address,omitempty"`
+}
+
+// DiscoverDevicesFromNetworkStatus enumerates the PCI devices from the network-status annotation
+// published by multus. The annotation is expected to be exposed through a downward API volume,
+// either projected alone (the file contains just the JSON value) or as part of all the pod
+// annotations (the file contains one key="value" pair per line).
+func DiscoverDevicesFromNetworkStatus(env *environ.Environ, path string) []DeviceInfo {
+	statuses, err := readNetworkStatus(path)
+	if err != nil {
+		env.Log.V(1).Info("cannot read network status, skipping", "path", path, "error", err)
+		return nil
+	}
+
+	var devices []DeviceInfo
+	domains := newDomainsScanner(env)
+	for _, status := range statuses {
+		if status.DeviceInfo == nil || status.DeviceInfo.PCI == nil {
+			env.Log.V(4).Info("network without PCI device, skipping", "network", status.Name)
+			continue
+		}
+		addr := status.DeviceInfo.PCI.PCIAddress
+		if _, err := deviceattribute.GetPCIBusIDAttribute(addr); err != nil {
+			env.Log.V(1).Info("invalid network PCI address, skipping", "network", status.Name, "error", err)
+			continue
+		}
+		dev := resolveDevice(env, addr, domains)
+		dev.Network = status.Name
+		dev.NetDev = status.Interface
+		// sysfs is the source of truth, but it may not be fully available inside the container
+		if pfAddr := status.DeviceInfo.PCI.PFPCIAddress; dev.PhysFn == "" && pfAddr != "" {
+			dev.PhysFn = pfAddr
+			resolvePhysFn(env, &dev)
+		}
+		devices = append(devices, dev)
+		env.Log.V(2).Info("discovered device", "network", status.Name, "netdev", status.Interface, "pciAddress", addr, "physfn", dev.PhysFn, "numaNode", dev.NUMANode, "pcieRoot", dev.PCIERoot, "localCPUs", dev.LocalCPUs.String())
+	}
+	return devices
+}
+
+func readNetworkStatus(path string) ([]networkStatus, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	content := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(content, "[") {
+		content, err = findAnnotation(content, NetworkStatusAnnotation)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var statuses []networkStatus
+	err = json.Unmarshal([]byte(content), &statuses)
+	return statuses, err
+}
+
+// findAnnotation extracts the value of the given annotation from the downward API annotations file format.
+func findAnnotation(content, name string) (string, error) {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	// the network status can be pretty long, and the annotations file has it on a single line
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok || key != name {
+			continue
+		}
+		return strconv.Unquote(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("annotation %q not found", name)
+}
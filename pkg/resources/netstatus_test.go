@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+const networkStatusJSON = `[{
+    "name": "ovn-kubernetes",
+    "interface": "eth0",
+    "ips": ["10.128.2.15"],
+    "default": true
+},{
+    "name": "default/sriov-net-a",
+    "interface": "net1",
+    "device-info": {
+        "type": "pci",
+        "version": "1.1.0",
+        "pci": {"pci-address": "0000:05:10.2", "pf-pci-address": "0000:05:00.0"}
+    }
+},{
+    "name": "default/sriov-net-b",
+    "interface": "net2",
+    "device-info": {
+        "type": "pci",
+        "version": "1.1.0",
+        "pci": {"pci-address": "0000:86:02.1"}
+    }
+}]`
+
+func TestDiscoverDevicesFromNetworkStatus(t *testing.T) {
+	expected := []DeviceInfo{
+		{Network: "default/sriov-net-a", NetDev: "net1", PCIAddress: "0000:05:10.2", NUMANode: 0, PhysFn: "0000:05:00.0"},
+		{Network: "default/sriov-net-b", NetDev: "net2", PCIAddress: "0000:86:02.1", NUMANode: 1, PhysFn: "0000:86:00.0"},
+	}
+
+	testCases := []struct {
+		name     string
+		content  string
+		expected []DeviceInfo
+	}{
+		{
+			name:    "missing file",
+			content: "",
+		},
+		{
+			name:    "malformed content",
+			content: "[{",
+		},
+		{
+			name:    "annotation not found",
+			content: `kubernetes.io/config.source="api"` + "\n",
+		},
+		{
+			name:     "single annotation",
+			content:  networkStatusJSON,
+			expected: expected,
+		},
+		{
+			name: "all annotations",
+			content: `k8s.v1.cni.cncf.io/network-status=` + strconv.Quote(networkStatusJSON) + "\n" +
+				`kubernetes.io/config.source="api"` + "\n",
+			expected: expected,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			env := &environ.Environ{
+				Root: environ.FS{Sys: filepath.Join(tmpDir, "sys")},
+				Log:  environ.DefaultLog(),
+			}
+			mustWriteFile(t, filepath.Join(env.Root.Sys, "bus", "pci", "devices", "0000:05:10.2", "numa_node"), "0\n")
+			mustWriteFile(t, filepath.Join(env.Root.Sys, "bus", "pci", "devices", "0000:86:02.1", "numa_node"), "1\n")
+			mustSymlink(t, filepath.Join("..", "0000:86:00.0"), filepath.Join(env.Root.Sys, "bus", "pci", "devices", "0000:86:02.1", "physfn"))
+
+			statusPath := filepath.Join(tmpDir, "podinfo", "annotations")
+			if tt.content != "" {
+				mustWriteFile(t, statusPath, tt.content)
+			}
+
+			got := DiscoverDevicesFromNetworkStatus(env, statusPath)
+
+			if len(got) != len(tt.expected) {
+				t.Fatalf("expected %d devices, got %d: %+v", len(tt.expected), len(got), got)
+			}
+			for i, exp := range tt.expected {
+				if got[i].Network != exp.Network {
+					t.Errorf("device[%d] Network: expected %q, got %q", i, exp.Network, got[i].Network)
+				}
+				if got[i].NetDev != exp.NetDev {
+					t.Errorf("device[%d] NetDev: expected %q, got %q", i, exp.NetDev, got[i].NetDev)
+				}
+				if got[i].PCIAddress != exp.PCIAddress {
+					t.Errorf("device[%d] PCIAddress: expected %q, got %q", i, exp.PCIAddress, got[i].PCIAddress)
+				}
+				if got[i].NUMANode != exp.NUMANode {
+					t.Errorf("device[%d] NUMANode: expected %d, got %d", i, exp.NUMANode, got[i].NUMANode)
+				}
+				if got[i].PhysFn != exp.PhysFn {
+					t.Errorf("device[%d] PhysFn: expected %q, got %q", i, exp.PhysFn, got[i].PhysFn)
+				}
+			}
+		})
+	}
+}
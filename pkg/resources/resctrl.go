@@ -0,0 +1,25 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"github.com/ffromani/ctrreschk/pkg/cgroups"
+	"github.com/ffromani/ctrreschk/pkg/environ"
+	"github.com/ffromani/ctrreschk/pkg/resctrl"
+)
+
+// DiscoverResctrl returns the resctrl groups the container threads are assigned to,
+// or nil if resctrl is not available, e.g. not mounted in the container.
+func DiscoverResctrl(env *environ.Environ) *resctrl.Info {
+	tids, err := cgroups.Threads(env)
+	if err != nil {
+		env.Log.V(1).Info("cannot read the container threads, skipping resctrl", "error", err)
+		return nil
+	}
+	info, err := resctrl.Read(env, tids)
+	if err != nil {
+		env.Log.V(1).Info("cannot read resctrl, skipping", "error", err)
+		return nil
+	}
+	return &info
+}
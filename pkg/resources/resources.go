@@ -17,22 +17,64 @@
 package resources
 
 import (
+	"errors"
+	"io/fs"
+
 	"k8s.io/utils/cpuset"
 
 	"github.com/ffromani/ctrreschk/pkg/cgroups"
 	"github.com/ffromani/ctrreschk/pkg/environ"
+	"github.com/ffromani/ctrreschk/pkg/resctrl"
 )
 
 type DeviceInfo struct {
-	EnvVar     string
-	PCIAddress string
-	NUMANode   int // -1 if unknown
+	EnvVar string
+	// CDIDevice is the fully qualified CDI device name (vendor.com/class=name), if discovered through CDI
+	CDIDevice string
+	// Network is the name of the network attachment, if discovered through the network-status annotation
+	Network string
+	// NetDev is the network interface name, if discovered through the container network interfaces
+	// or the network-status annotation
+	NetDev string
+	// BlockDevs are the block device names (e.g. nvme0n1p1, dm-0), if discovered through the container mounts
+	BlockDevs []string
+	// MountPoints are the container mount points backed by BlockDevs
+	MountPoints []string
+	PCIAddress  string
+	// NotFound is true if the device is not in sysfs, e.g. because of a stale or mistyped address.
+	// All the other attributes are unknown.
+	NotFound bool
+	// Driver is the kernel driver the device is bound to, e.g. vfio-pci, empty if unbound
+	Driver   string
+	NUMANode int    // -1 if unknown
+	PCIERoot string // empty if unknown
+	// LocalCPUs are the CPUs local to the PCIe root complex of the device, empty if unknown
+	LocalCPUs cpuset.CPUSet
+	// PCIEPath lists the sysfs devices from the PCIe root complex down to the device itself, empty if unknown
+	PCIEPath []string
+	// PhysFn is the PCI address of the SR-IOV physical function, empty if the device is not a virtual function.
+	// All the PhysFn* fields and VFIndex are meaningful only if PhysFn is not empty.
+	PhysFn         string
+	PhysFnNetDev   string
+	PhysFnNUMANode int    // -1 if unknown
+	PhysFnPCIERoot string // empty if unknown
+	VFIndex        int    // -1 if unknown
 }
 
 type Resources struct {
 	CPUs    cpuset.CPUSet
 	MEMs    cpuset.CPUSet
 	Devices []DeviceInfo
+	// Storage are the controllers backing the container mounts. They are kept apart from Devices because
+	// every container mounts something from the node disks (e.g. /etc/hosts), so only the storage check uses them.
+	Storage []DeviceInfo
+	// Resctrl is nil if the resctrl groups are unknown
+	Resctrl *resctrl.Info
+	// Limits is nil if the cgroup CPU limits are unknown
+	Limits *cgroups.Limits
+	// LimitsErr is why the cgroup CPU limits could not be read, nil if Limits is set or if the cgroup has no CPU limits
+	// because the CPU controller is not enabled
+	LimitsErr error
 }
 
 func Discover(env *environ.Environ) (Resources, error) {
@@ -49,8 +91,22 @@ func Discover(env *environ.Environ) (Resources, error) {
 	}
 	env.Log.V(2).Info("detected resources", "mems", mems)
 
+	var limits *cgroups.Limits
+	lim, limitsErr := cgroups.ReadLimits(env)
+	if errors.Is(limitsErr, fs.ErrNotExist) {
+		// the root cgroup has no cpu.max, nor the cgroups without the cpu controller: nothing to check
+		env.Log.V(1).Info("CPU controller not enabled, skipping CPU limits", "error", limitsErr)
+		limitsErr = nil
+	} else if limitsErr != nil {
+		env.Log.V(1).Info("cannot detect CPU limits, skipping", "error", limitsErr)
+	} else {
+		limits = &lim
+	}
+
 	return Resources{
-		CPUs: cpus,
-		MEMs: mems,
+		CPUs:      cpus,
+		MEMs:      mems,
+		Limits:    limits,
+		LimitsErr: limitsErr,
 	}, nil
 }
@@ -33,8 +33,11 @@ func TestDiscover(t *testing.T) {
 		path        string
 		cpuContent  string
 		memContent  string
+		cpuMax      string
 		expectedRes Resources
 		expectedErr bool
+		// expectedLimitsErr is true if the CPU limits discovery is expected to fail
+		expectedLimitsErr bool
 	}{
 		{
 			name:        "non-existent path",
@@ -50,6 +53,26 @@ func TestDiscover(t *testing.T) {
 				MEMs: cpuset.New(0),
 			},
 		},
+		{
+			name:       "CPU limits",
+			cpuContent: "0-3",
+			cpuMax:     "400000 100000",
+			expectedRes: Resources{
+				CPUs:   cpuset.New(0, 1, 2, 3),
+				MEMs:   cpuset.New(),
+				Limits: &cgroups.Limits{CPUQuota: 400000, CPUPeriod: 100000},
+			},
+		},
+		{
+			name:              "malformed CPU limits",
+			cpuContent:        "0-3",
+			cpuMax:            "lots 100000",
+			expectedLimitsErr: true,
+			expectedRes: Resources{
+				CPUs: cpuset.New(0, 1, 2, 3),
+				MEMs: cpuset.New(),
+			},
+		},
 		{
 			name:       "cpus with missing mems degrades gracefully",
 			cpuContent: "0-3",
@@ -93,6 +116,13 @@ func TestDiscover(t *testing.T) {
 						t.Fatalf("cannot prepare the fake data file at %v: %v", memPath, err)
 					}
 				}
+				if len(tt.cpuMax) > 0 {
+					cpuMaxPath := cgroups.CPUMaxPath(&env)
+					err = os.WriteFile(cpuMaxPath, []byte(tt.cpuMax), 0o644)
+					if err != nil {
+						t.Fatalf("cannot prepare the fake data file at %v: %v", cpuMaxPath, err)
+					}
+				}
 			} else {
 				t.Fatalf("neither path or content given; wrong test")
 			}
@@ -110,6 +140,16 @@ func TestDiscover(t *testing.T) {
 			if !got.MEMs.Equals(tt.expectedRes.MEMs) {
 				t.Fatalf("expected MEMs %v got %v", tt.expectedRes.MEMs, got.MEMs)
 			}
+			if err != nil {
+				return
+			}
+			// without cpu.max, like in the root cgroup, the limits are unknown but that's not a failure
+			if (got.LimitsErr != nil) != tt.expectedLimitsErr {
+				t.Fatalf("expected limits error %v got %v", tt.expectedLimitsErr, got.LimitsErr)
+			}
+			if (got.Limits == nil) != (tt.expectedRes.Limits == nil) || (got.Limits != nil && *got.Limits != *tt.expectedRes.Limits) {
+				t.Fatalf("expected limits %+v got %+v", tt.expectedRes.Limits, got.Limits)
+			}
 		})
 	}
 }
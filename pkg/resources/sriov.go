@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+// resolvePhysFn fills the details of the SR-IOV physical function, if the device is a virtual function.
+// When a VF is misaligned, the fix is usually in the SR-IOV configuration of the PF,
+// hence we need to report the PF properties alongside the VF ones.
+func resolvePhysFn(env *environ.Environ, dev *DeviceInfo) {
+	if dev.PhysFn == "" {
+		return
+	}
+	dev.PhysFnNetDev = readDeviceNetDev(env, dev.PhysFn)
+	dev.PhysFnNUMANode = readDeviceNUMANode(env, dev.PhysFn)
+	dev.PhysFnPCIERoot = readDevicePCIERoot(env, dev.PhysFn)
+	dev.VFIndex = readDeviceVFIndex(env, dev.PhysFn, dev.PCIAddress)
+	env.Log.V(2).Info("resolved physical function", "pciAddress", dev.PCIAddress, "physfn", dev.PhysFn, "vfIndex", dev.VFIndex, "physfnNetdev", dev.PhysFnNetDev, "physfnNUMANode", dev.PhysFnNUMANode, "physfnPCIERoot", dev.PhysFnPCIERoot)
+}
+
+// readDevicePhysFn returns the PCI address of the SR-IOV physical function, if the device is a virtual function.
+func readDevicePhysFn(env *environ.Environ, pciAddress string) string {
+	target, err := os.Readlink(filepath.Join(env.Root.Sys, "bus", "pci", "devices", pciAddress, "physfn"))
+	if err != nil {
+		// not a VF, which is the most common case
+		return ""
+	}
+	return filepath.Base(target)
+}
+
+// readDeviceVFIndex finds the index of the virtual function by looking at the virtfnN links of the physical function.
+func readDeviceVFIndex(env *environ.Environ, pfAddress, vfAddress string) int {
+	pfDir := filepath.Join(env.Root.Sys, "bus", "pci", "devices", pfAddress)
+	entries, err := os.ReadDir(pfDir)
+	if err != nil {
+		env.Log.V(1).Info("cannot list physical function, skipping", "pciAddress", pfAddress, "error", err)
+		return -1
+	}
+	for _, entry := range entries {
+		rest, ok := strings.CutPrefix(entry.Name(), "virtfn")
+		if !ok {
+			continue
+		}
+		target, err := os.Readlink(filepath.Join(pfDir, entry.Name()))
+		if err != nil || filepath.Base(target) != vfAddress {
+			continue
+		}
+		idx, err := strconv.Atoi(rest)
+		if err != nil {
+			continue
+		}
+		return idx
+	}
+	return -1
+}
+
+// readDeviceNetDev returns the first network interface backed by the device.
+// Network interfaces are namespaced, so we can't see the interfaces not in the container network namespace.
+func readDeviceNetDev(env *environ.Environ, pciAddress string) string {
+	entries, err := os.ReadDir(filepath.Join(env.Root.Sys, "bus", "pci", "devices", pciAddress, "net"))
+	if err != nil || len(entries) == 0 {
+		env.Log.V(4).Info("cannot find device network interface, skipping", "pciAddress", pciAddress)
+		return ""
+	}
+	return entries[0].Name()
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+func TestDiscoverDevicesFromEnvSRIOV(t *testing.T) {
+	tmpDir := t.TempDir()
+	env := &environ.Environ{
+		Root: environ.FS{Sys: tmpDir},
+		Log:  environ.DefaultLog(),
+	}
+
+	mustMkdirAll(t, filepath.Join(tmpDir, "bus", "pci", "devices"))
+	mustMkdirAll(t, filepath.Join(tmpDir, "bus", "pci", "drivers", "vfio-pci"))
+	mustMkdirAll(t, filepath.Join(tmpDir, "bus", "pci", "drivers", "iavf"))
+	mustMkdirAll(t, filepath.Join(tmpDir, "bus", "pci", "drivers", "ice"))
+
+	// the PF, with its netdev still in the host network namespace
+	pfPath := filepath.Join("devices", "pci0000:80", "0000:80:02.0", "0000:86:00.0")
+	mustWriteFile(t, filepath.Join(tmpDir, pfPath, "numa_node"), "1\n")
+	mustMkdirAll(t, filepath.Join(tmpDir, pfPath, "net", "ens5f0"))
+	mustSymlink(t, filepath.Join("..", "..", "..", "..", "bus", "pci", "drivers", "ice"), filepath.Join(tmpDir, pfPath, "driver"))
+	mustSymlink(t, filepath.Join("..", "..", "..", pfPath), filepath.Join(tmpDir, "bus", "pci", "devices", "0000:86:00.0"))
+
+	vfs := map[string]string{
+		"0000:86:01.0": "vfio-pci",
+		"0000:86:01.1": "iavf",
+	}
+	idx := 0
+	for _, addr := range []string{"0000:86:01.0", "0000:86:01.1"} {
+		vfPath := filepath.Join("devices", "pci0000:80", "0000:80:02.0", addr)
+		mustWriteFile(t, filepath.Join(tmpDir, vfPath, "numa_node"), "1\n")
+		mustSymlink(t, filepath.Join("..", "0000:86:00.0"), filepath.Join(tmpDir, vfPath, "physfn"))
+		mustSymlink(t, filepath.Join("..", "..", "..", "..", "bus", "pci", "drivers", vfs[addr]), filepath.Join(tmpDir, vfPath, "driver"))
+		mustSymlink(t, filepath.Join("..", addr), filepath.Join(tmpDir, pfPath, fmt.Sprintf("virtfn%d", idx)))
+		mustSymlink(t, filepath.Join("..", "..", "..", vfPath), filepath.Join(tmpDir, "bus", "pci", "devices", addr))
+		idx++
+	}
+
+	expected := []DeviceInfo{
+		{PCIAddress: "0000:86:00.0", Driver: "ice", NUMANode: 1, PCIERoot: "pci0000:80", VFIndex: -1},
+		{PCIAddress: "0000:86:01.0", Driver: "vfio-pci", NUMANode: 1, PCIERoot: "pci0000:80", PhysFn: "0000:86:00.0", PhysFnNetDev: "ens5f0", PhysFnNUMANode: 1, PhysFnPCIERoot: "pci0000:80", VFIndex: 0},
+		{PCIAddress: "0000:86:01.1", Driver: "iavf", NUMANode: 1, PCIERoot: "pci0000:80", PhysFn: "0000:86:00.0", PhysFnNetDev: "ens5f0", PhysFnNUMANode: 1, PhysFnPCIERoot: "pci0000:80", VFIndex: 1},
+	}
+
+	got := DiscoverDevicesFromEnv(env, []string{"PCIDEVICE_INTEL_COM_SRIOV=0000:86:00.0,0000:86:01.0,0000:86:01.1"}, []string{"PCIDEVICE_"})
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d devices, got %d: %+v", len(expected), len(got), got)
+	}
+	for i, exp := range expected {
+		if got[i].PCIAddress != exp.PCIAddress {
+			t.Errorf("device[%d] PCIAddress: expected %q, got %q", i, exp.PCIAddress, got[i].PCIAddress)
+		}
+		if got[i].Driver != exp.Driver {
+			t.Errorf("device[%d] Driver: expected %q, got %q", i, exp.Driver, got[i].Driver)
+		}
+		if got[i].NUMANode != exp.NUMANode {
+			t.Errorf("device[%d] NUMANode: expected %d, got %d", i, exp.NUMANode, got[i].NUMANode)
+		}
+		if got[i].PCIERoot != exp.PCIERoot {
+			t.Errorf("device[%d] PCIERoot: expected %q, got %q", i, exp.PCIERoot, got[i].PCIERoot)
+		}
+		if got[i].PhysFn != exp.PhysFn {
+			t.Errorf("device[%d] PhysFn: expected %q, got %q", i, exp.PhysFn, got[i].PhysFn)
+		}
+		if got[i].PhysFnNetDev != exp.PhysFnNetDev {
+			t.Errorf("device[%d] PhysFnNetDev: expected %q, got %q", i, exp.PhysFnNetDev, got[i].PhysFnNetDev)
+		}
+		if exp.PhysFn != "" && got[i].PhysFnNUMANode != exp.PhysFnNUMANode {
+			t.Errorf("device[%d] PhysFnNUMANode: expected %d, got %d", i, exp.PhysFnNUMANode, got[i].PhysFnNUMANode)
+		}
+		if got[i].PhysFnPCIERoot != exp.PhysFnPCIERoot {
+			t.Errorf("device[%d] PhysFnPCIERoot: expected %q, got %q", i, exp.PhysFnPCIERoot, got[i].PhysFnPCIERoot)
+		}
+		if got[i].VFIndex != exp.VFIndex {
+			t.Errorf("device[%d] VFIndex: expected %d, got %d", i, exp.VFIndex, got[i].VFIndex)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+const (
+	DefaultVFIODevDir = "/dev/vfio"
+)
+
+// DiscoverVFIOGroups returns the IOMMU group IDs of the /dev/vfio/<group> nodes available in the container.
+// The /dev/vfio/vfio container node and the /dev/vfio/devices iommufd directory are skipped.
+func DiscoverVFIOGroups(env *environ.Environ, devDir string) []string {
+	entries, err := os.ReadDir(devDir)
+	if err != nil {
+		env.Log.V(1).Info("cannot list vfio devices, skipping", "dir", devDir, "error", err)
+		return nil
+	}
+	var groups []string
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil {
+			continue
+		}
+		groups = append(groups, entry.Name())
+	}
+	slices.SortFunc(groups, func(a, b string) int {
+		// we already know these are valid integers
+		x, _ := strconv.Atoi(a)
+		y, _ := strconv.Atoi(b)
+		return x - y
+	})
+	env.Log.V(2).Info("discovered vfio groups", "groups", groups)
+	return groups
+}
@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+func TestDiscoverVFIOGroups(t *testing.T) {
+	env := &environ.Environ{
+		Log: environ.DefaultLog(),
+	}
+
+	devDir := t.TempDir()
+	for _, name := range []string{"vfio", "104", "12", "7"} {
+		mustWriteFile(t, filepath.Join(devDir, name), "")
+	}
+	mustMkdirAll(t, filepath.Join(devDir, "devices"))
+
+	got := DiscoverVFIOGroups(env, devDir)
+	if diff := cmp.Diff([]string{"7", "12", "104"}, got); diff != "" {
+		t.Errorf("vfio groups mismatch (-want +got):\n%s", diff)
+	}
+
+	if got := DiscoverVFIOGroups(env, filepath.Join(devDir, "missing")); got != nil {
+		t.Errorf("expected no groups from a missing dir, got %v", got)
+	}
+}
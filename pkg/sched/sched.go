@@ -0,0 +1,278 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package sched
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+// Thread is a snapshot of the scheduler state and counters of a thread.
+type Thread struct {
+	PID  int
+	TID  int
+	Comm string
+	// Processor is the CPU the thread last ran on
+	Processor int
+	// Affinity are the CPUs the thread is allowed to run on
+	Affinity            cpuset.CPUSet
+	VoluntarySwitches   uint64
+	InvoluntarySwitches uint64
+	// Migrations is -1 if unknown, because the kernel is built without CONFIG_SCHED_DEBUG
+	Migrations int64
+	// RunTimeNS and WaitTimeNS are the time spent running and waiting on a runqueue, zero if unknown
+	RunTimeNS  uint64
+	WaitTimeNS uint64
+}
+
+func TaskPath(env *environ.Environ, pid int) string {
+	return filepath.Join(env.Root.Proc, strconv.Itoa(pid), "task")
+}
+
+// ReadThreads reads all the threads of the process. Threads exiting while being read are skipped.
+func ReadThreads(env *environ.Environ, pid int) ([]Thread, error) {
+	taskDir := TaskPath(env, pid)
+	entries, err := os.ReadDir(taskDir)
+	if err != nil {
+		env.Log.V(1).Info("failed to read threads", "path", taskDir, "error", err)
+		return nil, err
+	}
+	var threads []Thread
+	for _, entry := range entries {
+		tid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		th, err := ReadThread(env, pid, tid)
+		if errors.Is(err, fs.ErrNotExist) {
+			env.Log.V(4).Info("thread gone", "pid", pid, "tid", tid)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		threads = append(threads, th)
+	}
+	return threads, nil
+}
+
+func ReadThread(env *environ.Environ, pid, tid int) (Thread, error) {
+	threadDir := filepath.Join(TaskPath(env, pid), strconv.Itoa(tid))
+	th := Thread{
+		PID:        pid,
+		TID:        tid,
+		Migrations: -1,
+	}
+
+	data, err := os.ReadFile(filepath.Join(threadDir, "stat"))
+	if err != nil {
+		return Thread{}, err
+	}
+	th.Comm, th.Processor, err = parseStat(string(data))
+	if err != nil {
+		return Thread{}, fmt.Errorf("thread %d: %w", tid, err)
+	}
+
+	err = readStatus(filepath.Join(threadDir, "status"), &th)
+	if err != nil {
+		return Thread{}, fmt.Errorf("thread %d: %w", tid, err)
+	}
+
+	// sched and schedstat depend on the kernel configuration
+	data, err = os.ReadFile(filepath.Join(threadDir, "sched"))
+	if err == nil {
+		th.Migrations, err = parseSchedMigrations(string(data))
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		env.Log.V(1).Info("cannot read the thread migrations", "pid", pid, "tid", tid, "error", err)
+	}
+	data, err = os.ReadFile(filepath.Join(threadDir, "schedstat"))
+	if err == nil {
+		_, err = fmt.Sscanf(string(data), "%d %d", &th.RunTimeNS, &th.WaitTimeNS)
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		env.Log.V(1).Info("cannot read the thread schedstat", "pid", pid, "tid", tid, "error", err)
+	}
+
+	env.Log.V(4).Info("thread state", "pid", pid, "tid", tid, "comm", th.Comm, "processor", th.Processor, "migrations", th.Migrations)
+	return th, nil
+}
+
+// parseStat extracts the command and the last CPU (processor, field 39) from the stat file.
+// The command is enclosed in parens but can include spaces and parens itself.
+func parseStat(data string) (string, int, error) {
+	start := strings.IndexByte(data, '(')
+	end := strings.LastIndexByte(data, ')')
+	if start < 0 || end < start {
+		return "", 0, fmt.Errorf("malformed stat: %q", data)
+	}
+	// the fields after the command start from the state, which is field 3
+	fields := strings.Fields(data[end+1:])
+	const processorIdx = 39 - 3
+	if len(fields) <= processorIdx {
+		return "", 0, fmt.Errorf("truncated stat: %d fields", len(fields)+2)
+	}
+	processor, err := strconv.Atoi(fields[processorIdx])
+	if err != nil {
+		return "", 0, err
+	}
+	return data[start+1 : end], processor, nil
+}
+
+func readStatus(path string, th *Thread) error {
+	fh, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		key, val, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		switch key {
+		case "Cpus_allowed_list":
+			th.Affinity, err = cpuset.Parse(val)
+		case "voluntary_ctxt_switches":
+			th.VoluntarySwitches, err = strconv.ParseUint(val, 10, 64)
+		case "nonvoluntary_ctxt_switches":
+			th.InvoluntarySwitches, err = strconv.ParseUint(val, 10, 64)
+		}
+		if err != nil {
+			return fmt.Errorf("malformed status %s: %w", key, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func parseSchedMigrations(data string) (int64, error) {
+	for _, line := range strings.Split(data, "\n") {
+		key, val, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(key) != "se.nr_migrations" {
+			continue
+		}
+		return strconv.ParseInt(strings.TrimSpace(val), 10, 64)
+	}
+	return -1, nil
+}
+
+// ThreadWindow tracks a thread over a sampling window.
+type ThreadWindow struct {
+	First Thread
+	Last  Thread
+	// CPUs are all the CPUs the thread was observed running on
+	CPUs    cpuset.CPUSet
+	Samples int
+}
+
+// Migrations returns the migrations across the window, -1 if unknown.
+func (tw ThreadWindow) Migrations() int64 {
+	if tw.First.Migrations < 0 || tw.Last.Migrations < 0 {
+		return -1
+	}
+	return tw.Last.Migrations - tw.First.Migrations
+}
+
+// Delta returns a copy of Last whose counters are the changes across the window.
+func (tw ThreadWindow) Delta() Thread {
+	th := tw.Last
+	th.VoluntarySwitches -= tw.First.VoluntarySwitches
+	th.InvoluntarySwitches -= tw.First.InvoluntarySwitches
+	th.Migrations = tw.Migrations()
+	th.RunTimeNS -= tw.First.RunTimeNS
+	th.WaitTimeNS -= tw.First.WaitTimeNS
+	return th
+}
+
+// Window collects the samples of a set of threads.
+type Window struct {
+	Start   time.Time
+	End     time.Time
+	Samples int
+	// Threads maps the thread IDs to their samples
+	Threads map[int]*ThreadWindow
+}
+
+func NewWindow() *Window {
+	return &Window{
+		Threads: make(map[int]*ThreadWindow),
+	}
+}
+
+// Add records a sample of the threads. Threads seen for the first time start their own window.
+func (win *Window) Add(ts time.Time, threads []Thread) {
+	if win.Samples == 0 {
+		win.Start = ts
+	}
+	win.End = ts
+	win.Samples++
+	for _, th := range threads {
+		tw, ok := win.Threads[th.TID]
+		if !ok {
+			tw = &ThreadWindow{
+				First: th,
+				CPUs:  cpuset.New(),
+			}
+			win.Threads[th.TID] = tw
+		}
+		tw.Last = th
+		tw.CPUs = tw.CPUs.Union(cpuset.New(th.Processor))
+		tw.Samples++
+	}
+}
+
+// TIDs returns the sorted IDs of the sampled threads.
+func (win *Window) TIDs() []int {
+	tids := make([]int, 0, len(win.Threads))
+	for tid := range win.Threads {
+		tids = append(tids, tid)
+	}
+	slices.Sort(tids)
+	return tids
+}
+
+// Sample samples the threads of the processes every interval for the duration of the window.
+// There is always at least one sample. Processes which can't be read are logged and skipped;
+// it is an error if no process can be read in the first sample.
+func Sample(env *environ.Environ, pids []int, window, interval time.Duration) (*Window, error) {
+	win := NewWindow()
+	deadline := time.Now().Add(window)
+	for {
+		var threads []Thread
+		var errs []error
+		for _, pid := range pids {
+			procThreads, err := ReadThreads(env, pid)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			threads = append(threads, procThreads...)
+		}
+		if win.Samples == 0 && len(errs) > 0 && len(errs) == len(pids) {
+			return nil, errors.Join(errs...)
+		}
+		now := time.Now()
+		win.Add(now, threads)
+		if !now.Before(deadline) {
+			break
+		}
+		time.Sleep(min(interval, deadline.Sub(now)))
+	}
+	env.Log.V(2).Info("sampled threads", "samples", win.Samples, "threads", len(win.Threads), "window", win.End.Sub(win.Start))
+	return win, nil
+}
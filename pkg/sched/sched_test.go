@@ -0,0 +1,205 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package sched
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/utils/cpuset"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+var cpusetComparer = cmp.Comparer(func(a, b cpuset.CPUSet) bool { return a.Equals(b) })
+
+func TestReadThreads(t *testing.T) {
+	env := environ.Environ{
+		Root: environ.FS{
+			Proc: t.TempDir(),
+		},
+		Log: environ.DefaultLog(),
+	}
+	taskDir := TaskPath(&env, 100)
+	mustWriteThread(t, taskDir, 100, "app", 4, map[string]string{
+		"status":    "Name:\tapp\nCpus_allowed_list:\t4-5\nvoluntary_ctxt_switches:\t12\nnonvoluntary_ctxt_switches:\t3\n",
+		"sched":     "app (100, #threads: 2)\n-------------------\nse.exec_start : 1234.5\nse.nr_migrations : 7\nnr_switches : 15\n",
+		"schedstat": "5000000 20000 15\n",
+	})
+	// no sched and schedstat, as on kernels without CONFIG_SCHED_DEBUG and CONFIG_SCHED_INFO
+	mustWriteThread(t, taskDir, 101, "worker (1)", 5, map[string]string{
+		"status": "Name:\tworker (1)\nCpus_allowed_list:\t5\nvoluntary_ctxt_switches:\t1\nnonvoluntary_ctxt_switches:\t0\n",
+	})
+
+	got, err := ReadThreads(&env, 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Thread{
+		{
+			PID:                 100,
+			TID:                 100,
+			Comm:                "app",
+			Processor:           4,
+			Affinity:            cpuset.New(4, 5),
+			VoluntarySwitches:   12,
+			InvoluntarySwitches: 3,
+			Migrations:          7,
+			RunTimeNS:           5000000,
+			WaitTimeNS:          20000,
+		},
+		{
+			PID:               100,
+			TID:               101,
+			Comm:              "worker (1)",
+			Processor:         5,
+			Affinity:          cpuset.New(5),
+			VoluntarySwitches: 1,
+			Migrations:        -1,
+		},
+	}
+	if diff := cmp.Diff(expected, got, cpusetComparer); diff != "" {
+		t.Fatalf("unexpected threads (-want +got):\n%s", diff)
+	}
+
+	_, err = ReadThreads(&env, 200)
+	if err == nil {
+		t.Fatalf("expected error reading a missing process")
+	}
+	_, err = Sample(&env, []int{200}, 0, time.Millisecond)
+	if err == nil {
+		t.Fatalf("expected error sampling a missing process")
+	}
+
+	win, err := Sample(&env, []int{100, 200}, 0, time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if win.Samples != 1 || len(win.Threads) != 2 {
+		t.Fatalf("unexpected window: samples=%d threads=%d", win.Samples, len(win.Threads))
+	}
+}
+
+func TestParseStat(t *testing.T) {
+	testCases := []struct {
+		name              string
+		data              string
+		expectedComm      string
+		expectedProcessor int
+		expectedErr       bool
+	}{
+		{
+			name:              "simple",
+			data:              statLine(42, "sleep", 3),
+			expectedComm:      "sleep",
+			expectedProcessor: 3,
+		},
+		{
+			name:              "command with spaces and parens",
+			data:              statLine(42, "a) (b c", 17),
+			expectedComm:      "a) (b c",
+			expectedProcessor: 17,
+		},
+		{
+			name:        "truncated",
+			data:        "42 (sleep) S 1 42 42",
+			expectedErr: true,
+		},
+		{
+			name:        "malformed",
+			data:        "42 sleep S 1",
+			expectedErr: true,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			comm, processor, err := parseStat(tt.data)
+			if tt.expectedErr {
+				if err == nil {
+					t.Fatalf("expected error, got success")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if comm != tt.expectedComm || processor != tt.expectedProcessor {
+				t.Fatalf("got comm=%q processor=%d expected comm=%q processor=%d", comm, processor, tt.expectedComm, tt.expectedProcessor)
+			}
+		})
+	}
+}
+
+func TestWindow(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	win := NewWindow()
+	win.Add(start, []Thread{
+		{PID: 1, TID: 1, Processor: 2, VoluntarySwitches: 10, Migrations: 3, RunTimeNS: 1000},
+	})
+	win.Add(start.Add(time.Second), []Thread{
+		{PID: 1, TID: 1, Processor: 18, VoluntarySwitches: 15, InvoluntarySwitches: 1, Migrations: 5, RunTimeNS: 3000},
+		{PID: 1, TID: 2, Processor: 3, Migrations: -1},
+	})
+	win.Add(start.Add(2*time.Second), []Thread{
+		{PID: 1, TID: 1, Processor: 2, VoluntarySwitches: 20, InvoluntarySwitches: 2, Migrations: 6, RunTimeNS: 6000},
+		{PID: 1, TID: 2, Processor: 3, Migrations: -1},
+	})
+
+	if win.Samples != 3 || win.End.Sub(win.Start) != 2*time.Second {
+		t.Fatalf("unexpected window: samples=%d duration=%v", win.Samples, win.End.Sub(win.Start))
+	}
+	if diff := cmp.Diff([]int{1, 2}, win.TIDs()); diff != "" {
+		t.Fatalf("unexpected thread IDs (-want +got):\n%s", diff)
+	}
+
+	tw := win.Threads[1]
+	if !tw.CPUs.Equals(cpuset.New(2, 18)) || tw.Samples != 3 {
+		t.Fatalf("unexpected thread 1 window: cpus=%v samples=%d", tw.CPUs, tw.Samples)
+	}
+	expected := Thread{PID: 1, TID: 1, Processor: 2, VoluntarySwitches: 10, InvoluntarySwitches: 2, Migrations: 3, RunTimeNS: 5000}
+	if diff := cmp.Diff(expected, tw.Delta(), cpusetComparer); diff != "" {
+		t.Fatalf("unexpected thread 1 delta (-want +got):\n%s", diff)
+	}
+
+	tw = win.Threads[2]
+	if !tw.CPUs.Equals(cpuset.New(3)) || tw.Samples != 2 || tw.Migrations() != -1 {
+		t.Fatalf("unexpected thread 2 window: cpus=%v samples=%d migrations=%d", tw.CPUs, tw.Samples, tw.Migrations())
+	}
+}
+
+// statLine builds a stat file content with the given command and processor, all the other fields are zero.
+func statLine(tid int, comm string, processor int) string {
+	fields := make([]string, 52-2)
+	for idx := range fields {
+		fields[idx] = "0"
+	}
+	fields[0] = "S"
+	fields[39-3] = strconv.Itoa(processor)
+	return strconv.Itoa(tid) + " (" + comm + ") " + strings.Join(fields, " ") + "\n"
+}
+
+func mustWriteThread(t *testing.T, taskDir string, tid int, comm string, processor int, files map[string]string) {
+	t.Helper()
+	threadDir := filepath.Join(taskDir, strconv.Itoa(tid))
+	err := os.MkdirAll(threadDir, os.ModePerm)
+	if err != nil {
+		t.Fatalf("cannot prepare the fake thread dir at %v: %v", threadDir, err)
+	}
+	mustWriteFile(t, filepath.Join(threadDir, "stat"), statLine(tid, comm, processor))
+	for name, content := range files {
+		mustWriteFile(t, filepath.Join(threadDir, name), content)
+	}
+}
+
+func mustWriteFile(t *testing.T, path, content string) {
+	t.Helper()
+	err := os.WriteFile(path, []byte(content), 0o644)
+	if err != nil {
+		t.Fatalf("cannot write %v: %v", path, err)
+	}
+}
@@ -0,0 +1,22 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package version
+
+import (
+	"runtime/debug"
+)
+
+// version is set at build time, see the Makefile
+var version string
+
+// Get returns the version of the tool, falling back to the module version recorded in the binary.
+func Get() string {
+	if version != "" {
+		return version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	return info.Main.Version
+}
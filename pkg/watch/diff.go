@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package watch
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	apiv1 "github.com/ffromani/ctrreschk/api/v1"
+)
+
+// Diff compares the JSON representation of two reports and returns the changes, ordered by path.
+// Arrays are compared by index. No changes means the reports are equivalent.
+func Diff(prev, cur any) ([]apiv1.Change, error) {
+	prevVal, err := toGeneric(prev)
+	if err != nil {
+		return nil, err
+	}
+	curVal, err := toGeneric(cur)
+	if err != nil {
+		return nil, err
+	}
+	return diffValues(nil, "", prevVal, curVal), nil
+}
+
+func toGeneric(obj any) (any, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var val any
+	err = json.Unmarshal(data, &val)
+	return val, err
+}
+
+func diffValues(changes []apiv1.Change, path string, prev, cur any) []apiv1.Change {
+	switch prevVal := prev.(type) {
+	case map[string]any:
+		curVal, ok := cur.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(prevVal)+len(curVal))
+		for key := range prevVal {
+			keys = append(keys, key)
+		}
+		for key := range curVal {
+			if _, ok := prevVal[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			changes = diffMember(changes, path+"/"+escapePointer(key), prevVal, curVal, key)
+		}
+		return changes
+	case []any:
+		curVal, ok := cur.([]any)
+		if !ok {
+			break
+		}
+		for idx := 0; idx < max(len(prevVal), len(curVal)); idx++ {
+			itemPath := path + "/" + strconv.Itoa(idx)
+			switch {
+			case idx >= len(curVal):
+				changes = append(changes, apiv1.Change{Path: itemPath, Old: prevVal[idx]})
+			case idx >= len(prevVal):
+				changes = append(changes, apiv1.Change{Path: itemPath, New: curVal[idx]})
+			default:
+				changes = diffValues(changes, itemPath, prevVal[idx], curVal[idx])
+			}
+		}
+		return changes
+	}
+	if reflect.DeepEqual(prev, cur) {
+		return changes
+	}
+	return append(changes, apiv1.Change{Path: path, Old: prev, New: cur})
+}
+
+func diffMember(changes []apiv1.Change, path string, prev, cur map[string]any, key string) []apiv1.Change {
+	prevVal, prevOk := prev[key]
+	curVal, curOk := cur[key]
+	if !curOk {
+		return append(changes, apiv1.Change{Path: path, Old: prevVal})
+	}
+	if !prevOk {
+		return append(changes, apiv1.Change{Path: path, New: curVal})
+	}
+	return diffValues(changes, path, prevVal, curVal)
+}
+
+// escapePointer escapes the key as JSON pointer reference token (RFC 6901)
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package watch
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	apiv1 "github.com/ffromani/ctrreschk/api/v1"
+)
+
+func TestDiff(t *testing.T) {
+	type report struct {
+		Status string            `json:"status"`
+		CPUs   []int             `json:"cpus,omitempty"`
+		Nodes  map[string][]int  `json:"nodes,omitempty"`
+		Labels map[string]string `json:"labels,omitempty"`
+	}
+
+	testCases := []struct {
+		name     string
+		prev     report
+		cur      report
+		expected []apiv1.Change
+	}{
+		{
+			name: "identical",
+			prev: report{Status: "aligned", CPUs: []int{2, 3}, Nodes: map[string][]int{"0": {2, 3}}},
+			cur:  report{Status: "aligned", CPUs: []int{2, 3}, Nodes: map[string][]int{"0": {2, 3}}},
+		},
+		{
+			name: "changed scalar",
+			prev: report{Status: "aligned"},
+			cur:  report{Status: "unaligned"},
+			expected: []apiv1.Change{
+				{Path: "/status", Old: "aligned", New: "unaligned"},
+			},
+		},
+		{
+			name: "array grown and changed",
+			prev: report{Status: "aligned", CPUs: []int{2, 3}},
+			cur:  report{Status: "aligned", CPUs: []int{2, 4, 5}},
+			expected: []apiv1.Change{
+				{Path: "/cpus/1", Old: float64(3), New: float64(4)},
+				{Path: "/cpus/2", New: float64(5)},
+			},
+		},
+		{
+			name: "array shrunk",
+			prev: report{Status: "aligned", CPUs: []int{2, 3}},
+			cur:  report{Status: "aligned", CPUs: []int{2}},
+			expected: []apiv1.Change{
+				{Path: "/cpus/1", Old: float64(3)},
+			},
+		},
+		{
+			name: "members added and removed",
+			prev: report{Status: "aligned", Nodes: map[string][]int{"0": {2, 3}}},
+			cur:  report{Status: "aligned", Nodes: map[string][]int{"1": {18}}, Labels: map[string]string{"a/b~c": "x"}},
+			expected: []apiv1.Change{
+				{Path: "/labels", New: map[string]any{"a/b~c": "x"}},
+				{Path: "/nodes/0", Old: []any{float64(2), float64(3)}},
+				{Path: "/nodes/1", New: []any{float64(18)}},
+			},
+		},
+		{
+			name: "escaped keys",
+			prev: report{Status: "aligned", Labels: map[string]string{"a/b~c": "x"}},
+			cur:  report{Status: "aligned", Labels: map[string]string{"a/b~c": "y"}},
+			expected: []apiv1.Change{
+				{Path: "/labels/a~1b~0c", Old: "x", New: "y"},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Diff(tt.prev, tt.cur)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(got, tt.expected); diff != "" {
+				t.Errorf("unexpected changes: %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package watch
+
+import (
+	"errors"
+	"os"
+	"syscall"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+const (
+	// inotifyMask catches both the in-place updates and the replacement of the file
+	inotifyMask = syscall.IN_MODIFY | syscall.IN_ATTRIB | syscall.IN_CLOSE_WRITE | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+)
+
+// FileNotifier reports the changes of a set of files using inotify.
+// The kernel may coalesce or not emit notifications for some pseudo-files, so
+// FileNotifier should complement, not replace, a periodic check.
+type FileNotifier struct {
+	file   *os.File
+	events chan struct{}
+}
+
+// NewFileNotifier starts watching the given paths. Paths which can't be watched are logged
+// and skipped; it is an error if none of the paths can be watched.
+func NewFileNotifier(env *environ.Environ, paths ...string) (*FileNotifier, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	// nonblocking fds are added to the runtime poller, so Close unblocks the pending reads
+	file := os.NewFile(uintptr(fd), "inotify")
+	watched := 0
+	for _, path := range paths {
+		_, err := syscall.InotifyAddWatch(fd, path, inotifyMask)
+		if err != nil {
+			env.Log.V(1).Info("cannot watch file", "path", path, "error", err)
+			continue
+		}
+		env.Log.V(2).Info("watching file", "path", path)
+		watched++
+	}
+	if watched == 0 {
+		file.Close()
+		return nil, errors.New("no file can be watched")
+	}
+	fn := FileNotifier{
+		file:   file,
+		events: make(chan struct{}, 1),
+	}
+	go fn.run(env)
+	return &fn, nil
+}
+
+// Events returns a channel which receives a value when any of the watched files changes.
+// Events happening while the previous one is not consumed yet are merged. The channel is
+// closed once the notifier is closed.
+func (fn *FileNotifier) Events() <-chan struct{} {
+	return fn.events
+}
+
+func (fn *FileNotifier) Close() error {
+	return fn.file.Close()
+}
+
+func (fn *FileNotifier) run(env *environ.Environ) {
+	defer close(fn.events)
+	buf := make([]byte, 4096)
+	for {
+		_, err := fn.file.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				env.Log.V(1).Info("cannot read file notifications", "error", err)
+			}
+			return
+		}
+		// we don't care which file changed, the caller needs to re-check everything anyway
+		select {
+		case fn.events <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ffromani/ctrreschk/pkg/environ"
+)
+
+func TestFileNotifier(t *testing.T) {
+	env := environ.Environ{
+		Log: environ.DefaultLog(),
+	}
+	path := filepath.Join(t.TempDir(), "cpuset.cpus.effective")
+	mustWriteFile(t, path, "2-3\n")
+
+	fn, err := NewFileNotifier(&env, path, filepath.Join(t.TempDir(), "missing"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mustWriteFile(t, path, "2-5\n")
+	select {
+	case <-fn.Events():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no event after the file changed")
+	}
+
+	err = fn.Close()
+	if err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+	select {
+	case _, ok := <-fn.Events():
+		// a pending event may be delivered before the channel is closed
+		if ok {
+			_, ok = <-fn.Events()
+		}
+		if ok {
+			t.Fatalf("events channel not closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("events channel not closed after close")
+	}
+}
+
+func TestFileNotifierNoFiles(t *testing.T) {
+	env := environ.Environ{
+		Log: environ.DefaultLog(),
+	}
+	_, err := NewFileNotifier(&env, filepath.Join(t.TempDir(), "missing"))
+	if err == nil {
+		t.Fatalf("expected error watching only missing files")
+	}
+}
+
+func mustWriteFile(t *testing.T, path, content string) {
+	t.Helper()
+	err := os.WriteFile(path, []byte(content), 0o644)
+	if err != nil {
+		t.Fatalf("cannot write %v: %v", path, err)
+	}
+}
@@ -30,7 +30,7 @@ var _ = Describe("ctrreschk alignment", func() {
 						Name:            "ctrreschk",
 						Image:           testImage,
 						ImagePullPolicy: corev1.PullIfNotPresent,
-						Command:         []string{"/ctrreschk", "align"},
+						Command:         []string{"/ctrreschk", "align", "--api-version=v0"},
 						Resources: corev1.ResourceRequirements{
 							Requests: corev1.ResourceList{
 								corev1.ResourceCPU:    resource.MustParse("2"),
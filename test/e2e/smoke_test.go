@@ -12,7 +12,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	apiv0 "github.com/ffromani/ctrreschk/api/v0"
+	apiv1 "github.com/ffromani/ctrreschk/api/v1"
 )
 
 var _ = Describe("ctrreschk smoke test", func() {
@@ -44,8 +44,11 @@ var _ = Describe("ctrreschk smoke test", func() {
 		logs := getPodLogs(ctx, created.Namespace, created.Name)
 		Expect(logs).NotTo(BeEmpty(), "pod logs should not be empty")
 
-		var result apiv0.Allocation
+		var result apiv1.Allocation
 		err := json.Unmarshal([]byte(logs), &result)
 		Expect(err).NotTo(HaveOccurred(), "pod output should be valid JSON, got: %s", logs)
+		Expect(result.APIVersion).To(Equal(apiv1.APIVersion))
+		Expect(result.Kind).To(Equal(apiv1.KindAllocation))
+		Expect(result.Checks).NotTo(BeEmpty(), "checks should be reported")
 	})
 })